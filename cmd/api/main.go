@@ -14,16 +14,24 @@ import (
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 
+	"go-server-boilerplate/internal/app/events"
+	"go-server-boilerplate/internal/app/ports"
 	"go-server-boilerplate/internal/app/services"
 	"go-server-boilerplate/internal/config"
 	"go-server-boilerplate/internal/infrastructure/auth"
+	"go-server-boilerplate/internal/infrastructure/cache"
 	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
 	"go-server-boilerplate/internal/infrastructure/database/models"
 	"go-server-boilerplate/internal/infrastructure/jobs"
+	"go-server-boilerplate/internal/infrastructure/outbox"
 
 	"go-server-boilerplate/internal/interfaces/api"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+	"go-server-boilerplate/internal/pkg/health"
 	"go-server-boilerplate/internal/pkg/logger"
 	"go-server-boilerplate/internal/pkg/middleware"
+	"go-server-boilerplate/internal/pkg/resilience"
 )
 
 // @title Go Server Boilerplate API
@@ -38,6 +46,10 @@ import (
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
 
+// version is reported by the health endpoints; set it at build time with
+// -ldflags "-X main.version=..."
+var version = "1.0.0"
+
 // @host localhost:8080
 // @BasePath /api/v1
 // @schemes http https
@@ -73,15 +85,60 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Initialize database connection
+	// Encrypt personal data at rest with the configured keys; in development
+	// and test a development keyring is used when none are configured
+	if cfg.Encryption.Configured() {
+		keyring, err := fieldcrypt.Load(cfg.Encryption.Keys, cfg.Encryption.KeysDir, cfg.Encryption.PrimaryKey, cfg.Encryption.BlindIndexKey)
+		if err != nil {
+			logger.Fatal("Failed to load encryption keys", zap.Error(err))
+		}
+		encryption.UseKeyring(keyring)
+		logger.Info("Loaded encryption keys", zap.String("primary_key", keyring.Primary()))
+	} else {
+		logger.Warn("No encryption keys configured; personal data is encrypted with the development keyring")
+	}
+
+	// Aggregate statement latencies by fingerprint for the admin API
+	var queryStats *database.QueryStats
+	if cfg.Database.QueryStats {
+		queryStats = database.NewQueryStats()
+	}
+
+	// Guard the primary with a circuit breaker so that requests fail fast
+	// while the database is unreachable
+	var dbBreaker *resilience.CircuitBreaker
+	if cfg.Database.BreakerThreshold > 0 {
+		dbBreaker = database.NewCircuitBreaker("database",
+			resilience.WithFailureThreshold(cfg.Database.BreakerThreshold),
+			resilience.WithOpenTimeout(cfg.Database.BreakerTimeout),
+			resilience.WithStateChange(func(from, to resilience.State) {
+				logger.Warn("Database circuit breaker state changed",
+					zap.Stringer("from", from),
+					zap.Stringer("to", to),
+				)
+			}),
+		)
+	}
+
+	// Initialize database connection, waiting for it to come up
 	dbConfig := database.Config{
 		URL:                cfg.Database.URL,
 		MaxConnections:     cfg.Database.MaxConnections,
 		MaxIdleConns:       cfg.Database.MaxIdleConnections,
 		ConnMaxLifetime:    cfg.Database.ConnMaxLifetime,
 		AutoMigrate:        cfg.Database.AutoMigrate,
+		Migrate:            cfg.Database.Migrate,
 		LogQueries:         cfg.Database.LogQueries,
 		PreparedStatements: cfg.Database.PreparedStatements,
+		SlowQueryThreshold: cfg.Database.SlowQueryThreshold,
+		QueryStats:         queryStats,
+		ConnectAttempts:    cfg.Database.ConnectAttempts,
+		ConnectBackoff: resilience.Backoff{
+			Initial: cfg.Database.ConnectBackoff,
+			Max:     cfg.Database.ConnectMaxBackoff,
+			Jitter:  true,
+		},
+		Breaker: dbBreaker,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -89,52 +146,241 @@ func main() {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
 
+	// Encrypt and index the users written before encryption was enabled
+	// before serving requests: until they have a blind index, nothing stops
+	// new users from taking their email
+	backfilled, err := database.NewReencryptor[models.User](db, cfg.Encryption.RotationBatchSize).Backfill(ctx)
+	if err != nil {
+		logger.Fatal("Failed to backfill user blind indexes", zap.Error(err))
+	}
+	if backfilled > 0 {
+		logger.Info("Backfilled user blind indexes", zap.Int64("count", backfilled))
+	}
+
+	// Record entity changes in the audit log
+	if cfg.Features.AuditLog {
+		if err := database.RegisterAuditCallbacks(db); err != nil {
+			logger.Fatal("Failed to register audit callbacks", zap.Error(err))
+		}
+	}
+
 	// Use ctx in a database ping to silence unused variable
 	sqlDB, err := db.DB()
 	if err == nil {
 		_ = sqlDB.PingContext(ctx)
 	}
 
+	// Register the checks behind the liveness, readiness and startup probes
+	healthRegistry := health.NewRegistry(health.WithCacheTTL(cfg.Health.CacheTTL))
+	checkTimeout := health.WithTimeout(cfg.Health.CheckTimeout)
+	healthRegistry.Register("database", database.HealthCheck(db), checkTimeout)
+	if cfg.Database.Migrate || !cfg.Database.AutoMigrate {
+		healthRegistry.Register("migrations", database.MigrationsCheck(db), checkTimeout, health.ForProbes(health.Startup))
+	}
+	if dbBreaker != nil {
+		healthRegistry.Register("database_circuit", dbBreaker.HealthCheck, checkTimeout, health.NonCritical())
+	}
+	healthRegistry.Register("disk", health.DiskSpace(cfg.Health.DiskPath, uint64(cfg.Health.MinFreeDiskMB)<<20), checkTimeout, health.NonCritical())
+
 	// Initialize JWT manager
 	jwtManager := auth.NewJWTManager(cfg.Auth.JWTSecret, cfg.Auth.JWTExpiryHours)
 
 	// Initialize auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
 
+	// Connect read replicas if configured; reads fall back to the primary
+	// whenever no replica is healthy
+	var replicas *database.ReplicaSet
+	var repoOptions []database.RepositoryOption
+	if len(cfg.Database.ReplicaURLs) > 0 {
+		replicas, err = database.ConnectReplicas(dbConfig, cfg.Database.ReplicaURLs)
+		if err != nil {
+			logger.Fatal("Failed to connect to database replicas", zap.Error(err))
+		}
+		defer replicas.Close()
+		replicas.Start(ctx, cfg.Database.ReplicaHealthInterval)
+		repoOptions = append(repoOptions, database.WithReplicas(replicas))
+		healthRegistry.Register("replicas", replicas.HealthCheck, checkTimeout, health.NonCritical())
+	}
+
+	// Retry reads failing with transient errors such as dropped connections,
+	// and whole transactions rolled back by serialization failures or deadlocks
+	dbRetry := resilience.RetryPolicy{
+		Attempts: cfg.Database.RetryAttempts,
+		Backoff: resilience.Backoff{
+			Initial: cfg.Database.RetryBackoff,
+			Max:     time.Second,
+			Jitter:  true,
+		},
+	}
+	repoOptions = append(repoOptions, database.WithRetry(dbRetry))
+
+	// Initialize transaction manager shared by all services
+	txManager := database.NewTransactionManager(db, database.WithTransactionRetry(dbRetry))
+
 	// Initialize repositories
-	userRepo := database.NewGormRepository[models.User](db)
+	// Users are encrypted, so search matches whole emails and the words of
+	// emails and names through their search tokens
+	var userRepo ports.Repository[models.User] = database.NewGormRepository[models.User](db, append(repoOptions, database.WithSearchFields("email", "first_name", "last_name"))...)
+	auditRepo := database.NewGormAuditRepository(db)
+
+	// Serve user lookups by ID from a cache when enabled
+	var userCache *cache.Repository[models.User]
+	if cfg.Cache.Enabled {
+		var backend cache.Backend = cache.NewLRU(cfg.Cache.Size)
+		cacheOptions := []cache.RepositoryOption{
+			cache.WithTTL(cfg.Cache.TTL),
+			cache.WithNamespace("users"),
+		}
+		if cfg.Cache.Backend == "redis" {
+			redis, err := cache.NewRedis(cfg.Cache.RedisURL, cache.WithKeyPrefix("go-server:"))
+			if err != nil {
+				logger.Fatal("Failed to configure redis cache", zap.Error(err))
+			}
+			defer redis.Close()
+			backend = redis
+			healthRegistry.Register("cache", redis.Ping, checkTimeout, health.NonCritical())
+			// Users are cached decrypted; keep their personal data and
+			// password hashes encrypted in redis too
+			cacheOptions = append(cacheOptions, cache.WithEncryption(encryption.Keyring()))
+		}
+		userCache = cache.NewRepository[models.User](userRepo, backend, txManager, cacheOptions...)
+		userRepo = userCache
+	}
+
+	// Initialize the in-process event bus; asynchronous subscribers run as
+	// background jobs when those are enabled
+	var jobDispatcher *jobs.Dispatcher
+	var busOptions []events.Option
+	if cfg.Features.BackgroundJobs {
+		busOptions = append(busOptions, events.WithAsyncRunner(func(name string, fn func(ctx context.Context) error) {
+			jobDispatcher.DispatchJob(jobs.NewJob(name, fn))
+		}))
+	}
+	eventBus := events.NewBus(busOptions...)
+
+	// Publish user changes on the bus and, when the outbox is enabled, record
+	// them in the same transaction as the change that caused them
+	userOptions := []services.Option[models.User]{services.WithEvents[models.User](eventBus)}
+	outboxRepo := database.NewGormOutboxRepository(db)
+	if cfg.Outbox.Enabled {
+		userOptions = append(userOptions, services.WithOutbox[models.User](outboxRepo, "users"))
+	}
 
 	// Initialize services
-	userService := services.NewBaseService[models.User](userRepo)
+	userService := services.NewBaseService[models.User](userRepo, txManager, userOptions...)
+	auditService := services.NewAuditService(auditRepo)
+
+	// Answer data export and erasure requests over every table holding
+	// personal data; exports are built as background jobs when those are
+	// enabled. Register the user record first: erasure runs providers in
+	// reverse order.
+	privacyOptions := []services.PrivacyOption{services.WithExportRetention(cfg.Privacy.ExportRetention)}
+	if cfg.Features.BackgroundJobs {
+		privacyOptions = append(privacyOptions, services.WithExportRunner(func(name string, fn func(ctx context.Context) error) {
+			jobDispatcher.DispatchJob(jobs.NewJob(name, fn))
+		}))
+	}
+	privacyService := services.NewPrivacyService(database.NewGormDataExportRepository(db), txManager, privacyOptions...)
+	privacyService.Register(
+		services.NewEntityDataProvider("profile", userService, "id", (*models.User).Anonymize),
+		database.NewAuditDataProvider(db, "users"),
+		database.NewOutboxDataProvider(db, "users"),
+	)
 
 	// Initialize handlers
 	userHandler := api.NewUserHandler(userService)
-	authHandler := api.NewAuthHandler(userService, jwtManager)
+	authHandler := api.NewAuthHandler(userService, jwtManager, eventBus)
+	adminHandler := api.NewAdminHandler(userService, auditService)
+	privacyHandler := api.NewPrivacyHandler(privacyService, userService)
+	if userCache != nil {
+		adminHandler.RegisterCache("users", userCache.Stats)
+	}
+	if queryStats != nil {
+		adminHandler.SetQueryStats(queryStats)
+	}
+
+	// cmd/gen adds the services and handlers of generated entities above this line
 
 	// Initialize background job system if enabled
-	var jobDispatcher *jobs.Dispatcher
 	if cfg.Features.BackgroundJobs {
 		jobDispatcher = jobs.NewDispatcher(5) // 5 workers
 		jobDispatcher.Start()
+		healthRegistry.Register("jobs", jobDispatcher.HealthCheck, checkTimeout, health.NonCritical())
 		defer func() {
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer shutdownCancel()
 			jobDispatcher.Stop(shutdownCtx)
 		}()
 
-		// Example of adding a scheduled job
-		// cleanupJob := jobs.NewScheduledJob("database-cleanup", 24*time.Hour, func(ctx context.Context) error {
-		//	 // Perform cleanup tasks
-		//	 return nil
-		// }, jobDispatcher)
-		// cleanupJob.Start()
+		// Permanently remove soft-deleted rows past the retention window
+		purgeJob := jobs.NewScheduledJob("soft-delete-purge", cfg.Database.PurgeInterval, func(ctx context.Context) error {
+			purged, err := userService.Purge(ctx, time.Now().Add(-cfg.Database.SoftDeleteRetention))
+			if err != nil {
+				return err
+			}
+			logger.Info("Purged soft-deleted users", zap.Int64("count", purged))
+			return nil
+		}, jobDispatcher)
+		purgeJob.Start()
+		defer purgeJob.Stop()
+
+		// Delete personal data export archives past their retention
+		exportCleanupJob := jobs.NewScheduledJob("data-export-cleanup", cfg.Privacy.ExportCleanupInterval, func(ctx context.Context) error {
+			deleted, err := privacyService.PurgeExpiredExports(ctx)
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				logger.Info("Deleted expired data exports", zap.Int64("count", deleted))
+			}
+			return nil
+		}, jobDispatcher)
+		exportCleanupJob.Start()
+		defer exportCleanupJob.Stop()
+
+		// Re-encrypt users written before the last key rotation, or before
+		// encryption was enabled, with the primary key, and fill the blind
+		// indexes and search tokens they lack
+		reencryptor := database.NewReencryptor[models.User](db, cfg.Encryption.RotationBatchSize)
+		reencryptJob := jobs.NewScheduledJob("user-reencryption", cfg.Encryption.RotationInterval, func(ctx context.Context) error {
+			rewritten, err := reencryptor.Run(ctx)
+			if err != nil {
+				return err
+			}
+			if rewritten > 0 {
+				logger.Info("Re-encrypted users", zap.Int64("count", rewritten))
+			}
+			return nil
+		}, jobDispatcher)
+		reencryptJob.Start()
+		defer reencryptJob.Stop()
+
+		// Relay outbox events to the configured publisher
+		if cfg.Outbox.Enabled {
+			var publisher ports.EventPublisher = outbox.NewLogPublisher()
+			if cfg.Outbox.Publisher == "webhook" {
+				publisher = outbox.NewWebhookPublisher(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookSecret, cfg.Outbox.WebhookTimeout)
+			}
+			relay := outbox.NewRelay(outboxRepo, publisher, txManager,
+				outbox.WithBatchSize(cfg.Outbox.BatchSize),
+				outbox.WithLease(cfg.Outbox.Lease),
+				outbox.WithRetention(cfg.Outbox.Retention),
+			)
+			relayJob := jobs.NewScheduledJob("outbox-relay", cfg.Outbox.RelayInterval, relay.Run, jobDispatcher)
+			relayJob.Start()
+			defer relayJob.Stop()
+		}
+	} else if cfg.Outbox.Enabled {
+		logger.Warn("Outbox is enabled but background jobs are disabled; events will not be relayed")
 	}
 
 	// Create a WaitGroup for tracking in-flight requests
 	var wg sync.WaitGroup
 
-	// Initialize router
+	// Initialize router; matched routes are recorded for slow query logs
 	router := mux.NewRouter()
+	router.Use(middleware.RouteMiddleware)
 
 	// Build middleware chain for net/http
 	var handler http.Handler = router
@@ -143,21 +389,30 @@ func main() {
 	if cfg.API.CorsEnabled {
 		handler = middleware.Cors(handler, cfg.API.AllowedOrigins)
 	}
+	if replicas != nil {
+		// Once a request has written, its later reads go to the primary
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(database.WithReadYourWrites(r.Context())))
+		})
+	}
 	handler = middleware.RecoveryMiddleware(handler)
 	handler = middleware.RequestIDMiddleware(handler)
 
 	// Track in-flight requests (outermost)
+	next := handler
 	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		wg.Add(1)
 		defer wg.Done()
-		handler.ServeHTTP(w, r)
+		next.ServeHTTP(w, r)
 	})
 
 	// Setup routes
-	setupRoutesMux(router, authMiddleware, userHandler, authHandler)
+	setupRoutesMux(router, authMiddleware, userHandler, authHandler, adminHandler, privacyHandler)
+	// cmd/gen adds the routes of generated entities above this line
 
-	// Health route
-	api.RegisterHealthRoutesMux(router)
+	// Health routes
+	api.NewHealthHandler(healthRegistry, version).RegisterHealthRoutesMux(router)
 
 	// Create server
 	srv := &http.Server{
@@ -183,6 +438,13 @@ func main() {
 	<-quit
 	logger.Info("Shutdown signal received")
 
+	// Fail readiness first so load balancers stop routing new requests here
+	healthRegistry.Shutdown()
+	if cfg.Server.DrainDelay > 0 {
+		logger.Info("Draining traffic", zap.Duration("delay", cfg.Server.DrainDelay))
+		time.Sleep(cfg.Server.DrainDelay)
+	}
+
 	// Cancel the context to notify all operations
 	cancel()
 
@@ -222,10 +484,16 @@ func main() {
 }
 
 // setupRoutes configures all the routes for the application
-func setupRoutesMux(r *mux.Router, authMiddleware *middleware.AuthMiddleware, userHandler *api.UserHandler, authHandler *api.AuthHandler) {
+func setupRoutesMux(r *mux.Router, authMiddleware *middleware.AuthMiddleware, userHandler *api.UserHandler, authHandler *api.AuthHandler, adminHandler *api.AdminHandler, privacyHandler *api.PrivacyHandler) {
 	// Register auth routes
 	authHandler.RegisterAuthRoutes(r)
 
+	// Register privacy routes ahead of the user routes they share a prefix with
+	privacyHandler.RegisterPrivacyRoutes(r, authMiddleware)
+
 	// Register user routes
 	userHandler.RegisterUserRoutes(r, authMiddleware)
+
+	// Register admin routes
+	adminHandler.RegisterAdminRoutes(r, authMiddleware)
 }
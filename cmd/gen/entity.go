@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	entityNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldNamePattern  = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// reservedColumns are the columns of domain.BaseEntity
+var reservedColumns = map[string]bool{
+	"id": true, "public_id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true,
+}
+
+// fieldType describes how a field type of the command line is declared in
+// Go, GORM and SQL, validated, filtered and exercised by the generated tests
+type fieldType struct {
+	Go       string
+	Gorm     string
+	Postgres string
+	SQLite   string
+	Validate string
+	Filter   string
+	Sample   string
+	Changed  string
+}
+
+// fieldTypes maps the type names accepted on the command line to their
+// declarations; Sample and Changed are JSON literals
+var fieldTypes = map[string]fieldType{
+	"string": {
+		Go: "string", Gorm: "type:varchar(255);not null;default:''",
+		Postgres: "VARCHAR(255) NOT NULL DEFAULT ''", SQLite: "VARCHAR(255) NOT NULL DEFAULT ''",
+		Validate: "required,max=255", Filter: "StringFilter",
+		Sample: `"example"`, Changed: `"changed"`,
+	},
+	"text": {
+		Go: "string", Gorm: "type:text;not null;default:''",
+		Postgres: "TEXT NOT NULL DEFAULT ''", SQLite: "TEXT NOT NULL DEFAULT ''",
+		Sample: `"example"`, Changed: `"changed"`,
+	},
+	"int": {
+		Go: "int64", Gorm: "not null;default:0",
+		Postgres: "BIGINT NOT NULL DEFAULT 0", SQLite: "INTEGER NOT NULL DEFAULT 0",
+		Filter: "IntFilter",
+		Sample: `42`, Changed: `43`,
+	},
+	"float": {
+		Go: "float64", Gorm: "not null;default:0",
+		Postgres: "DOUBLE PRECISION NOT NULL DEFAULT 0", SQLite: "REAL NOT NULL DEFAULT 0",
+		Sample: `1.5`, Changed: `2.5`,
+	},
+	"bool": {
+		Go: "bool", Gorm: "not null;default:false",
+		Postgres: "BOOLEAN NOT NULL DEFAULT false", SQLite: "BOOLEAN NOT NULL DEFAULT false",
+		Filter: "BoolFilter",
+		Sample: `true`, Changed: `false`,
+	},
+	"time": {
+		Go: "time.Time", Gorm: "not null",
+		Postgres: "TIMESTAMPTZ NOT NULL", SQLite: "DATETIME NOT NULL",
+		Validate: "required",
+		Sample:   `"2024-01-02T03:04:05Z"`, Changed: `"2025-06-07T08:09:10Z"`,
+	},
+}
+
+// Field is a field of a generated entity
+type Field struct {
+	// Name is the Go field name, e.g. UnitPrice
+	Name string
+	// Column is the column name, also used in JSON and as list filter, e.g. unit_price
+	Column string
+	Type   fieldType
+}
+
+// Entity describes the entity to generate
+type Entity struct {
+	// Name is the Go type name, e.g. OrderItem
+	Name string
+	// Plural is the plural of Name, e.g. OrderItems
+	Plural string
+	// Var is the lowerCamelCase name used for variables, e.g. orderItem
+	Var string
+	// Human is the name used in messages, e.g. "order item"
+	Human string
+	// Article is the indefinite article of Human, "a" or "an"
+	Article string
+	// Table is the table name, also used as the JSON key of lists, e.g. order_items
+	Table string
+	// Path is the URL path segment, e.g. order-items
+	Path string
+	// File is the base name of the generated Go files, e.g. order_item
+	File   string
+	Fields []Field
+	// Version is the version of the generated migration
+	Version int64
+}
+
+// UsesTime reports whether a field needs the time package
+func (e *Entity) UsesTime() bool {
+	for _, field := range e.Fields {
+		if field.Type.Go == "time.Time" {
+			return true
+		}
+	}
+	return false
+}
+
+// Width returns the width of the widest column name, for aligned SQL
+func (e *Entity) Width() int {
+	width := len("created_at")
+	for _, field := range e.Fields {
+		width = max(width, len(field.Column))
+	}
+	return width
+}
+
+// parseEntity parses the entity name and the name:type field specifications
+func parseEntity(name string, specs []string) (*Entity, error) {
+	if !entityNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("entity name %q must be CamelCase, e.g. Product or OrderItem", name)
+	}
+
+	words := splitWords(name)
+	file := strings.Join(words, "_")
+	pluralWords := append(words[:len(words)-1:len(words)-1], plural(words[len(words)-1]))
+	entity := &Entity{
+		Name:    name,
+		Plural:  name[:len(name)-len(words[len(words)-1])] + capitalize(plural(words[len(words)-1])),
+		Var:     strings.ToLower(name[:1]) + name[1:],
+		Human:   strings.Join(words, " "),
+		Article: "a",
+		Table:   strings.Join(pluralWords, "_"),
+		Path:    strings.Join(pluralWords, "-"),
+		File:    file,
+	}
+
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		fieldName, typeName, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("field %q must be given as name:type", spec)
+		}
+		if !fieldNamePattern.MatchString(fieldName) {
+			return nil, fmt.Errorf("field name %q must be snake_case, e.g. unit_price", fieldName)
+		}
+		if reservedColumns[fieldName] {
+			return nil, fmt.Errorf("field %q is already provided by domain.BaseEntity", fieldName)
+		}
+		if seen[fieldName] {
+			return nil, fmt.Errorf("field %q is given twice", fieldName)
+		}
+		seen[fieldName] = true
+
+		fieldType, ok := fieldTypes[typeName]
+		if !ok {
+			return nil, fmt.Errorf("field %q has unknown type %q", fieldName, typeName)
+		}
+		entity.Fields = append(entity.Fields, Field{
+			Name:   goName(fieldName),
+			Column: fieldName,
+			Type:   fieldType,
+		})
+	}
+	if strings.ContainsRune("aeiou", rune(entity.Human[0])) {
+		entity.Article = "an"
+	}
+	if token.IsKeyword(entity.Var) {
+		entity.Var += "Entity"
+	}
+	return entity, nil
+}
+
+// splitWords splits a CamelCase name into lowercase words, keeping
+// acronyms together: OrderItem gives order, item and HTTPLog gives http, log
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerBefore := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (lowerBefore || acronymEnd) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	return append(words, strings.ToLower(string(runes[start:])))
+}
+
+// plural returns the English plural of a lowercase word for the regular cases
+func plural(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	default:
+		return word + "s"
+	}
+}
+
+// goName converts a snake_case name to an exported Go name, upper-casing
+// the common initialisms: unit_price gives UnitPrice and owner_id OwnerID
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		switch word {
+		case "":
+		case "id", "url", "uri", "ip", "sku", "api", "http", "json", "uuid":
+			b.WriteString(strings.ToUpper(word))
+		default:
+			b.WriteString(capitalize(word))
+		}
+	}
+	return b.String()
+}
+
+// capitalize upper-cases the first letter of word
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
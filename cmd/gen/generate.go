@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+// Paths of the files the generator writes to or edits, relative to the
+// repository root
+const (
+	modelsDir     = "internal/infrastructure/database/models"
+	handlersDir   = "internal/interfaces/api"
+	migrationsDir = "internal/infrastructure/database/migrations/sql"
+	databaseFile  = "internal/infrastructure/database/database.go"
+	mainFile      = "cmd/api/main.go"
+)
+
+// Markers of the places where generated entities are registered; the
+// generated lines are inserted above them with their indentation
+const (
+	modelsMarker   = "// Add more models here as needed"
+	handlersMarker = "// cmd/gen adds the services and handlers of generated entities above this line"
+	routesMarker   = "// cmd/gen adds the routes of generated entities above this line"
+)
+
+// registrations are the lines added above each marker, as templates
+var registrations = []struct {
+	file, marker, lines string
+}{
+	{databaseFile, modelsMarker, "&models.{{.Name}}{},"},
+	{mainFile, handlersMarker, `{{.Var}}Service := services.NewBaseService[models.{{.Name}}](database.NewGormRepository[models.{{.Name}}](db, repoOptions...), txManager,
+	services.WithEvents[models.{{.Name}}](eventBus))
+{{.Var}}Handler := api.New{{.Name}}Handler({{.Var}}Service)`},
+	{mainFile, routesMarker, "{{.Var}}Handler.Register{{.Name}}Routes(router, authMiddleware)"},
+}
+
+// generate writes the files of entity under root and registers it in the
+// application. Nothing is written unless every file can be created and
+// every marker is found; with dryRun nothing is written at all.
+func generate(root string, entity *Entity, dryRun bool) error {
+	version, err := nextMigrationVersion(filepath.Join(root, migrationsDir))
+	if err != nil {
+		return err
+	}
+	entity.Version = version
+
+	files, err := render(entity)
+	if err != nil {
+		return err
+	}
+	for path := range files {
+		if _, err := os.Stat(filepath.Join(root, path)); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	edits := make(map[string]string)
+	for _, registration := range registrations {
+		content, ok := edits[registration.file]
+		if !ok {
+			data, err := os.ReadFile(filepath.Join(root, registration.file))
+			if err != nil {
+				return err
+			}
+			content = string(data)
+		}
+		lines, err := execute(template.Must(template.New("").Parse(registration.lines)), entity)
+		if err != nil {
+			return err
+		}
+		content, err = insertAbove(content, registration.marker, string(lines))
+		if err != nil {
+			return fmt.Errorf("%s: %w", registration.file, err)
+		}
+		edits[registration.file] = content
+	}
+	for path, content := range edits {
+		if strings.HasSuffix(path, ".go") {
+			formatted, err := format.Source([]byte(content))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			edits[path] = string(formatted)
+		}
+	}
+
+	for _, path := range sortedKeys(files) {
+		fmt.Println("Created", path)
+		if dryRun {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(root, path), files[path], 0o644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+	}
+	for _, path := range sortedKeys(edits) {
+		fmt.Println("Updated", path)
+		if dryRun {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(root, path), []byte(edits[path]), 0o644); err != nil {
+			return fmt.Errorf("failed to update %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// render renders the files of entity, keyed by their path
+func render(entity *Entity) (map[string][]byte, error) {
+	migration := fmt.Sprintf("%06d_create_%s", entity.Version, entity.Table)
+	outputs := map[string]string{
+		filepath.Join(modelsDir, entity.File+".go"):                     "model.go.tmpl",
+		filepath.Join(handlersDir, entity.File+"_handler.go"):           "handler.go.tmpl",
+		filepath.Join(handlersDir, entity.File+"_handler_test.go"):      "handler_test.go.tmpl",
+		filepath.Join(migrationsDir, "postgres", migration+".up.sql"):   "postgres.up.sql.tmpl",
+		filepath.Join(migrationsDir, "postgres", migration+".down.sql"): "down.sql.tmpl",
+		filepath.Join(migrationsDir, "sqlite", migration+".up.sql"):     "sqlite.up.sql.tmpl",
+		filepath.Join(migrationsDir, "sqlite", migration+".down.sql"):   "down.sql.tmpl",
+	}
+
+	files := make(map[string][]byte, len(outputs))
+	for path, name := range outputs {
+		content, err := execute(templates.Lookup(name), entity)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(path, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		files[path] = content
+	}
+	return files, nil
+}
+
+// execute renders tmpl with entity
+func execute(tmpl *template.Template, entity *Entity) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, entity); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// insertAbove inserts lines above the line holding marker, with the same
+// indentation
+func insertAbove(content, marker, lines string) (string, error) {
+	at := strings.Index(content, marker)
+	if at < 0 {
+		return "", fmt.Errorf("marker %q not found", marker)
+	}
+	lineStart := strings.LastIndex(content[:at], "\n") + 1
+	indent := content[lineStart:at]
+
+	var b strings.Builder
+	b.WriteString(content[:lineStart])
+	for _, line := range strings.Split(lines, "\n") {
+		b.WriteString(indent + line + "\n")
+	}
+	b.WriteString(content[lineStart:])
+	return b.String(), nil
+}
+
+// nextMigrationVersion returns the version following the highest one found
+// in the dialect directories under root
+func nextMigrationVersion(root string) (int64, error) {
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	var next int64 = 1
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, dir.Name()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		for _, file := range files {
+			var version int64
+			if _, err := fmt.Sscanf(file.Name(), "%d_", &version); err == nil && version >= next {
+				next = version + 1
+			}
+		}
+	}
+	return next, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
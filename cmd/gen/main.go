@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: gen [flags] <command> [args]
+
+Commands:
+  entity <Name> <field:type>...   Generate a model, migrations, an HTTP handler
+                                  with its tests, and wire them into the app
+
+Field types: string, text, int, float, bool, time
+
+Example:
+  go run ./cmd/gen entity Product name:string price:int
+
+Flags:
+`
+
+func main() {
+	root := flag.String("root", ".", "repository root the files are generated in")
+	dryRun := flag.Bool("dry-run", false, "list the files that would be written and edited without changing anything")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "entity":
+		if len(args) < 3 {
+			fail("entity requires a name and at least one field, e.g. entity Product name:string price:int")
+		}
+		entity, err := parseEntity(args[1], args[2:])
+		if err != nil {
+			fail(err.Error())
+		}
+		if err := generate(*root, entity, *dryRun); err != nil {
+			fail(err.Error())
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// fail prints msg and exits with a non-zero status
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"go-server-boilerplate/internal/config"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/migrations"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/resilience"
+)
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up              Apply all pending migrations
+  down [N]        Roll back the last N migrations (default 1)
+  to <version>    Migrate up or down to the given version (0 reverts everything)
+  status          Show applied and pending migrations
+  create <name>   Create a new pair of empty migration files
+
+Flags:
+`
+
+var namePattern = regexp.MustCompile(`^\w+$`)
+
+func main() {
+	dir := flag.String("dir", "internal/infrastructure/database/migrations/sql", "migrations root directory used by create (one subdirectory per dialect)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 || !namePattern.MatchString(args[1]) {
+			fail("create requires a name made of letters, digits and underscores")
+		}
+		if err := create(*dir, args[1]); err != nil {
+			fail(err.Error())
+		}
+		return
+	}
+
+	_ = godotenv.Load()
+
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		fail(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	logger.Init(cfg.Logging.Level, cfg.Logging.Format == "json")
+	defer logger.Sync()
+
+	db, err := database.Connect(database.Config{
+		URL:             cfg.Database.URL,
+		MaxConnections:  cfg.Database.MaxConnections,
+		MaxIdleConns:    cfg.Database.MaxIdleConnections,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnectAttempts: cfg.Database.ConnectAttempts,
+		ConnectBackoff: resilience.Backoff{
+			Initial: cfg.Database.ConnectBackoff,
+			Max:     cfg.Database.ConnectMaxBackoff,
+			Jitter:  true,
+		},
+	})
+	if err != nil {
+		fail(err.Error())
+	}
+	defer database.Close(db)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fail(err.Error())
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fail("down requires a positive number of steps")
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "to":
+		if len(args) != 2 {
+			fail("to requires a version")
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil || version < 0 {
+			fail("to requires a non-negative version")
+		}
+		err = migrator.To(ctx, version)
+	case "status":
+		err = printStatus(ctx, migrator)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fail(err.Error())
+	}
+}
+
+// printStatus writes the migration status table to stdout
+func printStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		state := "pending"
+		appliedAt := ""
+		switch {
+		case status.Missing:
+			state = "applied (file missing)"
+		case status.Modified:
+			state = "applied (file modified)"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
+
+// create writes empty up/down files numbered after the highest existing
+// version into every dialect subdirectory of root
+func create(root, name string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	var dialects []string
+	var next int64 = 1
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dialects = append(dialects, entry.Name())
+
+		files, err := os.ReadDir(filepath.Join(root, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read migrations directory: %w", err)
+		}
+		for _, file := range files {
+			var version int64
+			if _, err := fmt.Sscanf(file.Name(), "%d_", &version); err == nil && version >= next {
+				next = version + 1
+			}
+		}
+	}
+	if len(dialects) == 0 {
+		return fmt.Errorf("no dialect directories found in %s", root)
+	}
+
+	for _, dialect := range dialects {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(root, dialect, fmt.Sprintf("%06d_%s.%s.sql", next, name, direction))
+			if err := os.WriteFile(path, nil, 0o644); err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			fmt.Println("Created", path)
+		}
+	}
+	return nil
+}
+
+// fail prints the message and exits with a non-zero status
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+
+	"go-server-boilerplate/internal/config"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/infrastructure/database/seed"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/resilience"
+)
+
+const usage = `Usage: seed [flags] <command> [args]
+
+Commands:
+  run [set]       Load a seed set (default: the set named after ENVIRONMENT)
+  fake <N>        Insert N fake users for load testing
+  list            List the available seed sets
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "", "load seed sets from this directory instead of the fixtures built into the binary")
+	password := flag.String("password", "password", "password of the fake users")
+	randomSeed := flag.Int64("seed", 0, "random seed for fake users (default: current time)")
+	force := flag.Bool("force", false, "allow seeding when ENVIRONMENT is production")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "list" {
+		sets, err := fixtures(nil, *dir).Sets()
+		if err != nil {
+			fail(err.Error())
+		}
+		for _, set := range sets {
+			fmt.Println(set)
+		}
+		return
+	}
+
+	_ = godotenv.Load()
+
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = "development"
+	}
+	if env == "production" && !*force {
+		fail("refusing to seed a production database without -force")
+	}
+
+	cfg, err := config.LoadConfig(env)
+	if err != nil {
+		fail(fmt.Sprintf("Failed to load configuration: %v", err))
+	}
+
+	logger.Init(cfg.Logging.Level, cfg.Logging.Format == "json")
+	defer logger.Sync()
+
+	// Seeded users are encrypted with the keys of the server
+	if cfg.Encryption.Configured() {
+		keyring, err := fieldcrypt.Load(cfg.Encryption.Keys, cfg.Encryption.KeysDir, cfg.Encryption.PrimaryKey, cfg.Encryption.BlindIndexKey)
+		if err != nil {
+			fail(fmt.Sprintf("Failed to load encryption keys: %v", err))
+		}
+		encryption.UseKeyring(keyring)
+	}
+
+	db, err := database.Connect(database.Config{
+		URL:             cfg.Database.URL,
+		MaxConnections:  cfg.Database.MaxConnections,
+		MaxIdleConns:    cfg.Database.MaxIdleConnections,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnectAttempts: cfg.Database.ConnectAttempts,
+		ConnectBackoff: resilience.Backoff{
+			Initial: cfg.Database.ConnectBackoff,
+			Max:     cfg.Database.ConnectMaxBackoff,
+			Jitter:  true,
+		},
+		AutoMigrate: cfg.Database.AutoMigrate,
+		Migrate:     cfg.Database.Migrate,
+	})
+	if err != nil {
+		fail(err.Error())
+	}
+	defer database.Close(db)
+
+	runner := fixtures(db, *dir)
+
+	ctx := context.Background()
+	switch args[0] {
+	case "run":
+		set := env
+		if len(args) > 1 {
+			set = args[1]
+		}
+		err = run(ctx, runner, set)
+	case "fake":
+		if len(args) != 2 {
+			fail("fake requires a number of users")
+		}
+		n, perr := strconv.Atoi(args[1])
+		if perr != nil || n < 1 {
+			fail("fake requires a positive number of users")
+		}
+		if *randomSeed == 0 {
+			*randomSeed = time.Now().UnixNano()
+		}
+		var inserted int64
+		inserted, err = seed.FakeUsers(ctx, db, n, *password, rand.New(rand.NewSource(*randomSeed)))
+		if err == nil {
+			fmt.Printf("Inserted %d fake users (seed %d)\n", inserted, *randomSeed)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fail(err.Error())
+	}
+}
+
+// fixtures creates a runner for the seed sets in dir, or for the built-in
+// ones if dir is empty
+func fixtures(db *gorm.DB, dir string) *seed.Runner {
+	if dir != "" {
+		return seed.NewRunner(db, os.DirFS(dir))
+	}
+	return seed.NewRunner(db, nil)
+}
+
+// run loads a seed set and prints what changed per entity
+func run(ctx context.Context, runner *seed.Runner, set string) error {
+	sets, err := runner.Sets()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(sets, set) {
+		return fmt.Errorf("unknown seed set %q (available: %v)", set, sets)
+	}
+
+	results, err := runner.Run(ctx, set)
+	if err != nil {
+		return err
+	}
+
+	entities := make([]string, 0, len(results))
+	for entity := range results {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTITY\tCREATED\tUPDATED\tUNCHANGED")
+	for _, entity := range entities {
+		result := results[entity]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", entity, result.Created, result.Updated, result.Unchanged)
+	}
+	return w.Flush()
+}
+
+// fail prints the message and exits with a non-zero status
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// AuditAction identifies the kind of change recorded in the audit log
+type AuditAction string
+
+// Audit actions
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+	AuditActionPurge   AuditAction = "purge"
+)
+
+// AuditRedacted replaces the values of secret fields in audit entries
+const AuditRedacted = "[REDACTED]"
+
+// AuditChange holds the old and new value of one changed field
+type AuditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// AuditEntry records one change made to an entity. The entity and the
+// actor are identified by their internal IDs, for joins, and by their public
+// IDs, which are the only ones rendered.
+type AuditEntry struct {
+	ID             uint                   `json:"-" gorm:"primaryKey"`
+	EntityType     string                 `json:"entity_type" gorm:"type:varchar(100);not null"`
+	EntityID       uint                   `json:"-" gorm:"not null"`
+	EntityPublicID string                 `json:"entity_id" gorm:"type:varchar(36)"`
+	Action         AuditAction            `json:"action" gorm:"type:varchar(20);not null"`
+	ActorID        *uint                  `json:"-"`
+	ActorPublicID  string                 `json:"actor_id,omitempty" gorm:"type:varchar(36)"`
+	RequestID      string                 `json:"request_id,omitempty" gorm:"type:varchar(100)"`
+	Changes        map[string]AuditChange `json:"changes" gorm:"serializer:json"`
+	CreatedAt      time.Time              `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName overrides the table name
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// GetID returns the ID of the audit entry
+func (e AuditEntry) GetID() uint {
+	return e.ID
+}
+
+// AuditFilter narrows an audit log query; zero fields do not filter. The
+// entity and the actor are given by public ID.
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     AuditAction
+	From       time.Time
+	To         time.Time
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Entity represents a domain entity with basic identity
@@ -9,14 +11,48 @@ type Entity interface {
 	GetID() uint
 }
 
-// BaseEntity provides common fields for all entities
+// BaseEntity provides common fields for all entities. ID is the internal
+// key used for joins and pagination and is never exposed; clients see the
+// opaque PublicID instead.
 type BaseEntity struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID        uint           `json:"-" gorm:"primaryKey"`
+	PublicID  string         `json:"id" gorm:"type:varchar(36);uniqueIndex;not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	Version   uint           `json:"version" gorm:"not null;default:1"`
 }
 
 // GetID returns the ID of the entity
 func (b BaseEntity) GetID() uint {
 	return b.ID
 }
+
+// GetPublicID returns the public ID of the entity
+func (b BaseEntity) GetPublicID() string {
+	return b.PublicID
+}
+
+// GetVersion returns the optimistic concurrency version of the entity
+func (b BaseEntity) GetVersion() uint {
+	return b.Version
+}
+
+// GetBase returns a pointer to the embedded BaseEntity so generic code can
+// maintain its bookkeeping fields
+func (b *BaseEntity) GetBase() *BaseEntity {
+	return b
+}
+
+// BaseOf returns the BaseEntity embedded in entity, or nil if it has none
+func BaseOf[T Entity](entity *T) *BaseEntity {
+	if based, ok := any(entity).(interface{ GetBase() *BaseEntity }); ok {
+		return based.GetBase()
+	}
+	return nil
+}
+
+// IsDeleted reports whether the entity has been soft deleted
+func (b BaseEntity) IsDeleted() bool {
+	return b.DeletedAt.Valid
+}
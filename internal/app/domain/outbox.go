@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it describes and published asynchronously by the outbox relay. Events of
+// one aggregate are published in ID order. The aggregate is identified by
+// its internal ID, which orders its events, and by its public ID, the only
+// one sent to other systems or rendered.
+type OutboxEvent struct {
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	AggregateType     string          `json:"aggregate_type" gorm:"type:varchar(100);not null"`
+	AggregateID       uint            `json:"-" gorm:"not null"`
+	AggregatePublicID string          `json:"aggregate_id" gorm:"type:varchar(36)"`
+	EventType         string          `json:"event_type" gorm:"type:varchar(100);not null"`
+	Payload           json.RawMessage `json:"payload" gorm:"not null"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	Attempts          int             `json:"attempts" gorm:"not null;default:0"`
+	LastError         string          `json:"last_error,omitempty"`
+	NextAttemptAt     time.Time       `json:"next_attempt_at" gorm:"not null"`
+	PublishedAt       *time.Time      `json:"published_at,omitempty"`
+}
+
+// TableName overrides the table name
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// GetID returns the ID of the event
+func (e OutboxEvent) GetID() uint {
+	return e.ID
+}
+
+// AggregateKey identifies the aggregate the event belongs to
+func (e OutboxEvent) AggregateKey() string {
+	return fmt.Sprintf("%s/%d", e.AggregateType, e.AggregateID)
+}
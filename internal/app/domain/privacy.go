@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// DataExportStatus is the state of a personal data export
+type DataExportStatus string
+
+// Data export statuses
+const (
+	DataExportPending DataExportStatus = "pending"
+	DataExportReady   DataExportStatus = "ready"
+	DataExportFailed  DataExportStatus = "failed"
+)
+
+// DataExport is an archive of the personal data held about a user, built in
+// the background and kept for download until it expires. The archive is
+// stored encrypted by the database layer.
+type DataExport struct {
+	ID          uint             `json:"-" gorm:"primaryKey"`
+	UserID      uint             `json:"-" gorm:"not null;index"`
+	Status      DataExportStatus `json:"status" gorm:"type:varchar(20);not null"`
+	Archive     []byte           `json:"-" gorm:"type:text;serializer:encrypted"`
+	Error       string           `json:"-" gorm:"type:text"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time        `json:"expires_at" gorm:"not null;index"`
+}
+
+// TableName overrides the table name
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// GetID returns the ID of the export
+func (e DataExport) GetID() uint {
+	return e.ID
+}
+
+// Expired reports whether the export can no longer be downloaded at now
+func (e DataExport) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
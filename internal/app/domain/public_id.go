@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// NewPublicID returns a new public ID: a UUIDv7, which starts with a
+// millisecond timestamp so that IDs created later sort later and index
+// well, followed by random bits that make IDs impossible to guess
+func NewPublicID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// Only fails if the system random source does
+		panic(err)
+	}
+	return id.String()
+}
+
+// ParsePublicID returns s in the canonical, lowercase form of a public ID,
+// or false if s is not one
+func ParsePublicID(s string) (string, bool) {
+	if len(s) != 36 {
+		return "", false
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return "", false
+	}
+	return id.String(), true
+}
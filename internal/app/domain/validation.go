@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"strings"
+
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// Validatable is implemented by entities that check their own invariants.
+// Services call Validate before every create and update; a failure aborts
+// the write.
+type Validatable interface {
+	Validate() error
+}
+
+// ValidationError reports an invalid field of an entity
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the error message
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Is makes validation errors match apperrs.ErrInvalidInput
+func (e ValidationError) Is(target error) bool {
+	return target == apperrs.ErrInvalidInput
+}
+
+// ValidationErrors collects the invalid fields of an entity so they can be
+// reported together
+type ValidationErrors []ValidationError
+
+// Add records an invalid field
+func (e *ValidationErrors) Add(field, message string) {
+	*e = append(*e, ValidationError{Field: field, Message: message})
+}
+
+// Err returns the collected errors, or nil if there are none
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error returns the messages of all collected errors
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is makes validation errors match apperrs.ErrInvalidInput
+func (e ValidationErrors) Is(target error) bool {
+	return target == apperrs.ErrInvalidInput
+}
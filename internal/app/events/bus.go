@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Handler handles events of type E
+type Handler[E any] func(ctx context.Context, event E) error
+
+// AsyncRunner runs an asynchronous subscriber call, e.g. as a background job
+type AsyncRunner func(name string, fn func(ctx context.Context) error)
+
+// Bus dispatches events to the subscribers of their type. A nil *Bus is
+// valid and drops every event.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[reflect.Type][]*subscriber
+	async       AsyncRunner
+}
+
+type subscriber struct {
+	name   string
+	async  bool
+	handle func(ctx context.Context, event any) error
+}
+
+// Option configures a Bus
+type Option func(*Bus)
+
+// WithAsyncRunner sets how asynchronous subscribers are run. By default
+// each call runs in its own goroutine.
+func WithAsyncRunner(runner AsyncRunner) Option {
+	return func(b *Bus) {
+		b.async = runner
+	}
+}
+
+// NewBus creates a new event bus
+func NewBus(opts ...Option) *Bus {
+	b := &Bus{
+		subscribers: make(map[reflect.Type][]*subscriber),
+		async: func(name string, fn func(ctx context.Context) error) {
+			go func() {
+				if err := fn(context.Background()); err != nil {
+					logger.Error("Event subscriber failed", zap.String("subscriber", name), zap.Error(err))
+				}
+			}()
+		},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscribe registers a handler called synchronously, in subscription
+// order, by Publish. It returns a function that removes the subscription.
+func Subscribe[E any](bus *Bus, name string, handler Handler[E]) (unsubscribe func()) {
+	return subscribe(bus, name, false, handler)
+}
+
+// SubscribeAsync registers a handler called in the background after
+// Publish returns. It returns a function that removes the subscription.
+func SubscribeAsync[E any](bus *Bus, name string, handler Handler[E]) (unsubscribe func()) {
+	return subscribe(bus, name, true, handler)
+}
+
+func subscribe[E any](bus *Bus, name string, async bool, handler Handler[E]) func() {
+	key := reflect.TypeFor[E]()
+	sub := &subscriber{
+		name:  name,
+		async: async,
+		handle: func(ctx context.Context, event any) error {
+			return handler(ctx, event.(E))
+		},
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subscribers[key] = append(bus.subscribers[key], sub)
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		subs := bus.subscribers[key]
+		for i, s := range subs {
+			if s == sub {
+				bus.subscribers[key] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers event to the subscribers of its type. Subscribers are
+// isolated from each other and from the publisher: their errors and panics
+// are logged and never returned, so a failing side effect cannot undo or
+// fail the change that caused the event.
+func Publish[E any](ctx context.Context, bus *Bus, event E) {
+	if bus == nil {
+		return
+	}
+
+	bus.mu.RLock()
+	subs := bus.subscribers[reflect.TypeFor[E]()]
+	bus.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.async {
+			sub := sub
+			bus.async(sub.name, func(ctx context.Context) error {
+				return call(ctx, sub, event)
+			})
+			continue
+		}
+		if err := call(ctx, sub, event); err != nil {
+			logger.Error("Event subscriber failed", zap.String("subscriber", sub.name), zap.Error(err))
+		}
+	}
+}
+
+// call runs a subscriber, turning a panic into an error
+func call(ctx context.Context, sub *subscriber, event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+	return sub.handle(ctx, event)
+}
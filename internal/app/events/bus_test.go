@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-server-boilerplate/internal/app/events"
+)
+
+func TestPublishIsolatesSubscribers(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewBus()
+
+	var calls []string
+	events.Subscribe(bus, "failing", func(ctx context.Context, e events.UserRegistered) error {
+		calls = append(calls, "failing")
+		return errors.New("boom")
+	})
+	events.Subscribe(bus, "panicking", func(ctx context.Context, e events.UserRegistered) error {
+		calls = append(calls, "panicking")
+		panic("boom")
+	})
+	unsubscribe := events.Subscribe(bus, "removed", func(ctx context.Context, e events.UserRegistered) error {
+		calls = append(calls, "removed")
+		return nil
+	})
+	events.Subscribe(bus, "last", func(ctx context.Context, e events.UserRegistered) error {
+		calls = append(calls, "last")
+		return nil
+	})
+	events.Subscribe(bus, "other type", func(ctx context.Context, e events.UserLoggedIn) error {
+		calls = append(calls, "other type")
+		return nil
+	})
+
+	unsubscribe()
+	events.Publish(ctx, bus, events.UserRegistered{UserID: 1})
+
+	want := []string{"failing", "panicking", "last"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestPublishRunsAsyncSubscribersWithRunner(t *testing.T) {
+	var queued []func(ctx context.Context) error
+	bus := events.NewBus(events.WithAsyncRunner(func(name string, fn func(ctx context.Context) error) {
+		queued = append(queued, fn)
+	}))
+
+	var got uint
+	events.SubscribeAsync(bus, "async", func(ctx context.Context, e events.UserRegistered) error {
+		got = e.UserID
+		return nil
+	})
+	events.Publish(context.Background(), bus, events.UserRegistered{UserID: 7})
+
+	if len(queued) != 1 || got != 0 {
+		t.Fatalf("expected one queued call not yet run, got %d queued and user %d", len(queued), got)
+	}
+	if err := queued[0](context.Background()); err != nil || got != 7 {
+		t.Fatalf("queued call returned %v and saw user %d, want user 7", err, got)
+	}
+}
+
+func TestPublishOnNilBus(t *testing.T) {
+	events.Publish(context.Background(), nil, events.UserRegistered{UserID: 1})
+}
@@ -0,0 +1,48 @@
+// Package events provides an in-process bus for typed domain events.
+//
+// Subscribers register for one event type and are called with events of
+// exactly that type:
+//
+//	events.Subscribe(bus, "invalidate-cache", func(ctx context.Context, e events.EntityUpdated[models.User]) error {
+//		...
+//	})
+package events
+
+import "time"
+
+// EntityCreated is published after an entity was created
+type EntityCreated[T any] struct {
+	Entity T
+}
+
+// EntityUpdated is published after an entity was updated, with its state
+// before and after the update
+type EntityUpdated[T any] struct {
+	Before T
+	After  T
+}
+
+// EntityDeleted is published after an entity was deleted, with its last
+// state before the deletion
+type EntityDeleted[T any] struct {
+	ID     uint
+	Entity T
+}
+
+// EntityRestored is published after a soft-deleted entity was restored
+type EntityRestored[T any] struct {
+	Entity T
+}
+
+// UserRegistered is published after a user signed up
+type UserRegistered struct {
+	UserID uint
+	Email  string
+}
+
+// UserLoggedIn is published after a user logged in successfully
+type UserLoggedIn struct {
+	UserID uint
+	Email  string
+	At     time.Time
+}
@@ -0,0 +1,64 @@
+package ports
+
+import (
+	"context"
+
+	"go-server-boilerplate/internal/app/domain"
+)
+
+// Lifecycle hooks are registered with a service and run inside the
+// transaction of the write they observe; an error from any hook rolls the
+// write back and is returned to the caller. A hook implements whichever of
+// the interfaces below it needs.
+
+// BeforeCreateHook runs after validation and before an entity is stored; it
+// may modify the entity
+type BeforeCreateHook[T domain.Entity] interface {
+	BeforeCreate(ctx context.Context, entity *T) error
+}
+
+// AfterCreateHook runs once an entity has been stored and has its ID
+type AfterCreateHook[T domain.Entity] interface {
+	AfterCreate(ctx context.Context, entity *T) error
+}
+
+// BeforeUpdateHook runs after validation and before an entity is updated,
+// with its stored state as old; it may modify entity
+type BeforeUpdateHook[T domain.Entity] interface {
+	BeforeUpdate(ctx context.Context, old T, entity *T) error
+}
+
+// BeforeDeleteHook runs before an entity is deleted, with its stored state
+type BeforeDeleteHook[T domain.Entity] interface {
+	BeforeDelete(ctx context.Context, entity T) error
+}
+
+// Entities may take part in their own lifecycle by implementing the
+// interfaces below, with a value or pointer receiver. Services run them
+// inside the transaction of the write, before the registered hooks. Their
+// methods end in Hook so that they do not collide with GORM's BeforeCreate,
+// AfterCreate, BeforeUpdate and BeforeDelete callbacks, which models may
+// implement as well.
+
+// EntityBeforeCreateHook runs after validation and before the entity is
+// stored; it may modify the entity
+type EntityBeforeCreateHook interface {
+	BeforeCreateHook(ctx context.Context) error
+}
+
+// EntityAfterCreateHook runs once the entity has been stored and has its ID
+type EntityAfterCreateHook interface {
+	AfterCreateHook(ctx context.Context) error
+}
+
+// EntityBeforeUpdateHook runs after validation and before the entity is
+// updated, with its stored state as old; it may modify the entity
+type EntityBeforeUpdateHook[T domain.Entity] interface {
+	BeforeUpdateHook(ctx context.Context, old T) error
+}
+
+// EntityBeforeDeleteHook runs on the stored state of the entity before it
+// is deleted
+type EntityBeforeDeleteHook interface {
+	BeforeDeleteHook(ctx context.Context) error
+}
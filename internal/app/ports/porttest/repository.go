@@ -0,0 +1,528 @@
+// Package porttest provides conformance suites that every implementation of
+// the ports interfaces must pass.
+package porttest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// RepositoryHarness describes how to exercise a Repository implementation
+type RepositoryHarness[T domain.Entity] struct {
+	// New returns an empty repository and the transaction manager it takes part in
+	New func(t *testing.T) (ports.Repository[T], ports.TransactionManager)
+
+	// NewEntity returns a valid, unsaved entity; i makes unique fields unique
+	NewEntity func(i int) *T
+
+	// Mutate changes a persisted field of the entity
+	Mutate func(entity *T)
+
+	// Equal reports whether two entities hold the same persisted field values
+	Equal func(a, b T) bool
+
+	// LookupField names a string field usable with FindOneBy, and LookupValue
+	// returns its value for an entity
+	LookupField string
+	LookupValue func(entity T) string
+}
+
+// RunRepositoryConformance runs the shared Repository test suite
+func RunRepositoryConformance[T domain.Entity](t *testing.T, h RepositoryHarness[T]) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo ports.Repository[T], i int) *T {
+		t.Helper()
+		entity := h.NewEntity(i)
+		if err := repo.Create(ctx, entity); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		return entity
+	}
+
+	t.Run("CreateAssignsIdentityAndTimestamps", func(t *testing.T) {
+		repo, _ := h.New(t)
+		before := time.Now().Add(-time.Second)
+
+		first := create(t, repo, 1)
+		second := create(t, repo, 2)
+
+		base := domain.BaseOf(first)
+		if base.ID == 0 {
+			t.Fatal("expected ID to be assigned")
+		}
+		if domain.BaseOf(second).ID <= base.ID {
+			t.Fatalf("expected increasing IDs, got %d then %d", base.ID, domain.BaseOf(second).ID)
+		}
+		if base.CreatedAt.Before(before) || base.UpdatedAt.Before(before) {
+			t.Fatalf("expected CreatedAt/UpdatedAt to be set, got %v/%v", base.CreatedAt, base.UpdatedAt)
+		}
+		if base.Version != 1 {
+			t.Fatalf("expected version 1, got %d", base.Version)
+		}
+		if _, ok := domain.ParsePublicID(base.PublicID); !ok || base.PublicID == domain.BaseOf(second).PublicID {
+			t.Fatalf("expected distinct public IDs, got %q and %q", base.PublicID, domain.BaseOf(second).PublicID)
+		}
+	})
+
+	t.Run("FindByPublicID", func(t *testing.T) {
+		repo, _ := h.New(t)
+		create(t, repo, 1)
+		entity := create(t, repo, 2)
+
+		found, err := repo.FindByPublicID(ctx, domain.BaseOf(entity).PublicID)
+		if err != nil {
+			t.Fatalf("FindByPublicID: %v", err)
+		}
+		if !h.Equal(found, *entity) {
+			t.Fatalf("found entity differs from created one: %+v vs %+v", found, *entity)
+		}
+
+		if _, err := repo.FindByPublicID(ctx, domain.NewPublicID()); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for missing public ID, got %v", err)
+		}
+	})
+
+	t.Run("FindByID", func(t *testing.T) {
+		repo, _ := h.New(t)
+		entity := create(t, repo, 1)
+
+		found, err := repo.FindByID(ctx, domain.BaseOf(entity).ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if !h.Equal(found, *entity) {
+			t.Fatalf("found entity differs from created one: %+v vs %+v", found, *entity)
+		}
+
+		if _, err := repo.FindByID(ctx, 999999); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for missing ID, got %v", err)
+		}
+	})
+
+	t.Run("FindOneByIsCaseInsensitive", func(t *testing.T) {
+		repo, _ := h.New(t)
+		create(t, repo, 1)
+		entity := create(t, repo, 2)
+
+		value := strings.ToUpper(h.LookupValue(*entity))
+		found, err := repo.FindOneBy(ctx, h.LookupField, value)
+		if err != nil {
+			t.Fatalf("FindOneBy: %v", err)
+		}
+		if found.GetID() != domain.BaseOf(entity).ID {
+			t.Fatalf("expected entity %d, got %d", domain.BaseOf(entity).ID, found.GetID())
+		}
+
+		if _, err := repo.FindOneBy(ctx, h.LookupField, "no-such-value"); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for missing value, got %v", err)
+		}
+		if _, err := repo.FindOneBy(ctx, "no_such_field", value); !errors.Is(err, apperrs.ErrInvalidInput) {
+			t.Fatalf("expected ErrInvalidInput for unknown field, got %v", err)
+		}
+	})
+
+	t.Run("ListByFilters", func(t *testing.T) {
+		repo, _ := h.New(t)
+		create(t, repo, 1)
+		entity := create(t, repo, 2)
+		create(t, repo, 3)
+
+		filters := map[string]any{h.LookupField: strings.ToUpper(h.LookupValue(*entity))}
+		found, total, err := repo.ListBy(ctx, filters, 1, 10)
+		if err != nil {
+			t.Fatalf("ListBy: %v", err)
+		}
+		if total != 1 || len(found) != 1 || found[0].GetID() != domain.BaseOf(entity).ID {
+			t.Fatalf("expected only entity %d, got %d of %d", domain.BaseOf(entity).ID, len(found), total)
+		}
+
+		if _, total, err := repo.ListBy(ctx, nil, 1, 2); err != nil || total != 3 {
+			t.Fatalf("expected no filters to match all 3 entities, got %d, %v", total, err)
+		}
+		if _, _, err := repo.ListBy(ctx, map[string]any{"no_such_field": 1}, 1, 10); !errors.Is(err, apperrs.ErrInvalidInput) {
+			t.Fatalf("expected ErrInvalidInput for unknown field, got %v", err)
+		}
+	})
+
+	t.Run("UpdateBumpsVersion", func(t *testing.T) {
+		repo, _ := h.New(t)
+		entity := create(t, repo, 1)
+
+		h.Mutate(entity)
+		if err := repo.Update(ctx, entity); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if v := domain.BaseOf(entity).Version; v != 2 {
+			t.Fatalf("expected version 2 after update, got %d", v)
+		}
+
+		found, err := repo.FindByID(ctx, domain.BaseOf(entity).ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if !h.Equal(found, *entity) {
+			t.Fatalf("update was not persisted: %+v vs %+v", found, *entity)
+		}
+		if domain.BaseOf(&found).UpdatedAt.Before(domain.BaseOf(&found).CreatedAt) {
+			t.Fatal("expected UpdatedAt not before CreatedAt")
+		}
+	})
+
+	t.Run("UpdateDetectsConflicts", func(t *testing.T) {
+		repo, _ := h.New(t)
+		entity := create(t, repo, 1)
+		stale := *entity
+
+		h.Mutate(entity)
+		if err := repo.Update(ctx, entity); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		h.Mutate(&stale)
+		if err := repo.Update(ctx, &stale); !errors.Is(err, apperrs.ErrConflict) {
+			t.Fatalf("expected ErrConflict for stale update, got %v", err)
+		}
+		if v := domain.BaseOf(&stale).Version; v != 1 {
+			t.Fatalf("expected stale entity to keep version 1, got %d", v)
+		}
+
+		missing := h.NewEntity(2)
+		domain.BaseOf(missing).ID = 999999
+		domain.BaseOf(missing).Version = 1
+		if err := repo.Update(ctx, missing); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for missing entity, got %v", err)
+		}
+	})
+
+	t.Run("ListPaginatesInIDOrder", func(t *testing.T) {
+		repo, _ := h.New(t)
+		var ids []uint
+		for i := 1; i <= 5; i++ {
+			ids = append(ids, domain.BaseOf(create(t, repo, i)).ID)
+		}
+
+		page, total, err := repo.List(ctx, 1, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if total != 5 || len(page) != 2 || page[0].GetID() != ids[0] || page[1].GetID() != ids[1] {
+			t.Fatalf("unexpected first page: total=%d ids=%v", total, entityIDs(page))
+		}
+
+		page, _, err = repo.List(ctx, 3, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page) != 1 || page[0].GetID() != ids[4] {
+			t.Fatalf("unexpected last page: %v", entityIDs(page))
+		}
+
+		page, _, err = repo.List(ctx, 4, 2)
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(page) != 0 {
+			t.Fatalf("expected empty page past the end, got %v", entityIDs(page))
+		}
+	})
+
+	t.Run("ForEachBatchSkipsDeleted", func(t *testing.T) {
+		repo, _ := h.New(t)
+		var ids []uint
+		for i := 1; i <= 5; i++ {
+			ids = append(ids, domain.BaseOf(create(t, repo, i)).ID)
+		}
+		if err := repo.Delete(ctx, ids[2]); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		var batches [][]uint
+		err := repo.ForEachBatch(ctx, 2, func(batch []T) error {
+			batches = append(batches, entityIDs(batch))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ForEachBatch: %v", err)
+		}
+		want := [][]uint{{ids[0], ids[1]}, {ids[3], ids[4]}}
+		if len(batches) != len(want) || !slices.Equal(batches[0], want[0]) || !slices.Equal(batches[1], want[1]) {
+			t.Fatalf("got batches %v, want %v", batches, want)
+		}
+
+		errStop := errors.New("stop")
+		calls := 0
+		err = repo.ForEachBatch(ctx, 1, func(batch []T) error {
+			calls++
+			return errStop
+		})
+		if !errors.Is(err, errStop) || calls != 1 {
+			t.Fatalf("expected iteration to stop at the first error, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("CreateMany", func(t *testing.T) {
+		repo, _ := h.New(t)
+		entities := []*T{h.NewEntity(1), h.NewEntity(2), h.NewEntity(3)}
+
+		if err := repo.CreateMany(ctx, entities); err != nil {
+			t.Fatalf("CreateMany: %v", err)
+		}
+		for i, entity := range entities {
+			base := domain.BaseOf(entity)
+			if base.ID == 0 || base.Version != 1 {
+				t.Fatalf("item %d: expected ID and version 1, got %d/%d", i, base.ID, base.Version)
+			}
+			if i > 0 && base.ID <= domain.BaseOf(entities[i-1]).ID {
+				t.Fatalf("expected IDs in input order, got %v", entityIDs([]T{*entities[i-1], *entity}))
+			}
+		}
+		if _, total, _ := repo.List(ctx, 1, 10); total != 3 {
+			t.Fatalf("expected 3 entities, total=%d", total)
+		}
+		if err := repo.CreateMany(ctx, nil); err != nil {
+			t.Fatalf("CreateMany with no entities: %v", err)
+		}
+	})
+
+	t.Run("UpdateManyIsAllOrNothing", func(t *testing.T) {
+		repo, _ := h.New(t)
+		first := create(t, repo, 1)
+		second := create(t, repo, 2)
+
+		h.Mutate(first)
+		h.Mutate(second)
+		if err := repo.UpdateMany(ctx, []*T{first, second}); err != nil {
+			t.Fatalf("UpdateMany: %v", err)
+		}
+		if domain.BaseOf(first).Version != 2 || domain.BaseOf(second).Version != 2 {
+			t.Fatal("expected both versions bumped")
+		}
+
+		stale := *second
+		domain.BaseOf(&stale).Version = 1
+		h.Mutate(first)
+		err := repo.UpdateMany(ctx, []*T{first, &stale})
+		if !errors.Is(err, apperrs.ErrConflict) {
+			t.Fatalf("expected ErrConflict, got %v", err)
+		}
+		if v := domain.BaseOf(first).Version; v != 2 {
+			t.Fatalf("expected version of the rolled back item restored to 2, got %d", v)
+		}
+		found, err := repo.FindByID(ctx, domain.BaseOf(first).ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if domain.BaseOf(&found).Version != 2 {
+			t.Fatalf("expected the failed batch not to be persisted, got version %d", domain.BaseOf(&found).Version)
+		}
+	})
+
+	t.Run("DeleteManyIsAllOrNothing", func(t *testing.T) {
+		repo, _ := h.New(t)
+		first := domain.BaseOf(create(t, repo, 1)).ID
+		second := domain.BaseOf(create(t, repo, 2)).ID
+		third := domain.BaseOf(create(t, repo, 3)).ID
+
+		if err := repo.DeleteMany(ctx, []uint{first, 999999}); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+		if _, total, _ := repo.List(ctx, 1, 10); total != 3 {
+			t.Fatalf("expected the failed batch not to delete anything, total=%d", total)
+		}
+
+		if err := repo.DeleteMany(ctx, []uint{first, second, first}); err != nil {
+			t.Fatalf("DeleteMany: %v", err)
+		}
+		page, total, _ := repo.List(ctx, 1, 10)
+		if total != 1 || page[0].GetID() != third {
+			t.Fatalf("expected only entity %d left, got %v", third, entityIDs(page))
+		}
+	})
+
+	t.Run("DeleteVersionIsConditional", func(t *testing.T) {
+		repo, _ := h.New(t)
+		entity := create(t, repo, 1)
+		id := domain.BaseOf(entity).ID
+
+		changed := *entity
+		h.Mutate(&changed)
+		if err := repo.Update(ctx, &changed); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		if err := repo.DeleteVersion(ctx, id, 1); !errors.Is(err, apperrs.ErrConflict) {
+			t.Fatalf("expected ErrConflict deleting a stale version, got %v", err)
+		}
+		if _, err := repo.FindByID(ctx, id); err != nil {
+			t.Fatalf("expected the changed entity to survive, got %v", err)
+		}
+		if err := repo.DeleteVersion(ctx, id, 2); err != nil {
+			t.Fatalf("DeleteVersion: %v", err)
+		}
+		if err := repo.DeleteVersion(ctx, id, 2); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		repo, _ := h.New(t)
+		softDelete, ok := repo.(ports.SoftDeleteRepository[T])
+		if !ok {
+			t.Skip("repository does not support soft deletes")
+		}
+
+		kept := create(t, repo, 1)
+		deleted := create(t, repo, 2)
+		id := domain.BaseOf(deleted).ID
+
+		if err := repo.Delete(ctx, id); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repo.Delete(ctx, id); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+		}
+		if _, err := repo.FindByID(ctx, id); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected deleted entity to be hidden, got %v", err)
+		}
+		if _, total, _ := repo.List(ctx, 1, 10); total != 1 {
+			t.Fatalf("expected deleted entity excluded from List, total=%d", total)
+		}
+
+		listed, total, err := softDelete.ListDeleted(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("ListDeleted: %v", err)
+		}
+		if total != 1 || len(listed) != 1 || listed[0].GetID() != id {
+			t.Fatalf("unexpected deleted list: total=%d ids=%v", total, entityIDs(listed))
+		}
+		if found, err := softDelete.FindDeletedByPublicID(ctx, domain.BaseOf(deleted).PublicID); err != nil || found.GetID() != id {
+			t.Fatalf("expected to find the deleted entity by public ID, got %v", err)
+		}
+		if _, err := softDelete.FindDeletedByPublicID(ctx, domain.BaseOf(kept).PublicID); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound for a live entity, got %v", err)
+		}
+
+		if err := softDelete.Restore(ctx, id); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if err := softDelete.Restore(ctx, domain.BaseOf(kept).ID); !errors.Is(err, apperrs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound restoring a live entity, got %v", err)
+		}
+		if _, err := repo.FindByID(ctx, id); err != nil {
+			t.Fatalf("expected restored entity to be visible, got %v", err)
+		}
+
+		if err := repo.Delete(ctx, id); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		purged, err := softDelete.Purge(ctx, time.Now().Add(-time.Hour))
+		if err != nil || purged != 0 {
+			t.Fatalf("expected nothing purged before the retention window, got %d, %v", purged, err)
+		}
+		purged, err = softDelete.Purge(ctx, time.Now().Add(time.Hour))
+		if err != nil || purged != 1 {
+			t.Fatalf("expected one entity purged, got %d, %v", purged, err)
+		}
+		if _, total, _ := softDelete.ListDeleted(ctx, 1, 10); total != 0 {
+			t.Fatalf("expected purged entity gone, total=%d", total)
+		}
+	})
+
+	t.Run("TransactionCommitAndRollback", func(t *testing.T) {
+		repo, tm := h.New(t)
+		if tm == nil {
+			t.Skip("repository has no transaction manager")
+		}
+
+		var committed bool
+		err := tm.WithTransaction(ctx, func(ctx context.Context) error {
+			tm.AfterCommit(ctx, func(context.Context) { committed = true })
+			if err := repo.Create(ctx, h.NewEntity(1)); err != nil {
+				return err
+			}
+			if committed {
+				t.Error("after-commit hook ran before commit")
+			}
+			return nil
+		})
+		if err != nil || !committed {
+			t.Fatalf("expected commit with hook run, got err=%v committed=%v", err, committed)
+		}
+
+		rollback := errors.New("rollback")
+		var rolledBackHook bool
+		err = tm.WithTransaction(ctx, func(ctx context.Context) error {
+			tm.AfterCommit(ctx, func(context.Context) { rolledBackHook = true })
+			if err := repo.Create(ctx, h.NewEntity(2)); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) || rolledBackHook {
+			t.Fatalf("expected rollback without hook, got err=%v hook=%v", err, rolledBackHook)
+		}
+
+		if _, total, _ := repo.List(ctx, 1, 10); total != 1 {
+			t.Fatalf("expected only the committed entity, total=%d", total)
+		}
+	})
+
+	t.Run("NestedTransactionRollsBackToSavepoint", func(t *testing.T) {
+		repo, tm := h.New(t)
+		if tm == nil {
+			t.Skip("repository has no transaction manager")
+		}
+
+		var hooks []string
+		err := tm.WithTransaction(ctx, func(ctx context.Context) error {
+			tm.AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "outer") })
+			if err := repo.Create(ctx, h.NewEntity(1)); err != nil {
+				return err
+			}
+
+			innerErr := tm.WithTransaction(ctx, func(ctx context.Context) error {
+				tm.AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "failed-inner") })
+				if err := repo.Create(ctx, h.NewEntity(2)); err != nil {
+					return err
+				}
+				return errors.New("inner failure")
+			})
+			if innerErr == nil {
+				t.Error("expected inner transaction error")
+			}
+
+			return tm.WithTransaction(ctx, func(ctx context.Context) error {
+				tm.AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "inner") })
+				return repo.Create(ctx, h.NewEntity(3))
+			})
+		})
+		if err != nil {
+			t.Fatalf("outer transaction: %v", err)
+		}
+
+		if _, total, _ := repo.List(ctx, 1, 10); total != 2 {
+			t.Fatalf("expected the savepoint rollback to drop one entity, total=%d", total)
+		}
+		if strings.Join(hooks, ",") != "outer,inner" {
+			t.Fatalf("unexpected after-commit hooks: %v", hooks)
+		}
+	})
+}
+
+// entityIDs returns the IDs of entities, for failure messages
+func entityIDs[T domain.Entity](entities []T) []uint {
+	ids := make([]uint, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.GetID()
+	}
+	return ids
+}
@@ -0,0 +1,19 @@
+package ports
+
+import "context"
+
+// PersonalDataProvider gives access to the personal data one part of the
+// system holds about a user, for data subject requests. Every table storing
+// data tied to users registers a provider with the privacy service.
+type PersonalDataProvider interface {
+	// Name identifies the provider; exports store its data as <name>.json
+	Name() string
+
+	// Export returns the data held about the user, encodable as JSON
+	Export(ctx context.Context, userID uint) (any, error)
+
+	// Erase deletes or anonymizes the data held about the user, joining the
+	// transaction carried by ctx. Rows other data refers to must be
+	// anonymized rather than deleted so that references stay valid.
+	Erase(ctx context.Context, userID uint) error
+}
@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"go-server-boilerplate/internal/app/domain"
+)
+
+// EventPublisher delivers outbox events to other systems. Delivery is at
+// least once, so consumers must tolerate duplicates.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.OutboxEvent) error
+}
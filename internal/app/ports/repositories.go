@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"go-server-boilerplate/internal/app/domain"
 )
@@ -9,23 +10,154 @@ import (
 // Repository defines the base repository operations
 type Repository[T domain.Entity] interface {
 	// Create creates a new entity
-	Create(ctx context.Context, entity T) error
+	Create(ctx context.Context, entity *T) error
 
 	// FindByID retrieves an entity by its ID
 	FindByID(ctx context.Context, id uint) (T, error)
 
-	// Update updates an existing entity
-	Update(ctx context.Context, entity T) error
+	// FindByPublicID retrieves an entity by the public ID exposed to clients
+	FindByPublicID(ctx context.Context, publicID string) (T, error)
+
+	// FindOneBy retrieves the first entity whose field equals value; string
+	// values are compared case-insensitively
+	FindOneBy(ctx context.Context, field string, value any) (T, error)
+
+	// Update updates an existing entity, failing with ErrConflict if its
+	// version no longer matches the stored one
+	Update(ctx context.Context, entity *T) error
 
 	// Delete removes an entity
 	Delete(ctx context.Context, id uint) error
 
+	// DeleteVersion removes an entity, failing with ErrConflict if its
+	// version no longer matches the given one
+	DeleteVersion(ctx context.Context, id uint, version uint) error
+
 	// List retrieves entities with pagination
 	List(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+	// ListBy retrieves, with pagination, the entities whose fields equal the
+	// values in filters; values are compared as in FindOneBy
+	ListBy(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, int64, error)
+
+	// ForEachBatch calls fn with consecutive batches of at most batchSize
+	// entities in ID order, reading one batch at a time; it stops at the
+	// first error returned by fn
+	ForEachBatch(ctx context.Context, batchSize int, fn func(batch []T) error) error
+
+	// CreateMany creates entities using batched inserts; either all of them
+	// are created or none is
+	CreateMany(ctx context.Context, entities []*T) error
+
+	// UpdateMany updates entities with the same version checks as Update;
+	// either all of them are updated or none is
+	UpdateMany(ctx context.Context, entities []*T) error
+
+	// DeleteMany removes the entities with the given IDs; either all of them
+	// are removed or none is
+	DeleteMany(ctx context.Context, ids []uint) error
 }
 
 // TransactionManager defines the interface for database transactions
 type TransactionManager interface {
-	// WithTransaction executes the given function in a transaction
+	// WithTransaction executes the given function in a transaction. The
+	// transaction travels in the context passed to fn; nested calls use
+	// savepoints.
 	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit registers fn to run once the transaction carried by ctx
+	// commits; it is discarded on rollback and runs immediately outside a
+	// transaction
+	AfterCommit(ctx context.Context, fn func(ctx context.Context))
+
+	// InTransaction reports whether ctx carries an open transaction
+	InTransaction(ctx context.Context) bool
+}
+
+// SoftDeleteRepository defines the operations available on repositories
+// whose entities are soft deleted
+type SoftDeleteRepository[T domain.Entity] interface {
+	// ListDeleted retrieves soft-deleted entities with pagination
+	ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+	// FindDeletedByPublicID retrieves a soft-deleted entity by its public ID
+	FindDeletedByPublicID(ctx context.Context, publicID string) (T, error)
+
+	// Restore clears the deletion mark of a soft-deleted entity, failing with
+	// ErrAlreadyExists if a live entity has since taken one of its unique
+	// values
+	Restore(ctx context.Context, id uint) error
+
+	// Purge permanently removes entities soft deleted before the given time
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// SearchResult is an entity found by a search. Highlights holds, per
+// matching column, the HTML-escaped value with the matches wrapped in
+// <mark> tags.
+type SearchResult[T domain.Entity] struct {
+	Entity     T
+	Score      float64
+	Highlights map[string]string
+}
+
+// Searcher is implemented by repositories supporting free-text search
+type Searcher[T domain.Entity] interface {
+	// Search retrieves entities matching query with pagination, best
+	// matches first
+	Search(ctx context.Context, query string, page, pageSize int) ([]SearchResult[T], int64, error)
+}
+
+// AuditRepository defines read access to the audit log; entries are written
+// by the persistence layer as entities change
+type AuditRepository interface {
+	// List retrieves audit entries matching filter with pagination, newest first
+	List(ctx context.Context, filter domain.AuditFilter, page, pageSize int) ([]domain.AuditEntry, int64, error)
+}
+
+// OutboxRepository stores domain events alongside the changes they describe
+type OutboxRepository interface {
+	// Add stores events, joining the transaction carried by ctx
+	Add(ctx context.Context, events ...*domain.OutboxEvent) error
+
+	// Claim retrieves up to limit unpublished events in ID order, leaving
+	// out every aggregate whose oldest unpublished event is not yet due for
+	// another attempt, and leases them until the given time: their next
+	// attempt moves there, so that neither they nor the later events of their
+	// aggregates are claimed again before they are marked or released, or
+	// the lease runs out
+	Claim(ctx context.Context, limit int, until time.Time) ([]domain.OutboxEvent, error)
+
+	// Release makes claimed events due again without counting an attempt
+	Release(ctx context.Context, ids ...uint) error
+
+	// MarkPublished records that an event was delivered
+	MarkPublished(ctx context.Context, id uint) error
+
+	// MarkFailed records a failed delivery and when to try again
+	MarkFailed(ctx context.Context, id uint, cause error, nextAttempt time.Time) error
+
+	// DeletePublished removes events published before the given time
+	DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error)
+}
+
+// DataExportRepository stores personal data export archives
+type DataExportRepository interface {
+	// Create stores a new export
+	Create(ctx context.Context, export *domain.DataExport) error
+
+	// FindLatest retrieves the most recent export of a user, failing with
+	// ErrNotFound if there is none
+	FindLatest(ctx context.Context, userID uint) (domain.DataExport, error)
+
+	// Complete stores the outcome of a pending export: its status, archive,
+	// error and times. Exports no longer pending, or deleted in the
+	// meantime, are left alone.
+	Complete(ctx context.Context, export *domain.DataExport) error
+
+	// DeleteByUser removes every export of a user
+	DeleteByUser(ctx context.Context, userID uint) (int64, error)
+
+	// DeleteExpired removes exports that expired before the given time
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error)
 }
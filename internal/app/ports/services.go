@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"go-server-boilerplate/internal/app/domain"
 )
@@ -9,17 +10,115 @@ import (
 // Service defines the base service operations
 type Service[T domain.Entity] interface {
 	// Create creates a new entity
-	Create(ctx context.Context, entity T) error
+	Create(ctx context.Context, entity *T) error
 
 	// GetByID retrieves an entity by its ID
 	GetByID(ctx context.Context, id uint) (T, error)
 
-	// Update updates an existing entity
-	Update(ctx context.Context, entity T) error
+	// GetByPublicID retrieves an entity by the public ID exposed to clients
+	GetByPublicID(ctx context.Context, publicID string) (T, error)
+
+	// FindOneBy retrieves the first entity whose field equals value; string
+	// values are compared case-insensitively
+	FindOneBy(ctx context.Context, field string, value any) (T, error)
+
+	// Update updates an existing entity, failing with ErrConflict if its
+	// version no longer matches the stored one
+	Update(ctx context.Context, entity *T) error
 
 	// Delete removes an entity
 	Delete(ctx context.Context, id uint) error
 
+	// DeleteVersion removes an entity, failing with ErrConflict if its
+	// version no longer matches the given one
+	DeleteVersion(ctx context.Context, id uint, version uint) error
+
 	// List retrieves entities with pagination
 	List(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+	// ListBy retrieves, with pagination, the entities whose fields equal the
+	// values in filters; string values are compared case-insensitively
+	ListBy(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, int64, error)
+
+	// ForEachBatch calls fn with consecutive batches of entities in ID
+	// order without loading them all at once
+	ForEachBatch(ctx context.Context, batchSize int, fn func(batch []T) error) error
+
+	// CreateMany creates entities in bulk. Unless atomic is set, items that
+	// fail are reported in the result while the others are still created.
+	CreateMany(ctx context.Context, entities []*T, atomic bool) (BatchResult, error)
+
+	// UpdateMany updates entities in bulk with the same partial-failure
+	// semantics as CreateMany
+	UpdateMany(ctx context.Context, entities []*T, atomic bool) (BatchResult, error)
+
+	// DeleteMany removes entities in bulk with the same partial-failure
+	// semantics as CreateMany
+	DeleteMany(ctx context.Context, ids []uint, atomic bool) (BatchResult, error)
+}
+
+// BatchResult reports the outcome of a bulk operation item by item
+type BatchResult struct {
+	// Errors holds the error of each item in input order; nil means the
+	// item succeeded
+	Errors []error
+
+	// Committed reports whether the successful items were persisted. It is
+	// false when an atomic operation was rolled back because an item failed.
+	Committed bool
+}
+
+// Failed returns the number of items that failed
+func (r BatchResult) Failed() int {
+	failed := 0
+	for _, err := range r.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// SoftDeleteService defines the operations for managing soft-deleted entities
+type SoftDeleteService[T domain.Entity] interface {
+	// ListDeleted retrieves soft-deleted entities with pagination
+	ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+	// GetDeletedByPublicID retrieves a soft-deleted entity by its public ID
+	GetDeletedByPublicID(ctx context.Context, publicID string) (T, error)
+
+	// Restore brings back a soft-deleted entity
+	Restore(ctx context.Context, id uint) error
+
+	// Purge permanently removes entities soft deleted before the given time
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// SearchService defines free-text search over entities
+type SearchService[T domain.Entity] interface {
+	// Search retrieves entities matching query with pagination, best
+	// matches first
+	Search(ctx context.Context, query string, page, pageSize int) ([]SearchResult[T], int64, error)
+}
+
+// AuditService defines the operations for querying the audit log
+type AuditService interface {
+	// List retrieves audit entries matching filter with pagination, newest first
+	List(ctx context.Context, filter domain.AuditFilter, page, pageSize int) ([]domain.AuditEntry, int64, error)
+}
+
+// PrivacyService answers data subject requests: access to the personal data
+// held about a user and its erasure
+type PrivacyService interface {
+	// Export returns the current export of a user's data, starting a new one
+	// in the background when there is none, or it failed or expired. The
+	// archive can be downloaded once the export is ready.
+	Export(ctx context.Context, userID uint) (domain.DataExport, error)
+
+	// Erase deletes or anonymizes the personal data held about a user
+	// across every registered provider, in one transaction
+	Erase(ctx context.Context, userID uint) error
+
+	// PurgeExpiredExports removes export archives past their retention
+	PurgeExpiredExports(ctx context.Context) (int64, error)
 }
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// AuditService implements ports.AuditService
+type AuditService struct {
+	repository ports.AuditRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(repository ports.AuditRepository) *AuditService {
+	return &AuditService{
+		repository: repository,
+	}
+}
+
+// List retrieves audit entries matching filter with pagination, newest first
+func (s *AuditService) List(ctx context.Context, filter domain.AuditFilter, page, pageSize int) ([]domain.AuditEntry, int64, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, 0, fmt.Errorf("time range ends before it starts: %w", apperrs.ErrInvalidInput)
+	}
+	return s.repository.List(ctx, filter, page, pageSize)
+}
@@ -2,26 +2,171 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/events"
 	"go-server-boilerplate/internal/app/ports"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
 )
 
+// Event actions recorded in the outbox
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+)
+
+// maxSearchQueryLength bounds the length of a search query
+const maxSearchQueryLength = 200
+
 // BaseService is a generic implementation of the Service interface
 type BaseService[T domain.Entity] struct {
-	repository ports.Repository[T]
+	repository    ports.Repository[T]
+	txManager     ports.TransactionManager
+	outbox        ports.OutboxRepository
+	aggregateType string
+	events        *events.Bus
+	beforeCreate  []ports.BeforeCreateHook[T]
+	afterCreate   []ports.AfterCreateHook[T]
+	beforeUpdate  []ports.BeforeUpdateHook[T]
+	beforeDelete  []ports.BeforeDeleteHook[T]
+}
+
+// Option configures a BaseService
+type Option[T domain.Entity] func(*BaseService[T])
+
+// WithOutbox records an event in the outbox for every entity created,
+// updated, deleted or restored through the service, in the same transaction
+// as the change. Events are named "<aggregateType>.<action>", e.g.
+// "users.created", and carry the entity as JSON (deletions carry its ID).
+func WithOutbox[T domain.Entity](outbox ports.OutboxRepository, aggregateType string) Option[T] {
+	return func(s *BaseService[T]) {
+		s.outbox = outbox
+		s.aggregateType = aggregateType
+	}
+}
+
+// WithEvents publishes EntityCreated, EntityUpdated, EntityDeleted and
+// EntityRestored events on bus once the change has been committed
+func WithEvents[T domain.Entity](bus *events.Bus) Option[T] {
+	return func(s *BaseService[T]) {
+		s.events = bus
+	}
+}
+
+// WithHooks registers lifecycle hooks; each hook implements one or more of
+// ports.BeforeCreateHook, ports.AfterCreateHook, ports.BeforeUpdateHook and
+// ports.BeforeDeleteHook, and hooks run in the order they are registered,
+// after those the entity implements itself.
+// Once a hook is registered every write runs in a transaction so a failing
+// hook rolls it back. It panics if a hook implements none of the interfaces.
+func WithHooks[T domain.Entity](hooks ...any) Option[T] {
+	return func(s *BaseService[T]) {
+		for _, hook := range hooks {
+			registered := false
+			if h, ok := hook.(ports.BeforeCreateHook[T]); ok {
+				s.beforeCreate = append(s.beforeCreate, h)
+				registered = true
+			}
+			if h, ok := hook.(ports.AfterCreateHook[T]); ok {
+				s.afterCreate = append(s.afterCreate, h)
+				registered = true
+			}
+			if h, ok := hook.(ports.BeforeUpdateHook[T]); ok {
+				s.beforeUpdate = append(s.beforeUpdate, h)
+				registered = true
+			}
+			if h, ok := hook.(ports.BeforeDeleteHook[T]); ok {
+				s.beforeDelete = append(s.beforeDelete, h)
+				registered = true
+			}
+			if !registered {
+				panic(fmt.Sprintf("services: %T implements no lifecycle hook", hook))
+			}
+		}
+	}
 }
 
 // NewBaseService creates a new base service
-func NewBaseService[T domain.Entity](repository ports.Repository[T]) *BaseService[T] {
-	return &BaseService[T]{
+func NewBaseService[T domain.Entity](repository ports.Repository[T], txManager ports.TransactionManager, opts ...Option[T]) *BaseService[T] {
+	s := &BaseService[T]{
 		repository: repository,
+		txManager:  txManager,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.registerEntityHooks()
+	return s
+}
+
+// registerEntityHooks puts the hooks T implements itself ahead of the
+// registered ones
+func (s *BaseService[T]) registerEntityHooks() {
+	entity := any(new(T))
+	if _, ok := entity.(ports.EntityBeforeCreateHook); ok {
+		s.beforeCreate = append([]ports.BeforeCreateHook[T]{entityHooks[T]{}}, s.beforeCreate...)
+	}
+	if _, ok := entity.(ports.EntityAfterCreateHook); ok {
+		s.afterCreate = append([]ports.AfterCreateHook[T]{entityHooks[T]{}}, s.afterCreate...)
 	}
+	if _, ok := entity.(ports.EntityBeforeUpdateHook[T]); ok {
+		s.beforeUpdate = append([]ports.BeforeUpdateHook[T]{entityHooks[T]{}}, s.beforeUpdate...)
+	}
+	if _, ok := entity.(ports.EntityBeforeDeleteHook); ok {
+		s.beforeDelete = append([]ports.BeforeDeleteHook[T]{entityHooks[T]{}}, s.beforeDelete...)
+	}
+}
+
+// entityHooks runs the lifecycle hooks an entity implements itself; it is
+// registered only for the interfaces T implements
+type entityHooks[T domain.Entity] struct{}
+
+func (entityHooks[T]) BeforeCreate(ctx context.Context, entity *T) error {
+	return any(entity).(ports.EntityBeforeCreateHook).BeforeCreateHook(ctx)
+}
+
+func (entityHooks[T]) AfterCreate(ctx context.Context, entity *T) error {
+	return any(entity).(ports.EntityAfterCreateHook).AfterCreateHook(ctx)
+}
+
+func (entityHooks[T]) BeforeUpdate(ctx context.Context, old T, entity *T) error {
+	return any(entity).(ports.EntityBeforeUpdateHook[T]).BeforeUpdateHook(ctx, old)
+}
+
+func (entityHooks[T]) BeforeDelete(ctx context.Context, entity T) error {
+	return any(&entity).(ports.EntityBeforeDeleteHook).BeforeDeleteHook(ctx)
+}
+
+// WithTransaction runs fn as a single unit of work; repository calls made
+// with the context passed to fn share the transaction
+func (s *BaseService[T]) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.txManager == nil {
+		return fn(ctx)
+	}
+	return s.txManager.WithTransaction(ctx, fn)
+}
+
+// AfterCommit registers fn to run once the current unit of work commits
+func (s *BaseService[T]) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if s.txManager == nil {
+		fn(ctx)
+		return
+	}
+	s.txManager.AfterCommit(ctx, fn)
 }
 
 // Create creates a new entity
-func (s *BaseService[T]) Create(ctx context.Context, entity T) error {
-	return s.repository.Create(ctx, entity)
+func (s *BaseService[T]) Create(ctx context.Context, entity *T) error {
+	return s.write(ctx, func(ctx context.Context) error {
+		return s.create(ctx, entity)
+	})
 }
 
 // GetByID retrieves an entity by its ID
@@ -29,17 +174,494 @@ func (s *BaseService[T]) GetByID(ctx context.Context, id uint) (T, error) {
 	return s.repository.FindByID(ctx, id)
 }
 
+// GetByPublicID retrieves an entity by its public ID
+func (s *BaseService[T]) GetByPublicID(ctx context.Context, publicID string) (T, error) {
+	return s.repository.FindByPublicID(ctx, publicID)
+}
+
+// FindOneBy retrieves the first entity whose field equals value
+func (s *BaseService[T]) FindOneBy(ctx context.Context, field string, value any) (T, error) {
+	return s.repository.FindOneBy(ctx, field, value)
+}
+
 // Update updates an existing entity
-func (s *BaseService[T]) Update(ctx context.Context, entity T) error {
-	return s.repository.Update(ctx, entity)
+func (s *BaseService[T]) Update(ctx context.Context, entity *T) error {
+	return s.write(ctx, func(ctx context.Context) error {
+		return s.update(ctx, entity)
+	})
 }
 
 // Delete removes an entity
 func (s *BaseService[T]) Delete(ctx context.Context, id uint) error {
-	return s.repository.Delete(ctx, id)
+	return s.write(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, id, func(ctx context.Context) error {
+			return s.repository.Delete(ctx, id)
+		})
+	})
+}
+
+// DeleteVersion removes an entity if it is still at version
+func (s *BaseService[T]) DeleteVersion(ctx context.Context, id uint, version uint) error {
+	return s.write(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, id, func(ctx context.Context) error {
+			return s.repository.DeleteVersion(ctx, id, version)
+		})
+	})
 }
 
 // List retrieves entities with pagination
 func (s *BaseService[T]) List(ctx context.Context, page, pageSize int) ([]T, int64, error) {
 	return s.repository.List(ctx, page, pageSize)
 }
+
+// ListBy retrieves entities matching filters with pagination
+func (s *BaseService[T]) ListBy(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, int64, error) {
+	return s.repository.ListBy(ctx, filters, page, pageSize)
+}
+
+// ForEachBatch calls fn with consecutive batches of entities in ID order
+func (s *BaseService[T]) ForEachBatch(ctx context.Context, batchSize int, fn func(batch []T) error) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive: %w", apperrs.ErrInvalidInput)
+	}
+	return s.repository.ForEachBatch(ctx, batchSize, fn)
+}
+
+// Search retrieves entities matching query with pagination, best matches
+// first; the repository must implement ports.Searcher
+func (s *BaseService[T]) Search(ctx context.Context, query string, page, pageSize int) ([]ports.SearchResult[T], int64, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, 0, fmt.Errorf("search query is required: %w", apperrs.ErrInvalidInput)
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, 0, fmt.Errorf("search query exceeds %d characters: %w", maxSearchQueryLength, apperrs.ErrInvalidInput)
+	}
+
+	searcher, ok := s.repository.(ports.Searcher[T])
+	if !ok {
+		return nil, 0, fmt.Errorf("repository does not support search: %w", apperrs.ErrBadRequest)
+	}
+	return searcher.Search(ctx, query, page, pageSize)
+}
+
+// CreateMany creates entities in bulk, see bulk for the failure semantics
+func (s *BaseService[T]) CreateMany(ctx context.Context, entities []*T, atomic bool) (ports.BatchResult, error) {
+	return s.bulkEntities(ctx, entities, atomic,
+		func(ctx context.Context) error {
+			if err := s.beforeCreating(ctx, entities...); err != nil {
+				return err
+			}
+			if err := s.repository.CreateMany(ctx, entities); err != nil {
+				return err
+			}
+			if err := s.afterCreating(ctx, entities...); err != nil {
+				return err
+			}
+			for _, entity := range entities {
+				notify(ctx, s, events.EntityCreated[T]{Entity: *entity})
+			}
+			return s.recordEvents(ctx, EventCreated, entities...)
+		},
+		func(ctx context.Context, i int) error {
+			return s.create(ctx, entities[i])
+		},
+	)
+}
+
+// UpdateMany updates entities in bulk, see bulk for the failure semantics
+func (s *BaseService[T]) UpdateMany(ctx context.Context, entities []*T, atomic bool) (ports.BatchResult, error) {
+	return s.bulkEntities(ctx, entities, atomic,
+		func(ctx context.Context) error {
+			before, err := s.current(ctx, len(s.beforeUpdate) > 0, entityIDs(entities)...)
+			if err != nil {
+				return err
+			}
+			if err := s.beforeUpdating(ctx, before, entities); err != nil {
+				return err
+			}
+			if err := s.repository.UpdateMany(ctx, entities); err != nil {
+				return err
+			}
+			for i := range before {
+				notify(ctx, s, events.EntityUpdated[T]{Before: before[i], After: *entities[i]})
+			}
+			return s.recordEvents(ctx, EventUpdated, entities...)
+		},
+		func(ctx context.Context, i int) error {
+			return s.update(ctx, entities[i])
+		},
+	)
+}
+
+// DeleteMany removes entities in bulk, see bulk for the failure semantics
+func (s *BaseService[T]) DeleteMany(ctx context.Context, ids []uint, atomic bool) (ports.BatchResult, error) {
+	return s.bulk(ctx, len(ids), atomic,
+		func(ctx context.Context) error {
+			before, err := s.current(ctx, len(s.beforeDelete) > 0 || s.outbox != nil, ids...)
+			if err != nil {
+				return err
+			}
+			if err := s.beforeDeleting(ctx, before); err != nil {
+				return err
+			}
+			if err := s.repository.DeleteMany(ctx, ids); err != nil {
+				return err
+			}
+			for i := range before {
+				notify(ctx, s, events.EntityDeleted[T]{ID: ids[i], Entity: before[i]})
+			}
+			return s.recordDeletions(ctx, EventDeleted, before...)
+		},
+		func(ctx context.Context, i int) error {
+			return s.delete(ctx, ids[i], func(ctx context.Context) error {
+				return s.repository.Delete(ctx, ids[i])
+			})
+		},
+		func() {},
+	)
+}
+
+// bulkEntities runs a bulk operation over entities, restoring their
+// in-memory state whenever the writes made to them are rolled back
+func (s *BaseService[T]) bulkEntities(ctx context.Context, entities []*T, atomic bool, batch func(ctx context.Context) error, item func(ctx context.Context, i int) error) (ports.BatchResult, error) {
+	originals := make([]T, len(entities))
+	for i, entity := range entities {
+		originals[i] = *entity
+	}
+	reset := func() {
+		for i, entity := range entities {
+			*entity = originals[i]
+		}
+	}
+	return s.bulk(ctx, len(entities), atomic, batch, item, reset)
+}
+
+// bulk runs a bulk operation over n items. The batched operation is tried
+// first; if it fails, its writes are rolled back to a savepoint and the
+// items are applied one by one, each in its own savepoint, so failures can
+// be attributed to items. Failed items are reported in the result while the
+// others are committed, unless atomic is set, in which case any failure
+// rolls back the whole operation. reset restores the inputs after a rollback.
+func (s *BaseService[T]) bulk(ctx context.Context, n int, atomic bool, batch func(ctx context.Context) error, item func(ctx context.Context, i int) error, reset func()) (ports.BatchResult, error) {
+	result := ports.BatchResult{Errors: make([]error, n)}
+	if n == 0 {
+		result.Committed = true
+		return result, nil
+	}
+	if s.txManager == nil {
+		return result, fmt.Errorf("bulk operations require a transaction manager: %w", apperrs.ErrBadRequest)
+	}
+
+	errItemsFailed := errors.New("bulk operation items failed")
+	err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.txManager.WithTransaction(ctx, batch); err == nil {
+			return nil
+		}
+		reset()
+
+		failed := false
+		for i := 0; i < n; i++ {
+			result.Errors[i] = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+				return item(ctx, i)
+			})
+			failed = failed || result.Errors[i] != nil
+		}
+		if failed && atomic {
+			return errItemsFailed
+		}
+		return nil
+	})
+	if err != nil {
+		reset()
+		if errors.Is(err, errItemsFailed) {
+			return result, nil
+		}
+		return ports.BatchResult{}, err
+	}
+
+	result.Committed = true
+	return result, nil
+}
+
+// ListDeleted retrieves soft-deleted entities with pagination
+func (s *BaseService[T]) ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	repo, err := s.softDeleteRepository()
+	if err != nil {
+		return nil, 0, err
+	}
+	return repo.ListDeleted(ctx, page, pageSize)
+}
+
+// GetDeletedByPublicID retrieves a soft-deleted entity by its public ID
+func (s *BaseService[T]) GetDeletedByPublicID(ctx context.Context, publicID string) (T, error) {
+	repo, err := s.softDeleteRepository()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return repo.FindDeletedByPublicID(ctx, publicID)
+}
+
+// Restore brings back a soft-deleted entity
+func (s *BaseService[T]) Restore(ctx context.Context, id uint) error {
+	repo, err := s.softDeleteRepository()
+	if err != nil {
+		return err
+	}
+	return s.write(ctx, func(ctx context.Context) error {
+		if err := repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		if s.events == nil && s.outbox == nil {
+			return nil
+		}
+		restored, err := s.repository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		notify(ctx, s, events.EntityRestored[T]{Entity: restored})
+		return s.recordDeletions(ctx, EventRestored, restored)
+	})
+}
+
+// Purge permanently removes entities soft deleted before the given time
+func (s *BaseService[T]) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	repo, err := s.softDeleteRepository()
+	if err != nil {
+		return 0, err
+	}
+	return repo.Purge(ctx, deletedBefore)
+}
+
+// write runs fn in a transaction when its changes must be stored together
+// with outbox events or be rolled back by failing hooks, registered or
+// implemented by the entity
+func (s *BaseService[T]) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooked := len(s.beforeCreate)+len(s.afterCreate)+len(s.beforeUpdate)+len(s.beforeDelete) > 0
+	if s.outbox == nil && !hooked {
+		return fn(ctx)
+	}
+	return s.WithTransaction(ctx, fn)
+}
+
+// create creates an entity and records its event
+func (s *BaseService[T]) create(ctx context.Context, entity *T) error {
+	if err := s.beforeCreating(ctx, entity); err != nil {
+		return err
+	}
+	if err := s.repository.Create(ctx, entity); err != nil {
+		return err
+	}
+	if err := s.afterCreating(ctx, entity); err != nil {
+		return err
+	}
+	notify(ctx, s, events.EntityCreated[T]{Entity: *entity})
+	return s.recordEvents(ctx, EventCreated, entity)
+}
+
+// update updates an entity and records its event
+func (s *BaseService[T]) update(ctx context.Context, entity *T) error {
+	before, err := s.current(ctx, len(s.beforeUpdate) > 0, (*entity).GetID())
+	if err != nil {
+		return err
+	}
+	if err := s.beforeUpdating(ctx, before, []*T{entity}); err != nil {
+		return err
+	}
+	if err := s.repository.Update(ctx, entity); err != nil {
+		return err
+	}
+	if before != nil {
+		notify(ctx, s, events.EntityUpdated[T]{Before: before[0], After: *entity})
+	}
+	return s.recordEvents(ctx, EventUpdated, entity)
+}
+
+// delete removes an entity with remove, running the hooks and recording the
+// events around it
+func (s *BaseService[T]) delete(ctx context.Context, id uint, remove func(ctx context.Context) error) error {
+	before, err := s.current(ctx, len(s.beforeDelete) > 0 || s.outbox != nil, id)
+	if err != nil {
+		return err
+	}
+	if err := s.beforeDeleting(ctx, before); err != nil {
+		return err
+	}
+	if err := remove(ctx); err != nil {
+		return err
+	}
+	if before != nil {
+		notify(ctx, s, events.EntityDeleted[T]{ID: id, Entity: before[0]})
+	}
+	return s.recordDeletions(ctx, EventDeleted, before...)
+}
+
+// current loads the stored state of the given entities for the events
+// published about a change to them and for the hooks run before it; it
+// returns nil without a bus unless hooked is set
+func (s *BaseService[T]) current(ctx context.Context, hooked bool, ids ...uint) ([]T, error) {
+	if s.events == nil && !hooked {
+		return nil, nil
+	}
+	entities := make([]T, len(ids))
+	for i, id := range ids {
+		entity, err := s.repository.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		entities[i] = entity
+	}
+	return entities, nil
+}
+
+// beforeCreating validates entities and runs the before create hooks on them
+func (s *BaseService[T]) beforeCreating(ctx context.Context, entities ...*T) error {
+	for _, entity := range entities {
+		if err := validate(entity); err != nil {
+			return err
+		}
+		for _, hook := range s.beforeCreate {
+			if err := hook.BeforeCreate(ctx, entity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// afterCreating runs the after create hooks on entities
+func (s *BaseService[T]) afterCreating(ctx context.Context, entities ...*T) error {
+	for _, entity := range entities {
+		for _, hook := range s.afterCreate {
+			if err := hook.AfterCreate(ctx, entity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// beforeUpdating validates entities and runs the before update hooks on
+// them; old holds their stored states, loaded whenever such hooks exist
+func (s *BaseService[T]) beforeUpdating(ctx context.Context, old []T, entities []*T) error {
+	for i, entity := range entities {
+		if err := validate(entity); err != nil {
+			return err
+		}
+		for _, hook := range s.beforeUpdate {
+			if err := hook.BeforeUpdate(ctx, old[i], entity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// beforeDeleting runs the before delete hooks on the stored states of the
+// entities about to be deleted
+func (s *BaseService[T]) beforeDeleting(ctx context.Context, old []T) error {
+	for _, entity := range old {
+		for _, hook := range s.beforeDelete {
+			if err := hook.BeforeDelete(ctx, entity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks entity if it implements domain.Validatable; the error
+// matches apperrs.ErrInvalidInput
+func validate[T domain.Entity](entity *T) error {
+	validatable, ok := any(entity).(domain.Validatable)
+	if !ok {
+		return nil
+	}
+	err := validatable.Validate()
+	if err == nil || errors.Is(err, apperrs.ErrInvalidInput) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", apperrs.ErrInvalidInput, err)
+}
+
+// notify publishes event on the service's bus once the current unit of work
+// commits; events of rolled back work are never published
+func notify[T domain.Entity, E any](ctx context.Context, s *BaseService[T], event E) {
+	if s.events == nil {
+		return
+	}
+	s.AfterCommit(ctx, func(ctx context.Context) {
+		events.Publish(ctx, s.events, event)
+	})
+}
+
+// entityIDs returns the IDs of entities
+func entityIDs[T domain.Entity](entities []*T) []uint {
+	ids := make([]uint, len(entities))
+	for i, entity := range entities {
+		ids[i] = (*entity).GetID()
+	}
+	return ids
+}
+
+// recordEvents adds an outbox event carrying each entity
+func (s *BaseService[T]) recordEvents(ctx context.Context, action string, entities ...*T) error {
+	if s.outbox == nil || len(entities) == 0 {
+		return nil
+	}
+	events := make([]*domain.OutboxEvent, len(entities))
+	for i, entity := range entities {
+		payload, err := json.Marshal(entity)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", action, err)
+		}
+		events[i] = s.newEvent(action, *entity, payload)
+	}
+	return s.outbox.Add(ctx, events...)
+}
+
+// recordDeletions adds an outbox event carrying the public ID of each
+// entity, the "id" of the entities carried by the other events
+func (s *BaseService[T]) recordDeletions(ctx context.Context, action string, entities ...T) error {
+	if s.outbox == nil || len(entities) == 0 {
+		return nil
+	}
+	events := make([]*domain.OutboxEvent, len(entities))
+	for i, entity := range entities {
+		identified, ok := any(entity).(interface{ GetPublicID() string })
+		if !ok {
+			return fmt.Errorf("%s event requires a public ID: %w", action, apperrs.ErrBadRequest)
+		}
+		payload, err := json.Marshal(map[string]string{"id": identified.GetPublicID()})
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", action, err)
+		}
+		events[i] = s.newEvent(action, entity, payload)
+	}
+	return s.outbox.Add(ctx, events...)
+}
+
+// newEvent creates an outbox event about entity for the service's aggregate
+// type; entities without a public ID are sent to subscribers without one
+func (s *BaseService[T]) newEvent(action string, entity T, payload []byte) *domain.OutboxEvent {
+	event := &domain.OutboxEvent{
+		AggregateType: s.aggregateType,
+		AggregateID:   entity.GetID(),
+		EventType:     s.aggregateType + "." + action,
+		Payload:       payload,
+	}
+	if identified, ok := any(entity).(interface{ GetPublicID() string }); ok {
+		event.AggregatePublicID = identified.GetPublicID()
+	}
+	return event
+}
+
+// softDeleteRepository returns the repository as a SoftDeleteRepository if it supports it
+func (s *BaseService[T]) softDeleteRepository() (ports.SoftDeleteRepository[T], error) {
+	repo, ok := s.repository.(ports.SoftDeleteRepository[T])
+	if !ok {
+		return nil, fmt.Errorf("repository does not support soft deletes: %w", apperrs.ErrBadRequest)
+	}
+	return repo, nil
+}
@@ -0,0 +1,285 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/events"
+	"go-server-boilerplate/internal/app/services"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+func newUserService(t *testing.T) (*services.BaseService[models.User], []*models.User) {
+	t.Helper()
+	tm := memory.NewTransactionManager()
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm)
+
+	users := make([]*models.User, 3)
+	for i := range users {
+		users[i] = &models.User{Email: fmt.Sprintf("user%d@example.com", i), PasswordHash: "hash"}
+	}
+	if _, err := service.CreateMany(context.Background(), users, true); err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+	return service, users
+}
+
+func TestUpdateManyReportsPartialFailures(t *testing.T) {
+	ctx := context.Background()
+	service, users := newUserService(t)
+
+	stale := *users[1]
+	stale.Version = 7
+	first, third := *users[0], *users[2]
+	first.FirstName, stale.FirstName, third.FirstName = "a", "b", "c"
+
+	result, err := service.UpdateMany(ctx, []*models.User{&first, &stale, &third}, false)
+	if err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	if !result.Committed || result.Failed() != 1 || !errors.Is(result.Errors[1], apperrs.ErrConflict) {
+		t.Fatalf("expected only item 1 to fail with a conflict, got %+v", result)
+	}
+	if first.Version != 2 || third.Version != 2 || stale.Version != 7 {
+		t.Fatalf("unexpected versions %d/%d/%d", first.Version, stale.Version, third.Version)
+	}
+
+	stored, _ := service.GetByID(ctx, third.ID)
+	if stored.FirstName != "c" {
+		t.Fatalf("expected successful items to be committed, got %q", stored.FirstName)
+	}
+}
+
+func TestAtomicDeleteManyRollsBack(t *testing.T) {
+	ctx := context.Background()
+	service, users := newUserService(t)
+
+	result, err := service.DeleteMany(ctx, []uint{users[0].ID, 999, users[2].ID}, true)
+	if err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	if result.Committed || result.Failed() != 1 || !errors.Is(result.Errors[1], apperrs.ErrNotFound) {
+		t.Fatalf("expected a rolled back batch failing on item 1, got %+v", result)
+	}
+	if _, total, _ := service.List(ctx, 1, 10); total != 3 {
+		t.Fatalf("expected nothing deleted, total=%d", total)
+	}
+}
+
+func TestAtomicUpdateManyRestoresEntities(t *testing.T) {
+	ctx := context.Background()
+	service, users := newUserService(t)
+
+	first, stale := *users[0], *users[1]
+	stale.Version = 7
+	first.FirstName = "changed"
+
+	result, err := service.UpdateMany(ctx, []*models.User{&first, &stale}, true)
+	if err != nil {
+		t.Fatalf("UpdateMany: %v", err)
+	}
+	if result.Committed || result.Errors[0] != nil || !errors.Is(result.Errors[1], apperrs.ErrConflict) {
+		t.Fatalf("expected a rolled back batch failing on item 1, got %+v", result)
+	}
+	if first.Version != 1 {
+		t.Fatalf("expected the rolled back item to keep version 1, got %d", first.Version)
+	}
+
+	stored, _ := service.GetByID(ctx, first.ID)
+	if stored.FirstName != "" || stored.Version != 1 {
+		t.Fatalf("expected nothing persisted, got %q at version %d", stored.FirstName, stored.Version)
+	}
+}
+
+func TestServicePublishesEventsAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	bus := events.NewBus()
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm,
+		services.WithEvents[models.User](bus))
+
+	var created []events.EntityCreated[models.User]
+	var updated []events.EntityUpdated[models.User]
+	events.Subscribe(bus, "created", func(ctx context.Context, e events.EntityCreated[models.User]) error {
+		created = append(created, e)
+		return nil
+	})
+	events.Subscribe(bus, "updated", func(ctx context.Context, e events.EntityUpdated[models.User]) error {
+		updated = append(updated, e)
+		return nil
+	})
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	err := service.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := service.Create(ctx, user); err != nil {
+			return err
+		}
+		if len(created) != 0 {
+			t.Fatalf("event published before commit")
+		}
+		user.FirstName = "Ada"
+		return service.Update(ctx, user)
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	if len(created) != 1 || len(updated) != 1 {
+		t.Fatalf("got %d created and %d updated events, want 1 each", len(created), len(updated))
+	}
+	if updated[0].Before.FirstName != "" || updated[0].After.FirstName != "Ada" || updated[0].After.Version != 2 {
+		t.Fatalf("unexpected update event %+v", updated[0])
+	}
+
+	// Rolled back changes publish nothing
+	fresh, stale := *user, *user
+	stale.Version = 7
+	result, err := service.UpdateMany(ctx, []*models.User{&fresh, &stale}, true)
+	if err != nil || result.Committed {
+		t.Fatalf("expected the atomic batch to roll back, got %+v, %v", result, err)
+	}
+	if len(updated) != 1 {
+		t.Fatalf("got %d updated events after rollback, want 1", len(updated))
+	}
+}
+
+// roleGuard is a lifecycle hook protecting admins
+type roleGuard struct {
+	created []string
+}
+
+func (g *roleGuard) BeforeCreate(ctx context.Context, user *models.User) error {
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	return nil
+}
+
+func (g *roleGuard) AfterCreate(ctx context.Context, user *models.User) error {
+	g.created = append(g.created, user.Email)
+	return nil
+}
+
+func (g *roleGuard) BeforeUpdate(ctx context.Context, old models.User, user *models.User) error {
+	if old.Role == "admin" && user.Role != "admin" {
+		return domain.ValidationErrors{{Field: "role", Message: "admins cannot be demoted"}}
+	}
+	return nil
+}
+
+func (g *roleGuard) BeforeDelete(ctx context.Context, user models.User) error {
+	if user.Role == "admin" {
+		return fmt.Errorf("admins cannot be deleted: %w", apperrs.ErrForbidden)
+	}
+	return nil
+}
+
+func TestServiceRunsValidationAndHooks(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	guard := &roleGuard{}
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm,
+		services.WithHooks[models.User](guard))
+
+	invalid := &models.User{Email: "not-an-email", Role: "root", PasswordHash: "hash"}
+	err := service.Create(ctx, invalid)
+	var validationErrs domain.ValidationErrors
+	if !errors.Is(err, apperrs.ErrInvalidInput) || !errors.As(err, &validationErrs) || len(validationErrs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", err)
+	}
+
+	user := &models.User{Email: "user@example.com", PasswordHash: "hash"}
+	admin := &models.User{Email: "admin@example.com", Role: "admin", PasswordHash: "hash"}
+	if _, err := service.CreateMany(ctx, []*models.User{user, admin}, true); err != nil {
+		t.Fatalf("CreateMany: %v", err)
+	}
+	if user.Role != "user" || len(guard.created) != 2 {
+		t.Fatalf("expected the create hooks to run, got role %q and %v", user.Role, guard.created)
+	}
+
+	admin.Role = "user"
+	if err := service.Update(ctx, admin); !errors.Is(err, apperrs.ErrInvalidInput) {
+		t.Fatalf("expected the update hook to reject the demotion, got %v", err)
+	}
+	if admin.Version != 1 {
+		t.Fatalf("expected the rejected update to be rolled back, got version %d", admin.Version)
+	}
+
+	result, err := service.DeleteMany(ctx, []uint{user.ID, admin.ID}, false)
+	if err != nil {
+		t.Fatalf("DeleteMany: %v", err)
+	}
+	if result.Errors[0] != nil || !errors.Is(result.Errors[1], apperrs.ErrForbidden) {
+		t.Fatalf("expected only the admin deletion to fail, got %+v", result)
+	}
+}
+
+// ticket is an entity running its own lifecycle hooks
+type ticket struct {
+	domain.BaseEntity
+	Title  string
+	Status string
+
+	created bool
+}
+
+func (t *ticket) BeforeCreateHook(ctx context.Context) error {
+	if t.Status == "" {
+		t.Status = "open"
+	}
+	return nil
+}
+
+func (t *ticket) AfterCreateHook(ctx context.Context) error {
+	t.created = t.ID != 0
+	return nil
+}
+
+func (t *ticket) BeforeUpdateHook(ctx context.Context, old ticket) error {
+	if old.Status == "closed" {
+		return domain.ValidationErrors{{Field: "status", Message: "closed tickets cannot be changed"}}
+	}
+	return nil
+}
+
+func (t ticket) BeforeDeleteHook(ctx context.Context) error {
+	if t.Status != "closed" {
+		return fmt.Errorf("only closed tickets can be deleted: %w", apperrs.ErrForbidden)
+	}
+	return nil
+}
+
+func TestServiceRunsEntityHooks(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	service := services.NewBaseService[ticket](memory.NewRepository[ticket](tm), tm)
+
+	item := &ticket{Title: "Broken link"}
+	if err := service.Create(ctx, item); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if item.Status != "open" || !item.created {
+		t.Fatalf("expected the create hooks to run, got %+v", item)
+	}
+
+	if err := service.Delete(ctx, item.ID); !errors.Is(err, apperrs.ErrForbidden) {
+		t.Fatalf("expected the delete hook to refuse an open ticket, got %v", err)
+	}
+
+	item.Status = "closed"
+	if err := service.Update(ctx, item); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	item.Title = "Reopened"
+	if err := service.Update(ctx, item); !errors.Is(err, apperrs.ErrInvalidInput) {
+		t.Fatalf("expected the update hook to refuse a closed ticket, got %v", err)
+	}
+
+	if err := service.Delete(ctx, item.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
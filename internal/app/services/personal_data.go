@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+)
+
+// personalDataPageSize is the number of entities read per page by an
+// EntityDataProvider
+const personalDataPageSize = 100
+
+// EntityDataProvider implements ports.PersonalDataProvider for the entities
+// of T belonging to a user, those whose owner field holds the user's ID. It
+// works through the entity's service so that hooks, events, caches and the
+// audit log see erasures like any other change.
+type EntityDataProvider[T domain.Entity] struct {
+	name       string
+	service    ports.Service[T]
+	ownerField string
+	anonymize  func(*T)
+}
+
+// NewEntityDataProvider creates a provider for the entities of T whose
+// ownerField holds the user's ID; use "id" for the user entity itself.
+// Erase updates each entity after passing it to anonymize, or deletes the
+// entities when anonymize is nil.
+func NewEntityDataProvider[T domain.Entity](name string, service ports.Service[T], ownerField string, anonymize func(*T)) *EntityDataProvider[T] {
+	return &EntityDataProvider[T]{
+		name:       name,
+		service:    service,
+		ownerField: ownerField,
+		anonymize:  anonymize,
+	}
+}
+
+// Name identifies the provider
+func (p *EntityDataProvider[T]) Name() string {
+	return p.name
+}
+
+// Export returns the entities belonging to the user
+func (p *EntityDataProvider[T]) Export(ctx context.Context, userID uint) (any, error) {
+	return p.owned(ctx, userID)
+}
+
+// Erase anonymizes or deletes the entities belonging to the user
+func (p *EntityDataProvider[T]) Erase(ctx context.Context, userID uint) error {
+	entities, err := p.owned(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range entities {
+		if p.anonymize == nil {
+			err = p.service.Delete(ctx, entities[i].GetID())
+		} else {
+			p.anonymize(&entities[i])
+			err = p.service.Update(ctx, &entities[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// owned reads every entity belonging to the user
+func (p *EntityDataProvider[T]) owned(ctx context.Context, userID uint) ([]T, error) {
+	filters := map[string]any{p.ownerField: userID}
+	entities := []T{}
+	for page := 1; ; page++ {
+		batch, total, err := p.service.ListBy(ctx, filters, page, personalDataPageSize)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, batch...)
+		if len(batch) < personalDataPageSize || int64(len(entities)) >= total {
+			return entities, nil
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/events"
+	"go-server-boilerplate/internal/app/ports"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// defaultExportRetention is how long export archives are kept unless
+// configured otherwise
+const defaultExportRetention = 24 * time.Hour
+
+// exportTimeout is how long an export may stay pending before it is
+// considered abandoned, e.g. by a restarted server, and started again
+const exportTimeout = 15 * time.Minute
+
+// exportManifest describes the content of an export archive
+type exportManifest struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// PrivacyService implements ports.PrivacyService over the registered
+// personal data providers
+type PrivacyService struct {
+	exports   ports.DataExportRepository
+	txManager ports.TransactionManager
+	providers []ports.PersonalDataProvider
+	runner    events.AsyncRunner
+	retention time.Duration
+}
+
+// PrivacyOption configures a PrivacyService
+type PrivacyOption func(*PrivacyService)
+
+// WithExportRunner sets how exports are built in the background. By
+// default each export is built in its own goroutine.
+func WithExportRunner(runner events.AsyncRunner) PrivacyOption {
+	return func(s *PrivacyService) {
+		s.runner = runner
+	}
+}
+
+// WithExportRetention sets how long export archives can be downloaded
+func WithExportRetention(retention time.Duration) PrivacyOption {
+	return func(s *PrivacyService) {
+		s.retention = retention
+	}
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService(exports ports.DataExportRepository, txManager ports.TransactionManager, opts ...PrivacyOption) *PrivacyService {
+	s := &PrivacyService{
+		exports:   exports,
+		txManager: txManager,
+		retention: defaultExportRetention,
+		runner: func(name string, fn func(ctx context.Context) error) {
+			go func() {
+				if err := fn(context.Background()); err != nil {
+					logger.Error("Background task failed", zap.String("task", name), zap.Error(err))
+				}
+			}()
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register adds providers; call it before serving requests. Erase runs
+// providers in reverse registration order, so register the provider of the
+// user record first and those of records referring to it after.
+func (s *PrivacyService) Register(providers ...ports.PersonalDataProvider) {
+	s.providers = append(s.providers, providers...)
+}
+
+// Export returns the current export of a user's data, starting a new one
+// when there is none, or it failed, expired or was abandoned
+func (s *PrivacyService) Export(ctx context.Context, userID uint) (domain.DataExport, error) {
+	now := time.Now()
+	latest, err := s.exports.FindLatest(ctx, userID)
+	if err == nil && current(latest, now) {
+		return latest, nil
+	}
+	if err != nil && !errors.Is(err, apperrs.ErrNotFound) {
+		return domain.DataExport{}, err
+	}
+
+	export := &domain.DataExport{
+		UserID:    userID,
+		Status:    domain.DataExportPending,
+		ExpiresAt: now.Add(s.retention),
+	}
+	if err := s.exports.Create(ctx, export); err != nil {
+		return domain.DataExport{}, err
+	}
+	pending := *export
+	s.txManager.AfterCommit(ctx, func(context.Context) {
+		s.runner("data-export", func(ctx context.Context) error {
+			return s.build(ctx, pending)
+		})
+	})
+	return *export, nil
+}
+
+// current reports whether export is still being built or can be downloaded
+func current(export domain.DataExport, now time.Time) bool {
+	switch export.Status {
+	case domain.DataExportPending:
+		return now.Sub(export.CreatedAt) < exportTimeout
+	case domain.DataExportReady:
+		return !export.Expired(now)
+	default:
+		return false
+	}
+}
+
+// build assembles the archive of a pending export and stores the outcome
+func (s *PrivacyService) build(ctx context.Context, export domain.DataExport) error {
+	archive, err := s.archive(ctx, export.UserID)
+
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		export.Status = domain.DataExportFailed
+		export.Error = err.Error()
+	} else {
+		export.Status = domain.DataExportReady
+		export.Archive = archive
+		export.ExpiresAt = now.Add(s.retention)
+	}
+	if completeErr := s.exports.Complete(ctx, &export); completeErr != nil {
+		return completeErr
+	}
+	return err
+}
+
+// archive zips the data of every provider, one JSON file each, along with a
+// manifest listing them
+func (s *PrivacyService) archive(ctx context.Context, userID uint) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	manifest := exportManifest{GeneratedAt: time.Now().UTC(), Files: []string{}}
+
+	for _, provider := range s.providers {
+		data, err := provider.Export(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s data: %w", provider.Name(), err)
+		}
+		name := provider.Name() + ".json"
+		if err := writeJSON(archive, name, data); err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+	if err := writeJSON(archive, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write export archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON adds a file holding data as indented JSON to archive
+func writeJSON(archive *zip.Writer, name string, data any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return nil
+}
+
+// Erase deletes or anonymizes the data of every provider, last registered
+// first, and the user's export archives; either all of it is erased or
+// nothing is
+func (s *PrivacyService) Erase(ctx context.Context, userID uint) error {
+	return s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		for i := len(s.providers) - 1; i >= 0; i-- {
+			provider := s.providers[i]
+			if err := provider.Erase(ctx, userID); err != nil {
+				return fmt.Errorf("failed to erase %s data: %w", provider.Name(), err)
+			}
+		}
+		_, err := s.exports.DeleteByUser(ctx, userID)
+		return err
+	})
+}
+
+// PurgeExpiredExports removes export archives past their retention
+func (s *PrivacyService) PurgeExpiredExports(ctx context.Context) (int64, error) {
+	return s.exports.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/services"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// notesProvider is a personal data provider for records referring to users
+type notesProvider struct {
+	notes map[uint][]string
+	fail  error
+}
+
+func (p *notesProvider) Name() string { return "notes" }
+
+func (p *notesProvider) Export(ctx context.Context, userID uint) (any, error) {
+	return p.notes[userID], nil
+}
+
+func (p *notesProvider) Erase(ctx context.Context, userID uint) error {
+	if p.fail != nil {
+		return p.fail
+	}
+	delete(p.notes, userID)
+	return nil
+}
+
+func TestPrivacyServiceExportAndErase(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	users := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm)
+	exports := memory.NewDataExportRepository(tm)
+
+	var jobs []func(ctx context.Context) error
+	privacy := services.NewPrivacyService(exports, tm, services.WithExportRunner(func(name string, fn func(ctx context.Context) error) {
+		jobs = append(jobs, fn)
+	}))
+	notes := &notesProvider{notes: map[uint][]string{}}
+	privacy.Register(
+		services.NewEntityDataProvider("profile", users, "id", (*models.User).Anonymize),
+		notes,
+	)
+
+	user := &models.User{Email: "ada@example.com", PasswordHash: "hash", FirstName: "Ada"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	notes.notes[user.ID] = []string{"first note"}
+
+	export, err := privacy.Export(ctx, user.ID)
+	if err != nil || export.Status != domain.DataExportPending || len(jobs) != 1 {
+		t.Fatalf("Export = %+v, %v with %d jobs; want a pending export and one job", export, err, len(jobs))
+	}
+	if again, _ := privacy.Export(ctx, user.ID); again.ID != export.ID || len(jobs) != 1 {
+		t.Fatal("a second request started another export while the first was pending")
+	}
+	if err := jobs[0](ctx); err != nil {
+		t.Fatalf("export job: %v", err)
+	}
+
+	ready, err := privacy.Export(ctx, user.ID)
+	if err != nil || ready.ID != export.ID || ready.Status != domain.DataExportReady {
+		t.Fatalf("Export after the job = %+v, %v", ready, err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(ready.Archive), int64(len(ready.Archive)))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		r, _ := file.Open()
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		r.Close()
+		files[file.Name] = buf.Bytes()
+	}
+	var profile []models.User
+	if err := json.Unmarshal(files["profile.json"], &profile); err != nil || len(profile) != 1 || profile[0].Email != "ada@example.com" {
+		t.Fatalf("profile.json = %s, %v", files["profile.json"], err)
+	}
+	if !bytes.Contains(files["notes.json"], []byte("first note")) || files["manifest.json"] == nil {
+		t.Fatalf("archive holds %v", files)
+	}
+
+	// A failing provider rolls the whole erasure back
+	notes.fail = errors.New("notes unavailable")
+	if err := privacy.Erase(ctx, user.ID); err == nil {
+		t.Fatal("Erase succeeded with a failing provider")
+	}
+	if stored, _ := users.GetByID(ctx, user.ID); stored.Email != "ada@example.com" {
+		t.Fatalf("failed erasure changed the user: %+v", stored)
+	}
+
+	notes.fail = nil
+	if err := privacy.Erase(ctx, user.ID); err != nil {
+		t.Fatalf("Erase: %v", err)
+	}
+	erased, err := users.GetByID(ctx, user.ID)
+	if err != nil || erased.Email == "ada@example.com" || erased.FirstName != "" || erased.Active || erased.CheckPassword("hash") {
+		t.Fatalf("erased user = %+v, %v", erased, err)
+	}
+	if _, ok := notes.notes[user.ID]; ok {
+		t.Fatal("notes of the erased user were kept")
+	}
+	if _, err := exports.FindLatest(ctx, user.ID); !errors.Is(err, apperrs.ErrNotFound) {
+		t.Fatalf("exports of the erased user were kept: %v", err)
+	}
+}
@@ -7,7 +7,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWTClaims represents the claims in the JWT token
+// JWTClaims represents the claims in the JWT token; the subject is the
+// public ID of the user
 type JWTClaims struct {
 	UserID uint   `json:"user_id"`
 	Role   string `json:"role"`
@@ -29,11 +30,12 @@ func NewJWTManager(secretKey string, expiryHours int) *JWTManager {
 }
 
 // GenerateToken generates a new JWT token
-func (m *JWTManager) GenerateToken(userID uint, role string) (string, error) {
+func (m *JWTManager) GenerateToken(userID uint, publicID, role string) (string, error) {
 	claims := JWTClaims{
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   publicID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -0,0 +1,59 @@
+// Package cache provides cache backends and a read-through caching decorator
+// for repositories.
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Backend stores opaque values by key with a time to live
+type Backend interface {
+	// Get returns the value stored under key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key for ttl; a zero ttl never expires
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the given keys; missing keys are ignored
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Stats is a snapshot of cache statistics
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Coalesced     uint64 `json:"coalesced"`
+	Invalidations uint64 `json:"invalidations"`
+	Errors        uint64 `json:"errors"`
+}
+
+// HitRatio returns the fraction of lookups served from the cache
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// counters accumulates statistics concurrently
+type counters struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	coalesced     atomic.Uint64
+	invalidations atomic.Uint64
+	errors        atomic.Uint64
+}
+
+// snapshot returns the current values of the counters
+func (c *counters) snapshot() Stats {
+	return Stats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Coalesced:     c.coalesced.Load(),
+		Invalidations: c.invalidations.Load(),
+		Errors:        c.errors.Load(),
+	}
+}
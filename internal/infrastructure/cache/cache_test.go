@@ -0,0 +1,298 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/app/ports/porttest"
+	"go-server-boilerplate/internal/infrastructure/cache"
+	"go-server-boilerplate/internal/infrastructure/cache/redistest"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+
+	"gorm.io/gorm"
+)
+
+// countingRepository counts FindByID calls and blocks them until release is
+// closed
+type countingRepository struct {
+	ports.Repository[models.User]
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepository) FindByID(ctx context.Context, id uint) (models.User, error) {
+	r.loads.Add(1)
+	<-r.release
+	return r.Repository.FindByID(ctx, id)
+}
+
+func newRedis(t *testing.T) (*redistest.Server, *cache.Redis) {
+	t.Helper()
+	server, err := redistest.NewServer("secret")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	backend, err := cache.NewRedis(server.URL(2), cache.WithKeyPrefix("test:"))
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { backend.Close() })
+	return server, backend
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	porttest.RunRepositoryConformance(t, porttest.RepositoryHarness[models.User]{
+		New: func(t *testing.T) (ports.Repository[models.User], ports.TransactionManager) {
+			tm := memory.NewTransactionManager()
+			return cache.NewRepository[models.User](memory.NewRepository[models.User](tm), cache.NewLRU(100), tm), tm
+		},
+		NewEntity: func(i int) *models.User {
+			return &models.User{Email: fmt.Sprintf("user%d@example.com", i), PasswordHash: "hash", Role: "user", Active: true}
+		},
+		Mutate: func(user *models.User) {
+			user.FirstName += "x"
+		},
+		Equal: func(a, b models.User) bool {
+			return a.ID == b.ID && a.Version == b.Version && a.Email == b.Email && a.FirstName == b.FirstName
+		},
+		LookupField: "email",
+		LookupValue: func(user models.User) string { return user.Email },
+	})
+}
+
+func TestLRUEvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	ctx := context.Background()
+	lru := cache.NewLRU(2)
+
+	lru.Set(ctx, "a", []byte("1"), 0)
+	lru.Set(ctx, "b", []byte("2"), 0)
+	lru.Get(ctx, "a")
+	lru.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := lru.Get(ctx, "b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if _, ok, _ := lru.Get(ctx, "a"); !ok {
+		t.Fatal("expected recently used a to be kept")
+	}
+
+	lru.Set(ctx, "d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := lru.Get(ctx, "d"); ok {
+		t.Fatal("expected d to have expired")
+	}
+}
+
+func TestRedisBackend(t *testing.T) {
+	ctx := context.Background()
+	server, backend := newRedis(t)
+
+	if err := backend.Set(ctx, "k", []byte("binary\r\n\x00value"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value, ok, err := backend.Get(ctx, "k")
+	if err != nil || !ok || string(value) != "binary\r\n\x00value" {
+		t.Fatalf("Get = %q, %v, %v", value, ok, err)
+	}
+	if server.Keys(2) != 1 || server.Keys(0) != 0 {
+		t.Fatal("expected the key to be stored in database 2")
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, ok, _ := backend.Get(ctx, "k"); ok {
+		t.Fatal("expected the key to have expired")
+	}
+
+	backend.Set(ctx, "a", []byte("1"), 0)
+	if err := backend.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := backend.Get(ctx, "a"); ok {
+		t.Fatal("expected the key to be deleted")
+	}
+
+	unauthenticated, _ := cache.NewRedis("redis://" + server.Addr())
+	defer unauthenticated.Close()
+	if err := unauthenticated.Ping(ctx); err == nil {
+		t.Fatal("expected commands without AUTH to fail")
+	}
+}
+
+func TestRepositoryCachesAndInvalidates(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	_, backend := newRedis(t)
+	inner := &countingRepository{Repository: memory.NewRepository[models.User](tm), release: make(chan struct{})}
+	close(inner.release)
+	repo := cache.NewRepository[models.User](inner, backend, tm)
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	repo.Create(ctx, user)
+
+	for i := 0; i < 3; i++ {
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil || got.PasswordHash != "hash" {
+			t.Fatalf("FindByID = %+v, %v", got, err)
+		}
+	}
+	if stats := repo.Stats(); inner.loads.Load() != 1 || stats.Hits != 2 || stats.Misses != 1 {
+		t.Fatalf("expected one load and two hits, got %d loads and %+v", inner.loads.Load(), stats)
+	}
+
+	// A write inside a transaction is not visible to the cache until commit,
+	// and reads inside the transaction bypass the cache
+	err := tm.WithTransaction(ctx, func(ctx context.Context) error {
+		user.FirstName = "Ada"
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil || got.FirstName != "Ada" {
+			t.Fatalf("expected the transaction to read its own write, got %+v, %v", got, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	got, _ := repo.FindByID(ctx, user.ID)
+	if got.FirstName != "Ada" || got.Version != 2 {
+		t.Fatalf("expected the update to invalidate the cache, got %+v", got)
+	}
+
+	repo.Delete(ctx, user.ID)
+	if _, err := repo.FindByID(ctx, user.ID); err == nil {
+		t.Fatal("expected the deleted user to be gone")
+	}
+}
+
+func TestRepositoryLoadsMissesFromPrimary(t *testing.T) {
+	ctx := context.Background()
+	open := func() *gorm.DB {
+		db, err := database.Connect(database.Config{URL: "sqlite://file::memory:", Migrate: true})
+		if err != nil {
+			t.Fatalf("Connect: %v", err)
+		}
+		t.Cleanup(func() { database.Close(db) })
+		return db
+	}
+	primary, replica := open(), open()
+
+	// The replica lags behind an update made on the primary
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash", FirstName: "Old"}
+	stale := *user
+	if err := database.NewGormRepository[models.User](replica).Create(ctx, &stale); err != nil {
+		t.Fatalf("Create on replica: %v", err)
+	}
+	inner := database.NewGormRepository[models.User](primary, database.WithReplicas(database.NewReplicaSet(replica)))
+	user.PublicID = stale.PublicID
+	if err := inner.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	user.FirstName = "New"
+	if err := inner.Update(ctx, user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := inner.FindByID(ctx, user.ID); got.FirstName != "Old" {
+		t.Fatalf("expected reads to go to the lagging replica, got %q", got.FirstName)
+	}
+
+	repo := cache.NewRepository[models.User](inner, cache.NewLRU(10), database.NewTransactionManager(primary))
+	for i := 0; i < 2; i++ {
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil || got.FirstName != "New" {
+			t.Fatalf("FindByID = %q, %v; want the primary's state", got.FirstName, err)
+		}
+	}
+	if stats := repo.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("expected one miss and one hit, got %+v", stats)
+	}
+}
+
+func TestRepositoryEncryptsCachedEntities(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	_, backend := newRedis(t)
+	inner := &countingRepository{Repository: memory.NewRepository[models.User](tm), release: make(chan struct{})}
+	close(inner.release)
+	keyring := func(id string) *fieldcrypt.Keyring {
+		k, err := fieldcrypt.NewKeyring(id, map[string][]byte{id: bytes.Repeat([]byte(id), fieldcrypt.KeySize/len(id))}, bytes.Repeat([]byte{9}, fieldcrypt.KeySize))
+		if err != nil {
+			t.Fatalf("NewKeyring: %v", err)
+		}
+		return k
+	}
+	repo := cache.NewRepository[models.User](inner, backend, tm, cache.WithEncryption(keyring("k1")))
+
+	user := &models.User{Email: "ada@example.com", FirstName: "Ada", PasswordHash: "secret-hash"}
+	repo.Create(ctx, user)
+	for i := 0; i < 2; i++ {
+		if got, err := repo.FindByID(ctx, user.ID); err != nil || got.Email != "ada@example.com" || got.PasswordHash != "secret-hash" {
+			t.Fatalf("FindByID = %+v, %v", got, err)
+		}
+	}
+	if inner.loads.Load() != 1 {
+		t.Fatalf("expected the second read to be a hit, got %d loads", inner.loads.Load())
+	}
+
+	stored, ok, err := backend.Get(ctx, fmt.Sprintf("user:%d", user.ID))
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if !bytes.HasPrefix(stored, []byte("enc:k1:")) || bytes.Contains(stored, []byte("ada@example.com")) || bytes.Contains(stored, []byte("secret-hash")) {
+		t.Fatalf("cached entity is not encrypted: %q", stored)
+	}
+
+	// Entries under a key the keyring no longer holds are misses
+	rotated := cache.NewRepository[models.User](inner, backend, tm, cache.WithEncryption(keyring("k2")))
+	if got, err := rotated.FindByID(ctx, user.ID); err != nil || got.Email != "ada@example.com" || inner.loads.Load() != 2 {
+		t.Fatalf("FindByID after rotation = %+v, %v with %d loads", got, err, inner.loads.Load())
+	}
+}
+
+func TestRepositoryCoalescesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	tm := memory.NewTransactionManager()
+	inner := &countingRepository{Repository: memory.NewRepository[models.User](tm), release: make(chan struct{})}
+	repo := cache.NewRepository[models.User](inner, cache.NewLRU(10), tm)
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	repo.Create(ctx, user)
+
+	const readers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.FindByID(ctx, user.ID); err != nil {
+				t.Errorf("FindByID: %v", err)
+			}
+		}()
+	}
+
+	// Let every reader reach the shared load before it completes
+	for repo.Stats().Misses < readers {
+		time.Sleep(time.Millisecond)
+	}
+	close(inner.release)
+	wg.Wait()
+
+	if loads := inner.loads.Load(); loads != 1 {
+		t.Fatalf("expected concurrent misses to share one load, got %d", loads)
+	}
+	if stats := repo.Stats(); stats.Coalesced != readers {
+		t.Fatalf("expected %d coalesced lookups, got %+v", readers, stats)
+	}
+}
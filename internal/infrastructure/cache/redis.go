@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRedisTimeout bounds each command whose context has no deadline
+	defaultRedisTimeout = time.Second
+
+	// defaultRedisPoolSize is the number of idle connections kept open
+	defaultRedisPoolSize = 10
+)
+
+// RedisOption configures a Redis backend
+type RedisOption func(*Redis)
+
+// WithKeyPrefix prepends prefix to every key, so that several applications
+// can share a database
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(r *Redis) {
+		r.prefix = prefix
+	}
+}
+
+// WithRedisTimeout bounds each command whose context has no deadline
+func WithRedisTimeout(timeout time.Duration) RedisOption {
+	return func(r *Redis) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// WithPoolSize sets the number of idle connections kept open
+func WithPoolSize(size int) RedisOption {
+	return func(r *Redis) {
+		if size > 0 {
+			r.idle = make(chan *redisConn, size)
+		}
+	}
+}
+
+// Redis is a Backend storing values in a Redis server. It speaks the RESP
+// protocol directly and only needs GET, SET with PX and DEL, plus AUTH and
+// SELECT when the URL asks for them.
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	prefix   string
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+// NewRedis creates a Redis backend from a redis://[user:password@]host:port/db
+// URL. Connections are opened lazily.
+func NewRedis(rawURL string, opts ...RedisOption) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported redis URL scheme %q", u.Scheme)
+	}
+
+	r := &Redis{
+		addr:    u.Host,
+		timeout: defaultRedisTimeout,
+		idle:    make(chan *redisConn, defaultRedisPoolSize),
+	}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		if r.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", path)
+		}
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Get returns the value stored under key
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected redis reply %T to GET", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores value under key, expiring it after ttl if ttl is positive
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []any{"SET", r.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// Delete removes the given keys
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, r.prefix+key)
+	}
+	_, err := r.do(ctx, args...)
+	return err
+}
+
+// Ping checks that the server is reachable
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command on a pooled connection and returns its reply. Server
+// error replies are returned as errors; connections that failed at the
+// protocol level are discarded.
+func (r *Redis) do(ctx context.Context, args ...any) (any, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(r.deadline(ctx), args...)
+	var serverErr redisError
+	if err != nil && !errors.As(err, &serverErr) {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection or dials a new one
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: r.timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if r.password != "" {
+		args := []any{"AUTH", r.password}
+		if r.username != "" {
+			args = []any{"AUTH", r.username, r.password}
+		}
+		if _, err := conn.do(r.deadline(ctx), args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis authentication failed: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.do(r.deadline(ctx), "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database %d: %w", r.db, err)
+		}
+	}
+	return conn, nil
+}
+
+// deadline returns the deadline of ctx, or the default timeout from now
+func (r *Redis) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(r.timeout)
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisConn is one connection to a Redis server
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do writes a command as an array of bulk strings and reads its reply
+func (c *redisConn) do(deadline time.Time, args ...any) (any, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = append(b, '*')
+	b = strconv.AppendInt(b, int64(len(args)), 10)
+	b = append(b, "\r\n"...)
+	for _, arg := range args {
+		var value []byte
+		switch v := arg.(type) {
+		case string:
+			value = []byte(v)
+		case []byte:
+			value = v
+		default:
+			return nil, fmt.Errorf("unsupported redis argument %T", arg)
+		}
+		b = append(b, '$')
+		b = strconv.AppendInt(b, int64(len(value)), 10)
+		b = append(b, "\r\n"...)
+		b = append(b, value...)
+		b = append(b, "\r\n"...)
+	}
+	if _, err := c.Write(b); err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// readReply reads one RESP reply: simple strings and integers as such, bulk
+// strings as []byte, nil bulk strings and arrays as nil and arrays as []any
+func readReply(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed redis reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("malformed redis bulk length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("malformed redis array length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply type %q", kind)
+	}
+}
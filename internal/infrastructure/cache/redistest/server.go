@@ -0,0 +1,360 @@
+// Package redistest provides an in-memory stand-in for a Redis server, so
+// that code using Redis can be tested without one.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// item is one stored value
+type item struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Server is an in-memory Redis stand-in listening on a local port. It
+// supports PING, AUTH, SELECT, GET, SET (with EX, PX and NX), DEL, EXISTS,
+// DBSIZE and FLUSHDB, which is enough for the cache backend.
+type Server struct {
+	listener net.Listener
+	password string
+
+	mu    sync.Mutex
+	dbs   map[int]map[string]item
+	conns map[net.Conn]struct{}
+	now   func() time.Time
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a server on a random local port; when password is set
+// clients must AUTH before running other commands
+func NewServer(password string) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		password: password,
+		dbs:      make(map[int]map[string]item),
+		conns:    make(map[net.Conn]struct{}),
+		now:      time.Now,
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL returns a redis:// URL for database db of the server
+func (s *Server) URL(db int) string {
+	if s.password != "" {
+		return fmt.Sprintf("redis://:%s@%s/%d", s.password, s.Addr(), db)
+	}
+	return fmt.Sprintf("redis://%s/%d", s.Addr(), db)
+}
+
+// Keys returns the number of unexpired keys in database db
+func (s *Server) Keys(db int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dbsize(db)
+}
+
+// FastForward moves the server clock forward, expiring keys whose time to
+// live has passed
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now
+	s.now = func() time.Time { return now().Add(d) }
+}
+
+// Close stops the server and closes every client connection
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// handle runs the commands of one connection
+func (s *Server) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	db := 0
+	authenticated := s.password == ""
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			if err != io.EOF {
+				writeError(writer, "ERR Protocol error: "+err.Error())
+				writer.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := strings.ToUpper(string(args[0]))
+		switch {
+		case name == "AUTH":
+			password := args[len(args)-1]
+			if s.password == "" || string(password) != s.password {
+				writeError(writer, "WRONGPASS invalid username-password pair")
+			} else {
+				authenticated = true
+				writeSimple(writer, "OK")
+			}
+		case !authenticated:
+			writeError(writer, "NOAUTH Authentication required.")
+		case name == "SELECT":
+			n, err := strconv.Atoi(string(arg(args, 1)))
+			if err != nil || n < 0 || n > 15 {
+				writeError(writer, "ERR DB index is out of range")
+			} else {
+				db = n
+				writeSimple(writer, "OK")
+			}
+		default:
+			s.mu.Lock()
+			s.run(writer, db, name, args[1:])
+			s.mu.Unlock()
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// run executes a data command against database db; the caller holds the lock
+func (s *Server) run(w *bufio.Writer, db int, name string, args [][]byte) {
+	data := s.dbs[db]
+	if data == nil {
+		data = make(map[string]item)
+		s.dbs[db] = data
+	}
+
+	switch name {
+	case "PING":
+		writeSimple(w, "PONG")
+	case "GET":
+		if len(args) != 1 {
+			writeArity(w, name)
+			return
+		}
+		if it, ok := s.lookup(data, string(args[0])); ok {
+			writeBulk(w, it.value)
+		} else {
+			writeNil(w)
+		}
+	case "SET":
+		if len(args) < 2 {
+			writeArity(w, name)
+			return
+		}
+		key := string(args[0])
+		it := item{value: append([]byte(nil), args[1]...)}
+		nx := false
+		for i := 2; i < len(args); i++ {
+			switch option := strings.ToUpper(string(args[i])); option {
+			case "NX":
+				nx = true
+			case "EX", "PX":
+				n, err := strconv.ParseInt(string(arg(args, i+1)), 10, 64)
+				if err != nil || n <= 0 {
+					writeError(w, "ERR invalid expire time in 'set' command")
+					return
+				}
+				unit := time.Second
+				if option == "PX" {
+					unit = time.Millisecond
+				}
+				it.expiresAt = s.now().Add(time.Duration(n) * unit)
+				i++
+			default:
+				writeError(w, "ERR syntax error")
+				return
+			}
+		}
+		if _, exists := s.lookup(data, key); nx && exists {
+			writeNil(w)
+			return
+		}
+		data[key] = it
+		writeSimple(w, "OK")
+	case "DEL", "EXISTS":
+		if len(args) == 0 {
+			writeArity(w, name)
+			return
+		}
+		n := 0
+		for _, key := range args {
+			if _, ok := s.lookup(data, string(key)); ok {
+				n++
+				if name == "DEL" {
+					delete(data, string(key))
+				}
+			}
+		}
+		writeInt(w, n)
+	case "DBSIZE":
+		writeInt(w, s.dbsize(db))
+	case "FLUSHDB":
+		delete(s.dbs, db)
+		writeSimple(w, "OK")
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+}
+
+// lookup returns the unexpired item stored under key, dropping it if it
+// has expired; the caller holds the lock
+func (s *Server) lookup(data map[string]item, key string) (item, bool) {
+	it, ok := data[key]
+	if !ok {
+		return item{}, false
+	}
+	if !it.expiresAt.IsZero() && !s.now().Before(it.expiresAt) {
+		delete(data, key)
+		return item{}, false
+	}
+	return it, true
+}
+
+// dbsize counts the unexpired keys of database db; the caller holds the lock
+func (s *Server) dbsize(db int) int {
+	n := 0
+	for key := range s.dbs[db] {
+		if _, ok := s.lookup(s.dbs[db], key); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// readCommand reads a command sent as an array of bulk strings
+func readCommand(reader *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		// Inline command, as typed in a telnet session
+		var args [][]byte
+		for _, field := range strings.Fields(line) {
+			args = append(args, []byte(field))
+		}
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid multibulk length")
+	}
+	args := make([][]byte, n)
+	for i := range args {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("expected '$', got '%s'", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid bulk length")
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = value[:size]
+	}
+	return args, nil
+}
+
+// readLine reads a CRLF-terminated line without its terminator
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// arg returns args[i], or nil if there are not enough arguments
+func arg(args [][]byte, i int) []byte {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+func writeError(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "-%s\r\n", s)
+}
+
+func writeArity(w *bufio.Writer, name string) {
+	writeError(w, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+func writeInt(w *bufio.Writer, n int) {
+	fmt.Fprintf(w, ":%d\r\n", n)
+}
+
+func writeBulk(w *bufio.Writer, value []byte) {
+	fmt.Fprintf(w, "$%d\r\n", len(value))
+	w.Write(value)
+	w.WriteString("\r\n")
+}
+
+func writeNil(w *bufio.Writer) {
+	w.WriteString("$-1\r\n")
+}
@@ -0,0 +1,399 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/database"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultTTL is how long entities are cached unless WithTTL says otherwise
+const DefaultTTL = 5 * time.Minute
+
+// RepositoryOption configures a caching Repository
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	ttl       time.Duration
+	namespace string
+	keyring   *fieldcrypt.Keyring
+}
+
+// WithTTL sets how long entities stay cached; it bounds how long a reader
+// can see a value that a concurrent write has replaced
+func WithTTL(ttl time.Duration) RepositoryOption {
+	return func(o *repositoryOptions) {
+		if ttl > 0 {
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithNamespace sets the prefix of the cache keys, which defaults to the
+// lower-cased entity type name
+func WithNamespace(namespace string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithEncryption encrypts cached entities with keyring. Use it when the
+// backend stores entities outside the process, as Redis does, and they have
+// fields encrypted at rest: entities are cached decrypted, so without it
+// those fields would be stored in plaintext. Entries that cannot be
+// decrypted, such as those written before the option was set or under keys
+// since removed from the keyring, are treated as misses.
+func WithEncryption(keyring *fieldcrypt.Keyring) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.keyring = keyring
+	}
+}
+
+// Repository is a read-through caching decorator for a ports.Repository.
+// FindByID is served from the backend when possible; misses load the entity
+// once however many requests ask for it concurrently, and store it for the
+// configured TTL. Writes through the decorator invalidate the entities they
+// change, again after commit when they run in a transaction. Reads inside a
+// transaction bypass the cache so they see the transaction's own writes.
+//
+// Entities are stored with encoding/gob, which keeps fields hidden from JSON
+// such as password hashes, and encrypted when WithEncryption is given.
+// Backend failures are logged and counted, and the read falls back to the
+// wrapped repository.
+type Repository[T domain.Entity] struct {
+	ports.Repository[T]
+
+	backend   Backend
+	txManager ports.TransactionManager
+	ttl       time.Duration
+	namespace string
+	keyring   *fieldcrypt.Keyring
+
+	group singleflight.Group
+	stats counters
+
+	// generation is incremented by every invalidation, so that loads
+	// overlapping a write do not cache what they read before it
+	generation atomic.Uint64
+}
+
+// NewRepository wraps repository with a cache stored in backend
+func NewRepository[T domain.Entity](repository ports.Repository[T], backend Backend, txManager ports.TransactionManager, opts ...RepositoryOption) *Repository[T] {
+	options := repositoryOptions{
+		ttl:       DefaultTTL,
+		namespace: strings.ToLower(reflect.TypeFor[T]().Name()),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Repository[T]{
+		Repository: repository,
+		backend:    backend,
+		txManager:  txManager,
+		ttl:        options.ttl,
+		namespace:  options.namespace,
+		keyring:    options.keyring,
+	}
+}
+
+// Stats returns a snapshot of the cache statistics
+func (r *Repository[T]) Stats() Stats {
+	return r.stats.snapshot()
+}
+
+// FindByID retrieves an entity from the cache, loading it from the wrapped
+// repository's primary database on a miss. Errors, including ErrNotFound,
+// are not cached.
+func (r *Repository[T]) FindByID(ctx context.Context, id uint) (T, error) {
+	if r.txManager.InTransaction(ctx) {
+		return r.Repository.FindByID(ctx, id)
+	}
+
+	key := r.key(id)
+	if entity, ok := r.get(ctx, key); ok {
+		r.stats.hits.Add(1)
+		return entity, nil
+	}
+	r.stats.misses.Add(1)
+
+	// The load is shared by every waiting caller, so it must not be cut
+	// short when the first one gives up. It reads from the primary: a
+	// lagging replica could return a state older than the last write, and
+	// caching it would outlive the invalidation made by that write.
+	loadCtx := database.WithPrimary(context.WithoutCancel(ctx))
+	result := r.group.DoChan(key, func() (any, error) {
+		generation := r.generation.Load()
+		entity, err := r.Repository.FindByID(loadCtx, id)
+		if err != nil {
+			return entity, err
+		}
+		if r.generation.Load() == generation {
+			r.set(loadCtx, key, entity)
+			if r.generation.Load() != generation {
+				// Invalidated while storing: the delete may have come first
+				_ = r.backend.Delete(loadCtx, key)
+			}
+		}
+		return entity, nil
+	})
+
+	select {
+	case res := <-result:
+		if res.Shared {
+			r.stats.coalesced.Add(1)
+		}
+		entity, _ := res.Val.(T)
+		return entity, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// FindByPublicID resolves publicID to the entity's ID through the cache and
+// reads the entity with FindByID. Public IDs never change, so the mapping is
+// never invalidated: once the entity is deleted FindByID reports it missing.
+func (r *Repository[T]) FindByPublicID(ctx context.Context, publicID string) (T, error) {
+	if r.txManager.InTransaction(ctx) {
+		return r.Repository.FindByPublicID(ctx, publicID)
+	}
+
+	key := r.namespace + ":public:" + publicID
+	data, ok, err := r.backend.Get(ctx, key)
+	if err != nil {
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to read from cache", zap.String("key", key), zap.Error(err))
+	}
+	if ok {
+		if id, err := strconv.ParseUint(string(data), 10, 64); err == nil {
+			return r.FindByID(ctx, uint(id))
+		}
+	}
+	r.stats.misses.Add(1)
+
+	entity, err := r.Repository.FindByPublicID(ctx, publicID)
+	if err != nil {
+		return entity, err
+	}
+	id := strconv.FormatUint(uint64(entity.GetID()), 10)
+	if err := r.backend.Set(ctx, key, []byte(id), r.ttl); err != nil {
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to write to cache", zap.String("key", key), zap.Error(err))
+	}
+	return entity, nil
+}
+
+// Update updates an entity and invalidates its cached copy
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	if err := r.Repository.Update(ctx, entity); err != nil {
+		return err
+	}
+	r.invalidate(ctx, (*entity).GetID())
+	return nil
+}
+
+// Delete removes an entity and invalidates its cached copy
+func (r *Repository[T]) Delete(ctx context.Context, id uint) error {
+	if err := r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// DeleteVersion removes an entity at version and invalidates its cached copy
+func (r *Repository[T]) DeleteVersion(ctx context.Context, id uint, version uint) error {
+	if err := r.Repository.DeleteVersion(ctx, id, version); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// UpdateMany updates entities and invalidates their cached copies
+func (r *Repository[T]) UpdateMany(ctx context.Context, entities []*T) error {
+	if err := r.Repository.UpdateMany(ctx, entities); err != nil {
+		return err
+	}
+	ids := make([]uint, len(entities))
+	for i, entity := range entities {
+		ids[i] = (*entity).GetID()
+	}
+	r.invalidate(ctx, ids...)
+	return nil
+}
+
+// DeleteMany removes entities and invalidates their cached copies
+func (r *Repository[T]) DeleteMany(ctx context.Context, ids []uint) error {
+	if err := r.Repository.DeleteMany(ctx, ids); err != nil {
+		return err
+	}
+	r.invalidate(ctx, ids...)
+	return nil
+}
+
+// ListDeleted forwards to the wrapped repository if it supports soft deletes
+func (r *Repository[T]) ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	repo, err := r.softDeleteRepository()
+	if err != nil {
+		return nil, 0, err
+	}
+	return repo.ListDeleted(ctx, page, pageSize)
+}
+
+// FindDeletedByPublicID forwards to the wrapped repository if it supports
+// soft deletes
+func (r *Repository[T]) FindDeletedByPublicID(ctx context.Context, publicID string) (T, error) {
+	repo, err := r.softDeleteRepository()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return repo.FindDeletedByPublicID(ctx, publicID)
+}
+
+// Restore brings back a soft-deleted entity and invalidates its cached copy
+func (r *Repository[T]) Restore(ctx context.Context, id uint) error {
+	repo, err := r.softDeleteRepository()
+	if err != nil {
+		return err
+	}
+	if err := repo.Restore(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// Purge forwards to the wrapped repository if it supports soft deletes;
+// purged entities were invalidated when they were deleted
+func (r *Repository[T]) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	repo, err := r.softDeleteRepository()
+	if err != nil {
+		return 0, err
+	}
+	return repo.Purge(ctx, deletedBefore)
+}
+
+// Search forwards to the wrapped repository if it supports search
+func (r *Repository[T]) Search(ctx context.Context, query string, page, pageSize int) ([]ports.SearchResult[T], int64, error) {
+	searcher, ok := r.Repository.(ports.Searcher[T])
+	if !ok {
+		return nil, 0, fmt.Errorf("repository does not support search: %w", apperrs.ErrBadRequest)
+	}
+	return searcher.Search(ctx, query, page, pageSize)
+}
+
+// softDeleteRepository returns the wrapped repository as a
+// SoftDeleteRepository if it supports it
+func (r *Repository[T]) softDeleteRepository() (ports.SoftDeleteRepository[T], error) {
+	repo, ok := r.Repository.(ports.SoftDeleteRepository[T])
+	if !ok {
+		return nil, fmt.Errorf("repository does not support soft deletes: %w", apperrs.ErrBadRequest)
+	}
+	return repo, nil
+}
+
+// invalidate drops the cached copies of the given entities. Inside a
+// transaction they are dropped again once it commits, since a concurrent
+// reader may have cached the committed state in between.
+func (r *Repository[T]) invalidate(ctx context.Context, ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = r.key(id)
+	}
+
+	drop := func(ctx context.Context) {
+		r.generation.Add(1)
+		for _, key := range keys {
+			r.group.Forget(key)
+		}
+		r.stats.invalidations.Add(uint64(len(keys)))
+		if err := r.backend.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+			r.stats.errors.Add(1)
+			logger.Error("Failed to invalidate cache entries", zap.Strings("keys", keys), zap.Error(err))
+		}
+	}
+
+	if r.txManager.InTransaction(ctx) {
+		drop(ctx)
+	}
+	r.txManager.AfterCommit(ctx, drop)
+}
+
+// get returns the cached entity stored under key
+func (r *Repository[T]) get(ctx context.Context, key string) (T, bool) {
+	var entity T
+	data, ok, err := r.backend.Get(ctx, key)
+	if err != nil {
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to read from cache", zap.String("key", key), zap.Error(err))
+		return entity, false
+	}
+	if !ok {
+		return entity, false
+	}
+	if r.keyring != nil {
+		if data, err = r.keyring.Decrypt(string(data)); err != nil {
+			r.stats.errors.Add(1)
+			logger.Warn("Failed to decrypt cached entity", zap.String("key", key), zap.Error(err))
+			_ = r.backend.Delete(ctx, key)
+			return entity, false
+		}
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entity); err != nil {
+		// Most likely written by an older version of the entity type
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to decode cached entity", zap.String("key", key), zap.Error(err))
+		_ = r.backend.Delete(ctx, key)
+		return entity, false
+	}
+	return entity, true
+}
+
+// set stores entity under key
+func (r *Repository[T]) set(ctx context.Context, key string, entity T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entity); err != nil {
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to encode entity for cache", zap.String("key", key), zap.Error(err))
+		return
+	}
+	data := buf.Bytes()
+	if r.keyring != nil {
+		encrypted, err := r.keyring.Encrypt(data)
+		if err != nil {
+			r.stats.errors.Add(1)
+			logger.Warn("Failed to encrypt entity for cache", zap.String("key", key), zap.Error(err))
+			return
+		}
+		data = []byte(encrypted)
+	}
+	if err := r.backend.Set(ctx, key, data, r.ttl); err != nil {
+		r.stats.errors.Add(1)
+		logger.Warn("Failed to write to cache", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// key returns the cache key of the entity with the given ID
+func (r *Repository[T]) key(id uint) string {
+	return r.namespace + ":" + strconv.FormatUint(uint64(id), 10)
+}
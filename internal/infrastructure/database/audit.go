@@ -0,0 +1,403 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/middleware"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// auditBeforeKey stores the rows an update or delete is about to change
+const auditBeforeKey = "audit:before"
+
+// auditIgnoredColumns are bookkeeping columns left out of audit diffs
+var auditIgnoredColumns = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// auditSecretPattern matches field names whose values are always redacted
+var auditSecretPattern = regexp.MustCompile(`(?i)password|secret|token`)
+
+// RegisterAuditCallbacks registers GORM callbacks that record every create,
+// update and delete of entities embedding domain.BaseEntity in the audit
+// log, in the same transaction as the change itself.
+//
+// The rows touched by an update or delete are read before and after the
+// statement so that diffs reflect stored values; this costs two extra
+// queries per statement. Fields tagged audit:"-" are left out of diffs and
+// fields tagged audit:"redact", encrypted, or named like a password, secret
+// or token, are recorded as changed without their values.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+
+	if err := callbacks.Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_create", auditAfterCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:begin_transaction").Before("gorm:update").
+		Register("audit:before_update", auditBefore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_update", auditAfterUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:begin_transaction").Before("gorm:delete").
+		Register("audit:before_delete", auditBefore); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_delete", auditAfterDelete)
+}
+
+// auditable reports whether the statement changes an audited entity
+func auditable(db *gorm.DB) bool {
+	stmt := db.Statement
+	if db.Error != nil || db.DryRun || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return false
+	}
+	if stmt.Schema.Table == (domain.AuditEntry{}).TableName() {
+		return false
+	}
+	_, ok := reflect.New(stmt.Schema.ModelType).Interface().(interface{ GetBase() *domain.BaseEntity })
+	return ok
+}
+
+// auditAfterCreate records the fields of every created row
+func auditAfterCreate(db *gorm.DB) {
+	if !auditable(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	var entries []domain.AuditEntry
+	eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+		entries = append(entries, newAuditEntry(db, domain.AuditActionCreate, row, reflect.Value{}, row))
+	})
+	writeAuditEntries(db, entries)
+}
+
+// auditBefore loads the rows an update or delete is about to change
+func auditBefore(db *gorm.DB) {
+	if !auditable(db) {
+		return
+	}
+	exprs := auditConditions(db.Statement)
+	if len(exprs) == 0 {
+		return
+	}
+
+	query := db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+	if db.Statement.Unscoped {
+		query = query.Unscoped()
+	}
+	rows, err := loadRows(query.Clauses(clause.Where{Exprs: exprs}), db.Statement.Schema)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to read rows for audit log: %w", err))
+		return
+	}
+	db.InstanceSet(auditBeforeKey, rows)
+}
+
+// auditAfterUpdate records the fields changed by an update
+func auditAfterUpdate(db *gorm.DB) {
+	before, after, ok := auditRowsChanged(db)
+	if !ok {
+		return
+	}
+
+	var entries []domain.AuditEntry
+	eachRow(before, func(old reflect.Value) {
+		updated, found := after[primaryKey(db.Statement, old)]
+		if !found {
+			return
+		}
+		action := domain.AuditActionUpdate
+		if isDeleted(old) && !isDeleted(updated) {
+			action = domain.AuditActionRestore
+		}
+		if entry := newAuditEntry(db, action, old, old, updated); len(entry.Changes) > 0 {
+			entries = append(entries, entry)
+		}
+	})
+	writeAuditEntries(db, entries)
+}
+
+// auditAfterDelete records soft deletes and permanent deletions
+func auditAfterDelete(db *gorm.DB) {
+	before, after, ok := auditRowsChanged(db)
+	if !ok {
+		return
+	}
+
+	var entries []domain.AuditEntry
+	eachRow(before, func(old reflect.Value) {
+		if deleted, found := after[primaryKey(db.Statement, old)]; found {
+			if !isDeleted(old) && isDeleted(deleted) {
+				entries = append(entries, newAuditEntry(db, domain.AuditActionDelete, old, old, deleted))
+			}
+			return
+		}
+		entries = append(entries, newAuditEntry(db, domain.AuditActionPurge, old, old, reflect.Value{}))
+	})
+	writeAuditEntries(db, entries)
+}
+
+// auditRowsChanged returns the rows captured before the statement and their
+// current state keyed by ID
+func auditRowsChanged(db *gorm.DB) (reflect.Value, map[uint]reflect.Value, bool) {
+	value, ok := db.InstanceGet(auditBeforeKey)
+	if !ok || !auditable(db) || db.Statement.RowsAffected == 0 {
+		return reflect.Value{}, nil, false
+	}
+	before := value.(reflect.Value)
+	if before.Len() == 0 {
+		return reflect.Value{}, nil, false
+	}
+
+	ids := make([]uint, 0, before.Len())
+	eachRow(before, func(row reflect.Value) {
+		ids = append(ids, primaryKey(db.Statement, row))
+	})
+	query := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Unscoped().
+		Where(clause.IN{Column: clause.PrimaryColumn, Values: anySlice(ids)})
+	rows, err := loadRows(query, db.Statement.Schema)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to read rows for audit log: %w", err))
+		return reflect.Value{}, nil, false
+	}
+	return before, indexRows(db.Statement, rows), true
+}
+
+// auditConditions returns the WHERE conditions of a statement, including the
+// primary key of the model it was called on
+func auditConditions(stmt *gorm.Statement) []clause.Expression {
+	var exprs []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			exprs = append(exprs, where.Exprs...)
+		}
+	}
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		pk := stmt.Schema.PrioritizedPrimaryField
+		if value, isZero := pk.ValueOf(stmt.Context, stmt.ReflectValue); !isZero {
+			exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: value})
+		}
+	}
+	return exprs
+}
+
+// loadRows reads the rows selected by query into a slice of the schema's model
+func loadRows(query *gorm.DB, s *schema.Schema) (reflect.Value, error) {
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	if err := query.Model(reflect.New(s.ModelType).Interface()).Find(rows.Interface()).Error; err != nil {
+		return reflect.Value{}, err
+	}
+	return rows.Elem(), nil
+}
+
+// indexRows keys the rows of a slice by primary key
+func indexRows(stmt *gorm.Statement, rows reflect.Value) map[uint]reflect.Value {
+	index := make(map[uint]reflect.Value, rows.Len())
+	eachRow(rows, func(row reflect.Value) {
+		index[primaryKey(stmt, row)] = row
+	})
+	return index
+}
+
+// eachRow calls fn for the struct, or every struct of the slice, in value
+func eachRow(value reflect.Value, fn func(row reflect.Value)) {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if row := reflect.Indirect(value.Index(i)); row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
+
+// primaryKey returns the primary key of a row
+func primaryKey(stmt *gorm.Statement, row reflect.Value) uint {
+	value, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, row)
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint(v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint(v.Int())
+	}
+	return 0
+}
+
+// isDeleted reports whether a row is soft deleted
+func isDeleted(row reflect.Value) bool {
+	based, ok := row.Addr().Interface().(interface{ GetBase() *domain.BaseEntity })
+	return ok && based.GetBase().IsDeleted()
+}
+
+// newAuditEntry builds the entry for one row; before or after is invalid
+// when the row did not exist before or after the change
+func newAuditEntry(db *gorm.DB, action domain.AuditAction, row, before, after reflect.Value) domain.AuditEntry {
+	based, _ := row.Addr().Interface().(interface{ GetBase() *domain.BaseEntity })
+	return domain.AuditEntry{
+		EntityType:     db.Statement.Schema.Table,
+		EntityID:       primaryKey(db.Statement, row),
+		EntityPublicID: based.GetBase().PublicID,
+		Action:         action,
+		Changes:        auditDiff(db.Statement.Context, db.Statement.Schema, before, after),
+	}
+}
+
+// auditDiff returns the changes between two versions of a row, redacting
+// secret fields
+func auditDiff(ctx context.Context, s *schema.Schema, before, after reflect.Value) map[string]domain.AuditChange {
+	changes := make(map[string]domain.AuditChange)
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.PrimaryKey || auditIgnoredColumns[field.DBName] || field.Tag.Get("audit") == "-" {
+			continue
+		}
+
+		var oldValue, newValue any
+		if before.IsValid() {
+			oldValue = auditValue(ctx, field, before)
+		}
+		if after.IsValid() {
+			newValue = auditValue(ctx, field, after)
+		}
+		if before.IsValid() && after.IsValid() && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if field.Tag.Get("audit") == "redact" || encryption.IsEncrypted(field) || auditSecretPattern.MatchString(field.Name) {
+			if before.IsValid() {
+				oldValue = domain.AuditRedacted
+			}
+			if after.IsValid() {
+				newValue = domain.AuditRedacted
+			}
+		}
+		changes[field.DBName] = domain.AuditChange{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// auditValue returns the value of field in row; serialized fields are read
+// from the struct, as ValueOf wraps them for writing
+func auditValue(ctx context.Context, field *schema.Field, row reflect.Value) any {
+	if field.Serializer != nil {
+		return field.ReflectValueOf(ctx, row).Interface()
+	}
+	value, _ := field.ValueOf(ctx, row)
+	return value
+}
+
+// writeAuditEntries stores entries with the actor and request of the
+// statement's context; a failure fails the audited statement
+func writeAuditEntries(db *gorm.DB, entries []domain.AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	ctx := db.Statement.Context
+	var actorID *uint
+	if id, ok := middleware.ExtractUserIDFromContext(ctx); ok {
+		actorID = &id
+	}
+	actorPublicID, _ := middleware.ExtractUserPublicIDFromContext(ctx)
+	requestID := middleware.GetRequestIDFromContext(ctx)
+	for i := range entries {
+		entries[i].ActorID = actorID
+		entries[i].ActorPublicID = actorPublicID
+		entries[i].RequestID = requestID
+	}
+
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true, SkipDefaultTransaction: true}).
+		Create(&entries).Error
+	if err != nil {
+		logger.Error("Failed to write audit log", zap.String("entity_type", entries[0].EntityType), zap.Error(err))
+		db.AddError(fmt.Errorf("failed to write audit log: %w", err))
+	}
+}
+
+// anySlice converts a slice to []any for use in IN clauses
+func anySlice[T any](values []T) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// GormAuditRepository implements ports.AuditRepository using GORM
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuditRepository creates a new GORM audit repository
+func NewGormAuditRepository(db *gorm.DB) *GormAuditRepository {
+	return &GormAuditRepository{
+		db: db,
+	}
+}
+
+// List retrieves audit entries matching filter with pagination, newest first
+func (r *GormAuditRepository) List(ctx context.Context, filter domain.AuditFilter, page, pageSize int) ([]domain.AuditEntry, int64, error) {
+	var entries []domain.AuditEntry
+	var count int64
+
+	offset := (page - 1) * pageSize
+	filtered := func() *gorm.DB {
+		query := r.db.WithContext(ctx).Model(&domain.AuditEntry{})
+		if filter.EntityType != "" {
+			query = query.Where("entity_type = ?", filter.EntityType)
+		}
+		if filter.EntityID != "" {
+			query = query.Where("entity_public_id = ?", filter.EntityID)
+		}
+		if filter.ActorID != "" {
+			query = query.Where("actor_public_id = ?", filter.ActorID)
+		}
+		if filter.Action != "" {
+			query = query.Where("action = ?", filter.Action)
+		}
+		if !filter.From.IsZero() {
+			query = query.Where("created_at >= ?", filter.From)
+		}
+		if !filter.To.IsZero() {
+			query = query.Where("created_at < ?", filter.To)
+		}
+		return query
+	}
+
+	if err := filtered().Count(&count).Error; err != nil {
+		logger.Error("Failed to count audit entries", zap.Error(err))
+		return nil, 0, err
+	}
+
+	result := filtered().
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&entries)
+	if result.Error != nil {
+		logger.Error("Failed to list audit entries", zap.Error(result.Error))
+		return nil, 0, result.Error
+	}
+
+	return entries, count, nil
+}
@@ -0,0 +1,109 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/pkg/middleware"
+)
+
+func TestAuditCallbacks(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	if err := database.RegisterAuditCallbacks(db); err != nil {
+		t.Fatalf("RegisterAuditCallbacks: %v", err)
+	}
+	repo := database.NewGormRepository[models.User](db)
+	audit := database.NewGormAuditRepository(db)
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash", FirstName: "Ann"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	user.FirstName = "Anna"
+	user.PasswordHash = "new-hash"
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatalf("Update without changes: %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Restore(ctx, user.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.Purge(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+
+	entries, total, err := audit.List(ctx, domain.AuditFilter{EntityType: "users", EntityID: user.PublicID}, 1, 10)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	want := []domain.AuditAction{
+		domain.AuditActionPurge,
+		domain.AuditActionDelete,
+		domain.AuditActionRestore,
+		domain.AuditActionDelete,
+		domain.AuditActionUpdate,
+		domain.AuditActionCreate,
+	}
+	if total != int64(len(want)) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), total, entries)
+	}
+	for i, action := range want {
+		if entries[i].Action != action {
+			t.Fatalf("entry %d: expected %s, got %s", i, action, entries[i].Action)
+		}
+	}
+
+	update := entries[4].Changes
+	// Encrypted fields are redacted like secrets
+	if len(update) != 2 || update["first_name"].Old != domain.AuditRedacted || update["first_name"].New != domain.AuditRedacted {
+		t.Fatalf("unexpected update diff: %+v", update)
+	}
+	if update["password_hash"].Old != domain.AuditRedacted || update["password_hash"].New != domain.AuditRedacted {
+		t.Fatalf("expected password hash to be redacted, got %+v", update["password_hash"])
+	}
+	if created := entries[5].Changes; created["email"].New != domain.AuditRedacted || created["role"].New != "user" || created["password_hash"].New != domain.AuditRedacted {
+		t.Fatalf("unexpected create diff: %+v", created)
+	}
+
+	deletes, _, err := audit.List(ctx, domain.AuditFilter{Action: domain.AuditActionDelete, From: time.Now().Add(-time.Minute)}, 1, 10)
+	if err != nil || len(deletes) != 2 {
+		t.Fatalf("expected 2 delete entries, got %d, %v", len(deletes), err)
+	}
+	for _, entry := range entries {
+		if entry.EntityPublicID != user.PublicID || entry.ActorPublicID != "" {
+			t.Fatalf("entry %+v does not identify the user by public ID", entry)
+		}
+	}
+
+	// Changes made by an authenticated user are listed by their public ID
+	admin := &models.User{Email: "admin@example.com", PasswordHash: "hash", Role: "admin"}
+	if err := repo.Create(ctx, admin); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	actorCtx := middleware.WithUser(ctx, admin.ID, admin.PublicID, admin.Role)
+	if err := repo.Create(actorCtx, &models.User{Email: "b@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	byActor, _, err := audit.List(ctx, domain.AuditFilter{ActorID: admin.PublicID}, 1, 10)
+	if err != nil || len(byActor) != 1 || byActor[0].ActorID == nil || *byActor[0].ActorID != admin.ID {
+		t.Fatalf("expected 1 entry by the admin, got %+v, %v", byActor, err)
+	}
+
+	if future, _, _ := audit.List(ctx, domain.AuditFilter{From: time.Now().Add(time.Hour)}, 1, 10); len(future) != 0 {
+		t.Fatalf("expected no entries in the future, got %d", len(future))
+	}
+}
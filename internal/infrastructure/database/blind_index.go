@@ -0,0 +1,52 @@
+package database
+
+import (
+	"reflect"
+
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+
+	"gorm.io/gorm"
+)
+
+// RegisterBlindIndexCallbacks registers GORM callbacks that compute the
+// blind index fields of created and updated structs, tagged blindindex, and
+// their search tokens field, tagged searchtokens, from the fields they
+// index. Connect registers them on every connection.
+func RegisterBlindIndexCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("encryption:blind_index", setBlindIndexes); err != nil {
+		return err
+	}
+	return callbacks.Update().Before("gorm:update").Register("encryption:blind_index", setBlindIndexes)
+}
+
+// setBlindIndexes sets the blind index fields of the statement's rows
+func setBlindIndexes(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	indexes := encryption.BlindIndexes(stmt.Schema)
+	tokens, sources := encryption.SearchTokensField(stmt.Schema)
+	if len(indexes) == 0 && tokens == nil {
+		return
+	}
+
+	keyring := encryption.Keyring()
+	eachRow(stmt.ReflectValue, func(row reflect.Value) {
+		for index, source := range indexes {
+			value := source.ReflectValueOf(stmt.Context, row)
+			if value.Kind() != reflect.String {
+				continue
+			}
+			if err := index.Set(stmt.Context, row, keyring.BlindIndex(value.String())); err != nil {
+				db.AddError(err)
+			}
+		}
+		if tokens != nil {
+			if err := tokens.Set(stmt.Context, row, encryption.SearchTokensValue(stmt.Context, sources, row)); err != nil {
+				db.AddError(err)
+			}
+		}
+	})
+}
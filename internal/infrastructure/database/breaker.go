@@ -0,0 +1,68 @@
+package database
+
+import (
+	"errors"
+
+	"go-server-boilerplate/internal/pkg/resilience"
+
+	"gorm.io/gorm"
+)
+
+// breakerAllowedKey marks statements let through by the circuit breaker
+const breakerAllowedKey = "circuit_breaker:allowed"
+
+// NewCircuitBreaker creates a circuit breaker for a database that only
+// counts connection failures, so that constraint violations and other
+// rejected statements never open it
+func NewCircuitBreaker(name string, opts ...resilience.BreakerOption) *resilience.CircuitBreaker {
+	return resilience.NewCircuitBreaker(name, append([]resilience.BreakerOption{
+		resilience.WithFailurePredicate(IsConnectionError),
+	}, opts...)...)
+}
+
+// RegisterCircuitBreaker registers GORM callbacks that guard every statement
+// with breaker: while it is open statements fail immediately with an error
+// wrapping apperrs.ErrServiceUnavailable instead of waiting on a database
+// that is down, and the outcome of every statement that runs is recorded.
+func RegisterCircuitBreaker(db *gorm.DB, breaker *resilience.CircuitBreaker) error {
+	callbacks := db.Callback()
+	before, after := breakerBefore(breaker), breakerAfter(breaker)
+
+	return errors.Join(
+		callbacks.Create().Before("*").Register("circuit_breaker:before_create", before),
+		callbacks.Create().After("*").Register("circuit_breaker:after_create", after),
+		callbacks.Query().Before("*").Register("circuit_breaker:before_query", before),
+		callbacks.Query().After("*").Register("circuit_breaker:after_query", after),
+		callbacks.Update().Before("*").Register("circuit_breaker:before_update", before),
+		callbacks.Update().After("*").Register("circuit_breaker:after_update", after),
+		callbacks.Delete().Before("*").Register("circuit_breaker:before_delete", before),
+		callbacks.Delete().After("*").Register("circuit_breaker:after_delete", after),
+		callbacks.Row().Before("*").Register("circuit_breaker:before_row", before),
+		callbacks.Row().After("*").Register("circuit_breaker:after_row", after),
+		callbacks.Raw().Before("*").Register("circuit_breaker:before_raw", before),
+		callbacks.Raw().After("*").Register("circuit_breaker:after_raw", after),
+	)
+}
+
+// breakerBefore rejects the statement while the circuit is open
+func breakerBefore(breaker *resilience.CircuitBreaker) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		if err := breaker.Allow(); err != nil {
+			_ = db.AddError(err)
+			return
+		}
+		db.InstanceSet(breakerAllowedKey, true)
+	}
+}
+
+// breakerAfter records the outcome of a statement let through
+func breakerAfter(breaker *resilience.CircuitBreaker) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if _, ok := db.InstanceGet(breakerAllowedKey); ok {
+			breaker.Done(db.Error)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GormDataExportRepository implements ports.DataExportRepository using GORM
+type GormDataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewGormDataExportRepository creates a new GORM data export repository
+func NewGormDataExportRepository(db *gorm.DB) *GormDataExportRepository {
+	return &GormDataExportRepository{
+		db: db,
+	}
+}
+
+// withContext returns the GORM DB instance, joining the transaction carried
+// by ctx if there is one
+func (r *GormDataExportRepository) withContext(ctx context.Context) *gorm.DB {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
+	return r.db.WithContext(ctx)
+}
+
+// Create stores a new export
+func (r *GormDataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	if err := r.withContext(ctx).Create(export).Error; err != nil {
+		logger.Error("Failed to create data export", zap.Uint("user_id", export.UserID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// FindLatest retrieves the most recent export of a user
+func (r *GormDataExportRepository) FindLatest(ctx context.Context, userID uint) (domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.withContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Take(&export).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return export, apperrs.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("Failed to find data export", zap.Uint("user_id", userID), zap.Error(err))
+		return export, err
+	}
+	return export, nil
+}
+
+// Complete stores the outcome of a pending export
+func (r *GormDataExportRepository) Complete(ctx context.Context, export *domain.DataExport) error {
+	// A struct update, so that the archive goes through its serializer
+	err := r.withContext(ctx).
+		Model(export).
+		Where("status = ?", domain.DataExportPending).
+		Select("status", "archive", "error", "completed_at", "expires_at").
+		Updates(export).Error
+	if err != nil {
+		logger.Error("Failed to complete data export", zap.Uint("id", export.ID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// DeleteByUser removes every export of a user
+func (r *GormDataExportRepository) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	result := r.withContext(ctx).Where("user_id = ?", userID).Delete(&domain.DataExport{})
+	if result.Error != nil {
+		logger.Error("Failed to delete data exports", zap.Uint("user_id", userID), zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteExpired removes exports that expired before the given time
+func (r *GormDataExportRepository) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	result := r.withContext(ctx).Where("expires_at < ?", expiredBefore).Delete(&domain.DataExport{})
+	if result.Error != nil {
+		logger.Error("Failed to delete expired data exports", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
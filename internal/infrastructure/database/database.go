@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"time"
 
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/migrations"
 	"go-server-boilerplate/internal/infrastructure/database/models"
 	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/resilience"
 
 	"go.uber.org/zap"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 )
 
@@ -21,29 +24,115 @@ type Config struct {
 	MaxIdleConns       int
 	ConnMaxLifetime    time.Duration
 	AutoMigrate        bool
+	Migrate            bool
 	LogQueries         bool
 	PreparedStatements bool
+	// SlowQueryThreshold is the duration above which statements are logged as
+	// slow; zero uses DefaultSlowQueryThreshold and a negative value disables
+	// slow query logging
+	SlowQueryThreshold time.Duration
+	// QueryStats, when set, aggregates every statement by fingerprint
+	QueryStats *QueryStats
+	// ConnectAttempts is the number of attempts made to reach the database
+	// before Connect gives up, waiting ConnectBackoff between them; values
+	// below 2 try once
+	ConnectAttempts int
+	ConnectBackoff  resilience.Backoff
+	// Breaker, when set, guards every statement with a circuit breaker
+	Breaker *resilience.CircuitBreaker
 }
 
-// Connect establishes a connection to the database
+// Connect establishes a connection to the database. The driver is selected
+// by the URL scheme (postgres://, sqlite://, file:). When cfg.Migrate is set
+// the embedded SQL migrations are applied and AutoMigrate is ignored.
 func Connect(cfg Config) (*gorm.DB, error) {
-	logger.Info("Connecting to database", zap.String("url", cfg.URL))
+	driver, dsn, err := resolveDriver(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Connecting to database", zap.String("driver", driver.Name), zap.String("url", cfg.URL))
 
 	gormConfig := &gorm.Config{
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: false,
 		},
 		PrepareStmt: cfg.PreparedStatements,
+		// Report unique violations as gorm.ErrDuplicatedKey on every dialect
+		TranslateError: true,
 	}
 
-	// Set log level based on configuration
+	// Log every statement when asked to, otherwise only errors and slow queries
+	logLevel := gormlogger.Warn
 	if cfg.LogQueries {
-		gormConfig.Logger = NewGormLogger()
+		logLevel = gormlogger.Info
+	}
+	gormConfig.Logger = NewGormLogger(logLevel, cfg.SlowQueryThreshold, cfg.QueryStats)
+
+	// Connect to the database, retrying while it is not reachable yet, as
+	// when it starts alongside the application
+	var db *gorm.DB
+	err = resilience.Retry(context.Background(), resilience.RetryPolicy{
+		Attempts:  cfg.ConnectAttempts,
+		Backoff:   cfg.ConnectBackoff,
+		Retryable: IsConnectionError,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			logger.Warn("Database not reachable, retrying",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		},
+	}, func(ctx context.Context) error {
+		db, err = open(ctx, driver, dsn, gormConfig, cfg)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the blind indexes of encrypted fields in step with their values
+	if err := RegisterBlindIndexCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register blind index callbacks: %w", err)
+	}
+
+	if cfg.Breaker != nil {
+		if err := RegisterCircuitBreaker(db, cfg.Breaker); err != nil {
+			return nil, fmt.Errorf("failed to register circuit breaker: %w", err)
+		}
+	}
+
+	logger.Info("Successfully connected to database")
+
+	// Apply versioned migrations, or fall back to auto migration if enabled
+	if cfg.Migrate {
+		logger.Info("Running database migrations")
+		migrator, err := migrations.New(db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
+	} else if cfg.AutoMigrate {
+		logger.Info("Running auto migrations")
+		if err := autoMigrate(db); err != nil {
+			return nil, fmt.Errorf("failed to run migrations: %w", err)
+		}
 	}
 
-	// Connect to the database
-	db, err := gorm.Open(postgres.Open(cfg.URL), gormConfig)
+	return db, nil
+}
+
+// open opens a connection pool and pings the database through it
+func open(ctx context.Context, driver Driver, dsn string, gormConfig *gorm.Config, cfg Config) (*gorm.DB, error) {
+	db, err := gorm.Open(driver.Open(dsn), gormConfig)
 	if err != nil {
+		if db != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				_ = sqlDB.Close()
+			}
+		}
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
@@ -54,28 +143,25 @@ func Connect(cfg Config) (*gorm.DB, error) {
 	}
 
 	// Set connection pool settings
-	sqlDB.SetMaxOpenConns(cfg.MaxConnections)
-	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if driver.SingleConnection != nil && driver.SingleConnection(dsn) {
+		// Every connection would see its own empty database; keep exactly one alive
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxConnections)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
 
 	// Ping the database to verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-
-	logger.Info("Successfully connected to database")
-
-	// Auto migrate if enabled
-	if cfg.AutoMigrate {
-		logger.Info("Running auto migrations")
-		if err := autoMigrate(db); err != nil {
-			return nil, fmt.Errorf("failed to run migrations: %w", err)
-		}
-	}
-
 	return db, nil
 }
 
@@ -84,6 +170,9 @@ func autoMigrate(db *gorm.DB) error {
 	// Add all models to migrate here
 	return db.AutoMigrate(
 		&models.User{},
+		&domain.AuditEntry{},
+		&domain.OutboxEvent{},
+		&domain.DataExport{},
 		// Add more models here as needed
 	)
 }
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Driver opens GORM dialectors for one database engine.
+//
+// GormRepository is written against the subset of SQL shared by the
+// registered drivers. Known dialect differences and how they are handled:
+//
+//   - RETURNING: never written explicitly. Generated IDs come back through
+//     GORM (RETURNING on Postgres, RETURNING/last_insert_rowid on SQLite) and
+//     conditional updates are checked through RowsAffected.
+//   - Case-insensitive matching: Postgres LIKE is case sensitive while SQLite
+//     LIKE is not, so lookups compare LOWER(column) = LOWER(?) on both.
+//   - Locking: Postgres migrations take an advisory lock; SQLite has no
+//     equivalent and relies on its single-writer model.
+type Driver struct {
+	// Name is the GORM dialector name, also used to pick migrations
+	Name string
+
+	// Open returns a dialector for the driver-specific DSN
+	Open func(dsn string) gorm.Dialector
+
+	// DSN converts a DATABASE_URL into the DSN understood by Open
+	DSN func(url string) string
+
+	// SingleConnection is reported for DSNs whose database lives in a single
+	// connection (e.g. in-memory SQLite) and must not be pooled
+	SingleConnection func(dsn string) bool
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+func init() {
+	postgresDriver := Driver{
+		Name: "postgres",
+		Open: postgres.Open,
+		DSN:  func(url string) string { return url },
+	}
+	sqliteDriver := Driver{
+		Name: "sqlite",
+		Open: sqlite.Open,
+		DSN: func(url string) string {
+			return strings.TrimPrefix(url, "sqlite://")
+		},
+		SingleConnection: func(dsn string) bool {
+			return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+		},
+	}
+
+	RegisterDriver("postgres", postgresDriver)
+	RegisterDriver("postgresql", postgresDriver)
+	RegisterDriver("sqlite", sqliteDriver)
+	RegisterDriver("file", sqliteDriver)
+}
+
+// RegisterDriver makes a driver available for DATABASE_URLs with the given scheme
+func RegisterDriver(scheme string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[scheme] = driver
+}
+
+// resolveDriver selects the driver for a DATABASE_URL by its scheme and
+// returns it with the DSN to open
+func resolveDriver(url string) (Driver, string, error) {
+	scheme, _, found := strings.Cut(url, ":")
+	if !found || scheme == "" {
+		return Driver{}, "", fmt.Errorf("database URL %q has no scheme", url)
+	}
+
+	driversMu.RLock()
+	driver, ok := drivers[strings.ToLower(scheme)]
+	driversMu.RUnlock()
+	if !ok {
+		return Driver{}, "", fmt.Errorf("unsupported database URL scheme %q (supported: %s)", scheme, strings.Join(schemes(), ", "))
+	}
+
+	return driver, driver.DSN(url), nil
+}
+
+// schemes returns the registered URL schemes in sorted order
+func schemes() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for scheme := range drivers {
+		names = append(names, scheme)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,200 @@
+// Package encryption registers the "encrypted" GORM serializer, which
+// stores string and []byte fields encrypted with a fieldcrypt keyring, and resolves the
+// blind index and search token fields that let encrypted fields be matched.
+//
+// Models using the serializer import this package so that it is registered
+// wherever their schema is parsed.
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the name of the serializer, used as
+// gorm:"serializer:encrypted"
+const SerializerName = "encrypted"
+
+// blindIndexTag names the field a blind index field is computed from, as in
+// `blindindex:"email"`
+const blindIndexTag = "blindindex"
+
+// searchTokensTag lists the fields a search tokens field is computed from,
+// as in `searchtokens:"email,first_name,last_name"`
+const searchTokensTag = "searchtokens"
+
+// bytesType is the type of []byte fields
+var bytesType = reflect.TypeFor[[]byte]()
+
+// keyring encrypts the fields of every connection; GORM serializers are
+// registered process-wide, so the keyring is too
+var keyring atomic.Pointer[fieldcrypt.Keyring]
+
+func init() {
+	keyring.Store(fieldcrypt.DevelopmentKeyring())
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// UseKeyring makes k encrypt and decrypt the fields of every connection and
+// compute their blind indexes. Until it is called, a development keyring
+// with publicly known keys is used.
+func UseKeyring(k *fieldcrypt.Keyring) {
+	keyring.Store(k)
+}
+
+// Keyring returns the keyring in use
+func Keyring() *fieldcrypt.Keyring {
+	return keyring.Load()
+}
+
+// BlindIndex returns the blind index of value under the keyring in use
+func BlindIndex(value string) string {
+	return keyring.Load().BlindIndex(value)
+}
+
+// Serializer stores string and []byte fields encrypted with the keyring in
+// use; a nil []byte is stored as NULL. Values
+// read without the encryption prefix are returned as they are, so rows
+// written before encryption was enabled stay readable until they are
+// re-encrypted.
+//
+// GORM applies serializers when writing structs, not maps: a field updated
+// with Updates(map[string]any{...}) is stored in plaintext until it is
+// re-encrypted.
+type Serializer struct{}
+
+// Scan decrypts a database value into the field
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+		if field.FieldType == bytesType {
+			return field.Set(ctx, dst, []byte(nil))
+		}
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	if fieldcrypt.IsEncrypted(value) {
+		plaintext, err := keyring.Load().Decrypt(value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt field %s: %w", field.Name, err)
+		}
+		value = string(plaintext)
+	}
+	if field.FieldType == bytesType {
+		return field.Set(ctx, dst, []byte(value))
+	}
+	return field.Set(ctx, dst, value)
+}
+
+// Value encrypts the field with the primary key
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	switch value := fieldValue.(type) {
+	case string:
+		return keyring.Load().Encrypt([]byte(value))
+	case []byte:
+		if value == nil {
+			return nil, nil
+		}
+		return keyring.Load().Encrypt(value)
+	default:
+		return nil, fmt.Errorf("encrypted field %s must be a string or []byte, got %T", field.Name, fieldValue)
+	}
+}
+
+// Plaintext returns the value of an encrypted field as bytes
+func Plaintext(value reflect.Value) []byte {
+	if value.Type() == bytesType {
+		return value.Bytes()
+	}
+	return []byte(value.String())
+}
+
+// IsEncrypted reports whether field is stored with the serializer
+func IsEncrypted(field *schema.Field) bool {
+	return field.TagSettings["SERIALIZER"] == SerializerName
+}
+
+// BlindIndexOf returns the field holding the blind index of field, the one
+// tagged blindindex with its name or column, or nil if it has none
+func BlindIndexOf(s *schema.Schema, field *schema.Field) *schema.Field {
+	for _, candidate := range s.Fields {
+		if source := candidate.Tag.Get(blindIndexTag); source != "" && (source == field.Name || source == field.DBName) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// BlindIndexes maps the blind index fields of s to the fields they index
+func BlindIndexes(s *schema.Schema) map[*schema.Field]*schema.Field {
+	indexes := make(map[*schema.Field]*schema.Field)
+	for _, field := range s.Fields {
+		if source := field.Tag.Get(blindIndexTag); source != "" {
+			if sourceField := s.LookUpField(source); sourceField != nil {
+				indexes[field] = sourceField
+			}
+		}
+	}
+	return indexes
+}
+
+// SearchTokens returns the search tokens of values under the keyring in use
+func SearchTokens(values ...string) string {
+	return keyring.Load().SearchTokens(values...)
+}
+
+// SearchTokensField returns the field of s tagged searchtokens and the
+// fields its tokens are computed from, or nil if s has none
+func SearchTokensField(s *schema.Schema) (*schema.Field, []*schema.Field) {
+	for _, field := range s.Fields {
+		names := field.Tag.Get(searchTokensTag)
+		if names == "" {
+			continue
+		}
+		var sources []*schema.Field
+		for _, name := range strings.Split(names, ",") {
+			if source := s.LookUpField(strings.TrimSpace(name)); source != nil {
+				sources = append(sources, source)
+			}
+		}
+		return field, sources
+	}
+	return nil, nil
+}
+
+// SearchTokensOf returns the search tokens field computed from field, or nil
+// if field has none
+func SearchTokensOf(s *schema.Schema, field *schema.Field) *schema.Field {
+	tokens, sources := SearchTokensField(s)
+	for _, source := range sources {
+		if source == field {
+			return tokens
+		}
+	}
+	return nil
+}
+
+// SearchTokensValue computes the search tokens of row, a struct of s, from
+// the current values of sources
+func SearchTokensValue(ctx context.Context, sources []*schema.Field, row reflect.Value) string {
+	values := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if value := source.ReflectValueOf(ctx, row); value.Kind() == reflect.String {
+			values = append(values, value.String())
+		}
+	}
+	return SearchTokens(values...)
+}
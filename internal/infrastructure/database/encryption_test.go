@@ -0,0 +1,129 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+)
+
+func useKeyring(t *testing.T, primary string, ids ...string) {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, id := range ids {
+		key := sha256.Sum256([]byte(id))
+		keys[id] = key[:]
+	}
+	keyring, err := fieldcrypt.NewKeyring(primary, keys, bytes.Repeat([]byte{9}, fieldcrypt.KeySize))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	encryption.UseKeyring(keyring)
+	t.Cleanup(func() { encryption.UseKeyring(fieldcrypt.DevelopmentKeyring()) })
+}
+
+// storedUser is a users row as stored
+type storedUser struct {
+	Email      string
+	EmailIndex *string
+	FirstName  string
+}
+
+func TestEncryptedFieldsAndKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	repo := database.NewGormRepository[models.User](db)
+	stored := func(id uint) storedUser {
+		t.Helper()
+		var row storedUser
+		if err := db.Table("users").Where("id = ?", id).Take(&row).Error; err != nil {
+			t.Fatalf("reading stored row: %v", err)
+		}
+		return row
+	}
+
+	useKeyring(t, "k1", "k1")
+	user := &models.User{Email: "ada@example.com", PasswordHash: "hash", FirstName: "Ada"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	row := stored(user.ID)
+	if !strings.HasPrefix(row.Email, "enc:k1:") || !strings.HasPrefix(row.FirstName, "enc:k1:") {
+		t.Fatalf("stored row is not encrypted: %+v", row)
+	}
+	if row.EmailIndex == nil || *row.EmailIndex != encryption.BlindIndex("ada@example.com") {
+		t.Fatalf("stored blind index = %v", row.EmailIndex)
+	}
+
+	found, err := repo.FindOneBy(ctx, "email", "ADA@example.com")
+	if err != nil || found.ID != user.ID || found.FirstName != "Ada" {
+		t.Fatalf("FindOneBy = %+v, %v", found, err)
+	}
+	duplicate := &models.User{Email: "Ada@Example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, duplicate); !errors.Is(err, apperrs.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists for a duplicate email, got %v", err)
+	}
+
+	// A row written before encryption was enabled
+	now := time.Now()
+	if err := db.Exec("INSERT INTO users (public_id, email, password_hash, first_name, version, created_at, updated_at) VALUES ('legacy', 'grace@example.com', 'hash', 'Grace', 1, ?, ?)", now, now).Error; err != nil {
+		t.Fatalf("inserting plaintext row: %v", err)
+	}
+	legacy, err := repo.FindOneBy(ctx, "email", "grace@example.com")
+	if err != nil || legacy.FirstName != "Grace" {
+		t.Fatalf("FindOneBy plaintext row = %+v, %v", legacy, err)
+	}
+
+	// Its plaintext email stays unique until it is backfilled, and its blind
+	// index from then on
+	if err := db.Exec("INSERT INTO users (public_id, email, password_hash, version, created_at, updated_at) VALUES ('legacy-2', 'grace@example.com', 'hash', 1, ?, ?)", now, now).Error; err == nil {
+		t.Fatal("expected a duplicate plaintext email to be rejected")
+	}
+	if backfilled, err := database.NewReencryptor[models.User](db, 1).Backfill(ctx); err != nil || backfilled != 1 {
+		t.Fatalf("Backfill = %d, %v; want the plaintext row only", backfilled, err)
+	}
+	if row := stored(legacy.ID); !strings.HasPrefix(row.Email, "enc:k1:") || row.EmailIndex == nil {
+		t.Fatalf("plaintext row was not backfilled: %+v", row)
+	}
+	if err := repo.Create(ctx, &models.User{Email: "Grace@example.com", PasswordHash: "hash"}); !errors.Is(err, apperrs.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists for the email of a backfilled row, got %v", err)
+	}
+
+	// Rotate to k2: both rows are rewritten, once
+	useKeyring(t, "k2", "k1", "k2")
+	reencryptor := database.NewReencryptor[models.User](db, 1)
+	if rewritten, err := reencryptor.Run(ctx); err != nil || rewritten != 2 {
+		t.Fatalf("Run = %d, %v; want 2 rows rewritten", rewritten, err)
+	}
+	if rewritten, err := reencryptor.Run(ctx); err != nil || rewritten != 0 {
+		t.Fatalf("second Run = %d, %v; want nothing left to rewrite", rewritten, err)
+	}
+	for _, id := range []uint{user.ID, legacy.ID} {
+		if row := stored(id); !strings.HasPrefix(row.Email, "enc:k2:") || !strings.HasPrefix(row.FirstName, "enc:k2:") || row.EmailIndex == nil {
+			t.Fatalf("row %d was not re-encrypted: %+v", id, row)
+		}
+	}
+	reread, err := repo.FindByID(ctx, legacy.ID)
+	if err != nil || reread.Email != "grace@example.com" || reread.Version != legacy.Version {
+		t.Fatalf("re-encrypted row = %+v, %v", reread, err)
+	}
+	searchable := database.NewGormRepository[models.User](db, database.WithSearchFields("first_name"))
+	if results, total, err := searchable.Search(ctx, "grac", 1, 10); err != nil || total != 1 || results[0].Entity.ID != legacy.ID {
+		t.Fatalf("search tokens of the re-encrypted row: got %d results, %v", total, err)
+	}
+
+	// Once every row is rewritten, k1 can be removed from the keyring
+	useKeyring(t, "k2", "k2")
+	if _, _, err := repo.List(ctx, 1, 10); err != nil {
+		t.Fatalf("List after removing the rotated-out key: %v", err)
+	}
+}
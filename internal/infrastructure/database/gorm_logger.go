@@ -7,23 +7,34 @@ import (
 	"time"
 
 	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/middleware"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
-// GormLogger is an implementation of gorm.logger.Interface
+// DefaultSlowQueryThreshold is the slow query threshold used when none is configured
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger is an implementation of gorm.logger.Interface. When Stats is
+// set every statement is recorded there, whatever the log level.
 type GormLogger struct {
 	SlowThreshold time.Duration
 	LogLevel      gormlogger.LogLevel
+	Stats         *QueryStats
 }
 
-// NewGormLogger creates a new GormLogger
-func NewGormLogger() *GormLogger {
+// NewGormLogger creates a new GormLogger; a zero slowThreshold uses
+// DefaultSlowQueryThreshold and stats may be nil
+func NewGormLogger(level gormlogger.LogLevel, slowThreshold time.Duration, stats *QueryStats) *GormLogger {
+	if slowThreshold == 0 {
+		slowThreshold = DefaultSlowQueryThreshold
+	}
 	return &GormLogger{
-		SlowThreshold: 200 * time.Millisecond,
-		LogLevel:      gormlogger.Info,
+		SlowThreshold: slowThreshold,
+		LogLevel:      level,
+		Stats:         stats,
 	}
 }
 
@@ -55,29 +66,43 @@ func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{})
 	}
 }
 
-// Trace logs SQL and time
+// Trace records the statement in Stats and logs SQL and time
 func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	slow := l.SlowThreshold > 0 && elapsed > l.SlowThreshold
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	if l.Stats != nil {
+		sql, rows := fc()
+		l.Stats.Record(sql, elapsed, rows, slow, err)
+	}
 	if l.LogLevel <= gormlogger.Silent {
 		return
 	}
 
-	elapsed := time.Since(begin)
 	switch {
-	case err != nil && l.LogLevel >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil && l.LogLevel >= gormlogger.Error:
 		sql, rows := fc()
 		logger.Error("SQL Error",
 			zap.Error(err),
 			zap.Duration("elapsed", elapsed),
 			zap.String("sql", sql),
 			zap.Int64("rows", rows),
+			zap.String("request_id", middleware.GetRequestIDFromContext(ctx)),
+			zap.String("route", middleware.GetRouteFromContext(ctx)),
 		)
-	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= gormlogger.Warn:
+	case slow && l.LogLevel >= gormlogger.Warn:
 		sql, rows := fc()
 		logger.Warn("SQL Slow Query",
 			zap.Duration("elapsed", elapsed),
 			zap.String("sql", sql),
+			zap.String("fingerprint", Fingerprint(sql)),
 			zap.Int64("rows", rows),
 			zap.Duration("threshold", l.SlowThreshold),
+			zap.String("request_id", middleware.GetRequestIDFromContext(ctx)),
+			zap.String("route", middleware.GetRouteFromContext(ctx)),
 		)
 	case l.LogLevel >= gormlogger.Info:
 		sql, rows := fc()
@@ -3,35 +3,171 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
 	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/resilience"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
+// defaultBatchSize is the number of rows per INSERT statement used by CreateMany
+const defaultBatchSize = 500
+
 // GormRepository is a generic implementation of the Repository interface using GORM
 type GormRepository[T domain.Entity] struct {
-	db *gorm.DB
+	db        *gorm.DB
+	replicas  *ReplicaSet
+	batchSize int
+	// searchFields are the columns matched by Search
+	searchFields []string
+	// retry, when set, retries idempotent operations run outside a transaction
+	retry *resilience.RetryPolicy
+}
+
+// RepositoryOption configures a GormRepository
+type RepositoryOption func(*repositoryOptions)
+
+type repositoryOptions struct {
+	replicas     *ReplicaSet
+	batchSize    int
+	searchFields []string
+	retry        *resilience.RetryPolicy
+}
+
+// WithReplicas routes the repository's reads to the given replica set
+func WithReplicas(replicas *ReplicaSet) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.replicas = replicas
+	}
+}
+
+// WithBatchSize sets the number of rows inserted per statement by CreateMany
+func WithBatchSize(size int) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithSearchFields enables Search over the given text columns. On Postgres
+// the full-text expression index created by the migrations must list the
+// same columns in the same order.
+func WithSearchFields(fields ...string) RepositoryOption {
+	return func(o *repositoryOptions) {
+		o.searchFields = fields
+	}
+}
+
+// WithRetry retries reads and other idempotent operations that fail with a
+// transient error, following policy; a nil policy.Retryable retries the
+// errors accepted by IsRetryable. Operations joining a transaction are never
+// retried on their own, see WithTransactionRetry.
+func WithRetry(policy resilience.RetryPolicy) RepositoryOption {
+	return func(o *repositoryOptions) {
+		if policy.Retryable == nil {
+			policy.Retryable = IsRetryable
+		}
+		o.retry = &policy
+	}
 }
 
 // NewGormRepository creates a new GORM repository
-func NewGormRepository[T domain.Entity](db *gorm.DB) *GormRepository[T] {
+func NewGormRepository[T domain.Entity](db *gorm.DB, opts ...RepositoryOption) *GormRepository[T] {
+	options := repositoryOptions{batchSize: defaultBatchSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.batchSize <= 0 {
+		options.batchSize = defaultBatchSize
+	}
+
 	return &GormRepository[T]{
-		db: db,
+		db:           db,
+		replicas:     options.replicas,
+		batchSize:    options.batchSize,
+		searchFields: options.searchFields,
+		retry:        options.retry,
 	}
 }
 
-// withContext adds context to the GORM DB instance
+// withContext returns the primary GORM DB instance for writes, joining the
+// transaction carried by ctx if there is one
 func (r *GormRepository[T]) withContext(ctx context.Context) *gorm.DB {
+	markWrite(ctx)
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
 	return r.db.WithContext(ctx)
 }
 
-// Create creates a new entity
-func (r *GormRepository[T]) Create(ctx context.Context, entity T) error {
+// reader returns the GORM DB instance for reads: the open transaction, the
+// primary when requested or after a write (read-your-writes), otherwise a
+// healthy replica, falling back to the primary when none is available
+func (r *GormRepository[T]) reader(ctx context.Context) *gorm.DB {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
+	if r.replicas != nil && !usePrimary(ctx) {
+		if replica := r.replicas.Reader(); replica != nil {
+			return replica.WithContext(ctx)
+		}
+	}
+	return r.db.WithContext(ctx)
+}
+
+// idempotent runs fn, an operation that may safely run twice, retrying it
+// on transient errors when the repository is configured to and ctx carries
+// no transaction
+func (r *GormRepository[T]) idempotent(ctx context.Context, fn func() error) error {
+	if r.retry == nil || InTransaction(ctx) {
+		return fn()
+	}
+	return resilience.Retry(ctx, *r.retry, func(ctx context.Context) error {
+		return fn()
+	})
+}
+
+// restoreOnRollback restores the base fields of entity to their current
+// values if the transaction carried by ctx rolls back, so that a retried
+// transaction starts from the same entities
+func restoreOnRollback[T domain.Entity](ctx context.Context, entity *T) {
+	if base := domain.BaseOf(entity); base != nil {
+		saved := *base
+		onRollback(ctx, func() { *base = saved })
+	}
+}
+
+// prepareCreate sets the version and public ID of a new entity
+func prepareCreate[T domain.Entity](entity *T) {
+	base := domain.BaseOf(entity)
+	if base == nil {
+		return
+	}
+	if base.Version == 0 {
+		base.Version = 1
+	}
+	if base.PublicID == "" {
+		base.PublicID = domain.NewPublicID()
+	}
+}
+
+// Create creates a new entity, assigning it a public ID unless it has one
+func (r *GormRepository[T]) Create(ctx context.Context, entity *T) error {
+	restoreOnRollback(ctx, entity)
+	prepareCreate(entity)
+
 	result := r.withContext(ctx).Create(entity)
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("entity: %w", apperrs.ErrAlreadyExists)
+		}
 		logger.Error("Failed to create entity", zap.Error(result.Error))
 		return result.Error
 	}
@@ -41,29 +177,166 @@ func (r *GormRepository[T]) Create(ctx context.Context, entity T) error {
 // FindByID retrieves an entity by its ID
 func (r *GormRepository[T]) FindByID(ctx context.Context, id uint) (T, error) {
 	var entity T
-	result := r.withContext(ctx).First(&entity, id)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).First(&entity, id).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			var zero T
-			return zero, errors.New("entity not found")
+			return zero, fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
 		}
-		logger.Error("Failed to find entity by ID", zap.Uint("id", id), zap.Error(result.Error))
-		return entity, result.Error
+		logger.Error("Failed to find entity by ID", zap.Uint("id", id), zap.Error(err))
+		return entity, err
 	}
 	return entity, nil
 }
 
-// Update updates an existing entity
-func (r *GormRepository[T]) Update(ctx context.Context, entity T) error {
-	result := r.withContext(ctx).Save(entity)
+// FindByPublicID retrieves an entity by its public ID
+func (r *GormRepository[T]) FindByPublicID(ctx context.Context, publicID string) (T, error) {
+	var entity T
+	err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).Where("public_id = ?", publicID).First(&entity).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var zero T
+			return zero, fmt.Errorf("entity %s: %w", publicID, apperrs.ErrNotFound)
+		}
+		logger.Error("Failed to find entity by public ID", zap.String("public_id", publicID), zap.Error(err))
+		return entity, err
+	}
+	return entity, nil
+}
+
+// FindOneBy retrieves the first entity whose field equals value. Field may be
+// a struct field or column name and is checked against the model schema.
+// String values are matched with LOWER() on both sides, which is
+// case-insensitive on every supported dialect; encrypted fields are matched
+// through their blind index.
+func (r *GormRepository[T]) FindOneBy(ctx context.Context, field string, value any) (T, error) {
+	var entity T
+	condition, args, err := r.equals(field, value)
+	if err != nil {
+		return entity, err
+	}
+
+	err = r.idempotent(ctx, func() error {
+		return r.reader(ctx).Where(condition, args...).First(&entity).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var zero T
+			return zero, fmt.Errorf("entity with %s %v: %w", field, value, apperrs.ErrNotFound)
+		}
+		logger.Error("Failed to find entity", zap.String("field", field), zap.Error(err))
+		return entity, err
+	}
+	return entity, nil
+}
+
+// equals returns the condition matching rows whose field equals value,
+// comparing strings with LOWER() on both sides. Encrypted fields are matched
+// on their blind index, or on their plaintext for rows written before
+// encryption that have no index yet.
+func (r *GormRepository[T]) equals(field string, value any) (string, []any, error) {
+	stmt, schemaField, err := r.lookUp(field)
+	if err != nil {
+		return "", nil, err
+	}
+	column := stmt.Quote(schemaField.DBName)
+	s, isString := value.(string)
+	if !encryption.IsEncrypted(schemaField) {
+		if isString {
+			return fmt.Sprintf("LOWER(%s) = LOWER(?)", column), []any{s}, nil
+		}
+		return fmt.Sprintf("%s = ?", column), []any{value}, nil
+	}
+
+	index := encryption.BlindIndexOf(stmt.Schema, schemaField)
+	if index == nil || !isString {
+		return "", nil, fmt.Errorf("encrypted field %q cannot be matched: %w", field, apperrs.ErrInvalidInput)
+	}
+	indexColumn := stmt.Quote(index.DBName)
+	condition := fmt.Sprintf("(%[1]s = ? OR (%[1]s IS NULL AND LOWER(%[2]s) = LOWER(?)))", indexColumn, column)
+	return condition, []any{encryption.BlindIndex(s), s}, nil
+}
+
+// column resolves a field or column name of T to its quoted column name,
+// rejecting anything that is not part of the model
+func (r *GormRepository[T]) column(field string) (string, error) {
+	stmt, schemaField, err := r.lookUp(field)
+	if err != nil {
+		return "", err
+	}
+	return stmt.Quote(schemaField.DBName), nil
+}
+
+// lookUp resolves a field or column name of T to its schema field,
+// rejecting anything that is not part of the model
+func (r *GormRepository[T]) lookUp(field string) (*gorm.Statement, *schema.Field, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, nil, err
+	}
+	schemaField := stmt.Schema.LookUpField(field)
+	if schemaField == nil || schemaField.DBName == "" {
+		return nil, nil, fmt.Errorf("unknown field %q: %w", field, apperrs.ErrInvalidInput)
+	}
+	return stmt, schemaField, nil
+}
+
+// Update updates an existing entity. Entities embedding domain.BaseEntity are
+// updated with UPDATE ... WHERE version = ?, so a concurrent modification
+// yields apperrs.ErrConflict instead of being silently overwritten.
+func (r *GormRepository[T]) Update(ctx context.Context, entity *T) error {
+	base := domain.BaseOf(entity)
+	if base == nil {
+		result := r.withContext(ctx).Save(entity)
+		if result.Error != nil {
+			logger.Error("Failed to update entity", zap.Error(result.Error))
+			return result.Error
+		}
+		return nil
+	}
+
+	expected := base.Version
+	base.Version = expected + 1
+
+	result := r.withContext(ctx).
+		Model(entity).
+		Where("version = ?", expected).
+		Select("*").
+		Omit("id", "created_at", "deleted_at").
+		Updates(entity)
 	if result.Error != nil {
-		logger.Error("Failed to update entity", zap.Error(result.Error))
+		base.Version = expected
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("entity %d: %w", base.ID, apperrs.ErrAlreadyExists)
+		}
+		logger.Error("Failed to update entity", zap.Uint("id", base.ID), zap.Error(result.Error))
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		base.Version = expected
+		return r.updateMissError(ctx, base.ID, expected)
+	}
+	onRollback(ctx, func() { base.Version = expected })
 	return nil
 }
 
-// Delete removes an entity
+// updateMissError explains why a versioned update matched no rows
+func (r *GormRepository[T]) updateMissError(ctx context.Context, id uint, expected uint) error {
+	var count int64
+	if err := r.withContext(ctx).Model(new(T)).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
+	}
+	return fmt.Errorf("entity %d was modified concurrently (expected version %d): %w", id, expected, apperrs.ErrConflict)
+}
+
+// Delete soft deletes an entity; it stays recoverable until purged
 func (r *GormRepository[T]) Delete(ctx context.Context, id uint) error {
 	var entity T
 	result := r.withContext(ctx).Delete(&entity, id)
@@ -71,44 +344,305 @@ func (r *GormRepository[T]) Delete(ctx context.Context, id uint) error {
 		logger.Error("Failed to delete entity", zap.Uint("id", id), zap.Error(result.Error))
 		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
+	}
 	return nil
 }
 
-// List retrieves entities with pagination
+// DeleteVersion soft deletes an entity only if it is still at version, in a
+// single conditional UPDATE so that a concurrent change cannot slip in
+// between the check and the delete
+func (r *GormRepository[T]) DeleteVersion(ctx context.Context, id uint, version uint) error {
+	var entity T
+	if domain.BaseOf(&entity) == nil {
+		return r.Delete(ctx, id)
+	}
+
+	result := r.withContext(ctx).Where("version = ?", version).Delete(&entity, id)
+	if result.Error != nil {
+		logger.Error("Failed to delete entity", zap.Uint("id", id), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.updateMissError(ctx, id, version)
+	}
+	return nil
+}
+
+// List retrieves entities with pagination, ordered by ID
 func (r *GormRepository[T]) List(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	return r.ListBy(ctx, nil, page, pageSize)
+}
+
+// ListBy retrieves the entities whose fields equal the values in filters,
+// with pagination in ID order. Fields are checked against the model schema
+// and values compared as in FindOneBy.
+func (r *GormRepository[T]) ListBy(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, int64, error) {
 	var entities []T
 	var count int64
 
+	// Resolve the filters in a stable order so equal filters produce the
+	// same statement
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	conditions := make([]string, len(fields))
+	args := make([][]any, len(fields))
+	for i, field := range fields {
+		condition, fieldArgs, err := r.equals(field, filters[field])
+		if err != nil {
+			return nil, 0, err
+		}
+		conditions[i], args[i] = condition, fieldArgs
+	}
+	filtered := func(query *gorm.DB) *gorm.DB {
+		for i := range fields {
+			query = query.Where(conditions[i], args[i]...)
+		}
+		return query
+	}
+
 	// Calculate offset
 	offset := (page - 1) * pageSize
 
 	// Get total count
-	if err := r.withContext(ctx).Model(new(T)).Count(&count).Error; err != nil {
+	if err := r.idempotent(ctx, func() error {
+		return filtered(r.reader(ctx).Model(new(T))).Count(&count).Error
+	}); err != nil {
 		logger.Error("Failed to count entities", zap.Error(err))
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	result := r.withContext(ctx).
-		Offset(offset).
-		Limit(pageSize).
-		Find(&entities)
+	if err := r.idempotent(ctx, func() error {
+		return filtered(r.reader(ctx)).
+			Order("id").
+			Offset(offset).
+			Limit(pageSize).
+			Find(&entities).Error
+	}); err != nil {
+		logger.Error("Failed to list entities", zap.Error(err))
+		return nil, 0, err
+	}
 
-	if result.Error != nil {
-		logger.Error("Failed to list entities", zap.Error(result.Error))
-		return nil, 0, result.Error
+	return entities, count, nil
+}
+
+// ForEachBatch calls fn with batches of entities read by keyset pagination
+// on the primary key, so every query stays cheap however far the iteration
+// goes and rows inserted meanwhile are not read twice
+func (r *GormRepository[T]) ForEachBatch(ctx context.Context, batchSize int, fn func(batch []T) error) error {
+	var after uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var batch []T
+		if err := r.idempotent(ctx, func() error {
+			return r.reader(ctx).
+				Where("id > ?", after).
+				Order("id").
+				Limit(batchSize).
+				Find(&batch).Error
+		}); err != nil {
+			logger.Error("Failed to read entity batch", zap.Uint("after_id", after), zap.Error(err))
+			return err
+		}
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		after = batch[len(batch)-1].GetID()
+	}
+}
+
+// CreateMany creates entities with multi-row INSERT statements of up to the
+// configured batch size, all within one transaction
+func (r *GormRepository[T]) CreateMany(ctx context.Context, entities []*T) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	return NewTransactionManager(r.db).WithTransaction(ctx, func(ctx context.Context) error {
+		for _, entity := range entities {
+			restoreOnRollback(ctx, entity)
+			prepareCreate(entity)
+		}
+		result := r.withContext(ctx).CreateInBatches(entities, r.batchSize)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return fmt.Errorf("entities: %w", apperrs.ErrAlreadyExists)
+			}
+			logger.Error("Failed to create entities", zap.Int("count", len(entities)), zap.Error(result.Error))
+			return result.Error
+		}
+		return nil
+	})
+}
+
+// UpdateMany updates entities within one transaction. Each row needs its own
+// versioned UPDATE, so statements are issued per entity; on failure every
+// entity keeps the version it had before the call.
+func (r *GormRepository[T]) UpdateMany(ctx context.Context, entities []*T) error {
+	versions := make([]uint, len(entities))
+	for i, entity := range entities {
+		if base := domain.BaseOf(entity); base != nil {
+			versions[i] = base.Version
+		}
+	}
+
+	err := NewTransactionManager(r.db).WithTransaction(ctx, func(ctx context.Context) error {
+		for i, entity := range entities {
+			if err := r.Update(ctx, entity); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for i, entity := range entities {
+			if base := domain.BaseOf(entity); base != nil {
+				base.Version = versions[i]
+			}
+		}
+	}
+	return err
+}
+
+// DeleteMany soft deletes the entities with the given IDs in a single
+// statement, failing with ErrNotFound if any of them does not exist
+func (r *GormRepository[T]) DeleteMany(ctx context.Context, ids []uint) error {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return NewTransactionManager(r.db).WithTransaction(ctx, func(ctx context.Context) error {
+		result := r.withContext(ctx).Delete(new(T), ids)
+		if result.Error != nil {
+			logger.Error("Failed to delete entities", zap.Int("count", len(ids)), zap.Error(result.Error))
+			return result.Error
+		}
+		if missing := int64(len(ids)) - result.RowsAffected; missing > 0 {
+			return fmt.Errorf("%d of %d entities: %w", missing, len(ids), apperrs.ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// uniqueIDs returns ids without duplicates, keeping their order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// ListDeleted retrieves soft-deleted entities with pagination, most recently deleted first
+func (r *GormRepository[T]) ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	var entities []T
+	var count int64
+
+	offset := (page - 1) * pageSize
+	deleted := func() *gorm.DB {
+		return r.reader(ctx).Unscoped().Model(new(T)).Where("deleted_at IS NOT NULL")
+	}
+
+	if err := r.idempotent(ctx, func() error {
+		return deleted().Count(&count).Error
+	}); err != nil {
+		logger.Error("Failed to count deleted entities", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if err := r.idempotent(ctx, func() error {
+		return deleted().
+			Order("deleted_at DESC").
+			Offset(offset).
+			Limit(pageSize).
+			Find(&entities).Error
+	}); err != nil {
+		logger.Error("Failed to list deleted entities", zap.Error(err))
+		return nil, 0, err
 	}
 
 	return entities, count, nil
 }
 
-// WithTransaction executes the given function in a transaction
-func (r *GormRepository[T]) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Create a new context with the transaction
-		txCtx := context.WithValue(ctx, txKeyType{}, tx)
-		return fn(txCtx)
+// FindDeletedByPublicID retrieves a soft-deleted entity by its public ID
+func (r *GormRepository[T]) FindDeletedByPublicID(ctx context.Context, publicID string) (T, error) {
+	var entity T
+	err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).Unscoped().
+			Where("public_id = ? AND deleted_at IS NOT NULL", publicID).
+			First(&entity).Error
 	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var zero T
+			return zero, fmt.Errorf("deleted entity %s: %w", publicID, apperrs.ErrNotFound)
+		}
+		logger.Error("Failed to find deleted entity", zap.String("public_id", publicID), zap.Error(err))
+		return entity, err
+	}
+	return entity, nil
 }
 
-type txKeyType struct{}
+// Restore clears the deletion mark of a soft-deleted entity, failing with
+// ErrAlreadyExists if a live entity has since taken one of its unique values
+func (r *GormRepository[T]) Restore(ctx context.Context, id uint) error {
+	result := r.withContext(ctx).
+		Unscoped().
+		Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("deleted entity %d: %w", id, apperrs.ErrAlreadyExists)
+		}
+		logger.Error("Failed to restore entity", zap.Uint("id", id), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("deleted entity %d: %w", id, apperrs.ErrNotFound)
+	}
+	return nil
+}
+
+// Purge permanently removes entities soft deleted before the given time
+func (r *GormRepository[T]) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.idempotent(ctx, func() error {
+		result := r.withContext(ctx).
+			Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Delete(new(T))
+		purged += result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		logger.Error("Failed to purge deleted entities", zap.Time("deleted_before", deletedBefore), zap.Error(err))
+		return 0, err
+	}
+	return purged, nil
+}
+
+// WithTransaction executes the given function in a transaction
+func (r *GormRepository[T]) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return NewTransactionManager(r.db).WithTransaction(ctx, fn)
+}
@@ -0,0 +1,172 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/app/ports/porttest"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/migrations"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+func connect(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Connect(database.Config{
+		URL:     "sqlite://file::memory:",
+		Migrate: true,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	return db
+}
+
+func TestGormRepositoryConformance(t *testing.T) {
+	porttest.RunRepositoryConformance(t, porttest.RepositoryHarness[models.User]{
+		New: func(t *testing.T) (ports.Repository[models.User], ports.TransactionManager) {
+			db := connect(t)
+			return database.NewGormRepository[models.User](db), database.NewTransactionManager(db)
+		},
+		NewEntity: func(i int) *models.User {
+			return &models.User{
+				Email:        fmt.Sprintf("user%d@example.com", i),
+				PasswordHash: "hash",
+				FirstName:    "First",
+				LastName:     "Last",
+				Role:         "user",
+				Active:       true,
+			}
+		},
+		Mutate: func(user *models.User) {
+			user.FirstName += "x"
+		},
+		Equal: func(a, b models.User) bool {
+			return a.ID == b.ID && a.Version == b.Version && a.Email == b.Email &&
+				a.FirstName == b.FirstName && a.LastName == b.LastName
+		},
+		LookupField: "email",
+		LookupValue: func(user models.User) string { return user.Email },
+	})
+}
+
+func TestGormRepositoryCreateManyRejectsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewGormRepository[models.User](connect(t), database.WithBatchSize(2))
+
+	users := []*models.User{
+		{Email: "a@example.com", PasswordHash: "hash"},
+		{Email: "b@example.com", PasswordHash: "hash"},
+		{Email: "a@example.com", PasswordHash: "hash"},
+	}
+	if err := repo.CreateMany(ctx, users); !errors.Is(err, apperrs.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists, got %v", err)
+	}
+	if _, total, _ := repo.List(ctx, 1, 10); total != 0 {
+		t.Fatalf("expected the earlier batches to be rolled back, total=%d", total)
+	}
+}
+
+func TestGormRepositoryReregistersDeletedEmail(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewGormRepository[models.User](connect(t))
+
+	deleted := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, deleted); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Create(ctx, &models.User{Email: "A@example.com", PasswordHash: "hash"}); !errors.Is(err, apperrs.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists for a live user's email, got %v", err)
+	}
+	if err := repo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// The email of a deleted user can be registered again
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create after delete: %v", err)
+	}
+	found, err := repo.FindOneBy(ctx, "email", "a@example.com")
+	if err != nil || found.ID != user.ID {
+		t.Fatalf("FindOneBy returned %d, %v; want the new user %d", found.ID, err, user.ID)
+	}
+
+	// but the deleted user cannot be restored while it is taken
+	if err := repo.Restore(ctx, deleted.ID); !errors.Is(err, apperrs.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists restoring a taken email, got %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Restore(ctx, deleted.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+
+	if err := database.HealthCheck(db)(ctx); err != nil {
+		t.Fatalf("expected the database to be healthy: %v", err)
+	}
+	if err := database.MigrationsCheck(db)(ctx); err != nil {
+		t.Fatalf("expected every migration to be applied: %v", err)
+	}
+
+	db.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)")
+	if err := database.MigrationsCheck(db)(ctx); err == nil || !strings.Contains(err.Error(), "1 migrations pending") {
+		t.Fatalf("expected a pending migration, got %v", err)
+	}
+}
+
+func TestPublicIDBackfill(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatalf("migrations.New: %v", err)
+	}
+	if err := migrator.To(ctx, 5); err != nil {
+		t.Fatalf("To(5): %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if err := db.Exec("INSERT INTO users (email, password_hash, created_at, updated_at) VALUES (?, 'hash', ?, ?)", email, created, created).Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	var publicIDs []string
+	db.Raw("SELECT public_id FROM users ORDER BY id").Scan(&publicIDs)
+	prefix := fmt.Sprintf("%012x", created.UnixMilli())
+	for _, publicID := range publicIDs {
+		if _, ok := domain.ParsePublicID(publicID); !ok || publicID[14] != '7' ||
+			strings.ReplaceAll(publicID, "-", "")[:12] != prefix {
+			t.Fatalf("expected a UUIDv7 starting with %s, got %q", prefix, publicID)
+		}
+	}
+	if len(publicIDs) != 2 || publicIDs[0] == publicIDs[1] {
+		t.Fatalf("expected two distinct public IDs, got %v", publicIDs)
+	}
+
+	user, err := database.NewGormRepository[models.User](db).FindByPublicID(ctx, publicIDs[1])
+	if err != nil || user.Email != "b@example.com" {
+		t.Fatalf("expected to find the backfilled user, got %+v, %v", user, err)
+	}
+}
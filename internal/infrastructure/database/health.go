@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go-server-boilerplate/internal/infrastructure/database/migrations"
+	"go-server-boilerplate/internal/pkg/health"
+
+	"gorm.io/gorm"
+)
+
+// HealthCheck returns a check pinging the database and failing when every
+// connection of the pool is in use while requests wait for one
+func HealthCheck(db *gorm.DB) health.CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping failed: %w", err)
+		}
+		stats := sqlDB.Stats()
+		if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections && stats.WaitCount > 0 {
+			return fmt.Errorf("connection pool exhausted: %d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+		}
+		return nil
+	}
+}
+
+// MigrationsCheck returns a check failing while embedded migrations are
+// pending or applied ones have been edited or removed
+func MigrationsCheck(db *gorm.DB) health.CheckFunc {
+	return func(ctx context.Context) error {
+		migrator, err := migrations.New(db)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for _, status := range statuses {
+			switch {
+			case status.Modified:
+				return fmt.Errorf("applied migration %d_%s has been modified", status.Version, status.Name)
+			case status.Missing:
+				return fmt.Errorf("applied migration %d has no file", status.Version)
+			case !status.Applied:
+				pending++
+			}
+		}
+		if pending > 0 {
+			return fmt.Errorf("%d migrations pending", pending)
+		}
+		return nil
+	}
+}
+
+// HealthCheck fails when no replica is healthy and reads have fallen back
+// to the primary
+func (rs *ReplicaSet) HealthCheck(ctx context.Context) error {
+	if rs.Healthy() == 0 {
+		return fmt.Errorf("none of %d replicas is healthy", rs.Size())
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS public_id VARCHAR(36);
+
+-- Backfill existing rows with UUIDv7 built from their creation time, so that
+-- public IDs sort like the rows they identify: the first 48 bits of a random
+-- UUID are replaced by the millisecond timestamp and the version nibble is
+-- turned from 4 into 7
+UPDATE users SET public_id = encode(
+    set_bit(
+        set_bit(
+            overlay(uuid_send(gen_random_uuid())
+                placing substring(int8send(floor(extract(epoch FROM created_at) * 1000)::bigint) FROM 3)
+                FROM 1 FOR 6),
+            52, 1),
+        53, 1),
+    'hex')::uuid::text
+WHERE public_id IS NULL;
+
+ALTER TABLE users ALTER COLUMN public_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_public_id ON users (public_id);
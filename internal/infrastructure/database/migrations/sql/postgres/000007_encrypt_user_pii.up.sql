@@ -0,0 +1,24 @@
+-- Emails and names are encrypted by the application from now on. Ciphertexts
+-- are longer than the values they encrypt, and differ every time a value is
+-- written, so uniqueness and lookups move to the blind index of the email.
+-- Existing rows keep their plaintext until they are backfilled with
+-- email_index; until then they are matched on their email.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_index VARCHAR(64);
+ALTER TABLE users
+    ALTER COLUMN email TYPE TEXT,
+    ALTER COLUMN first_name TYPE TEXT,
+    ALTER COLUMN last_name TYPE TEXT;
+
+-- Like emails, blind indexes are unique among live users only
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_index ON users (email_index) WHERE deleted_at IS NULL;
+
+-- Rows without email_index are kept unique by their plaintext email until
+-- they are backfilled, which the server does at startup
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE email_index IS NULL AND deleted_at IS NULL;
+
+-- Encrypted columns cannot be searched by content
+DROP INDEX IF EXISTS idx_users_last_name_trgm;
+DROP INDEX IF EXISTS idx_users_first_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_search_document;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_search_tokens_trgm;
+ALTER TABLE users DROP COLUMN IF EXISTS search_tokens;
@@ -0,0 +1,7 @@
+-- Encrypted emails and names are searched through search_tokens, a blind
+-- index of their trigrams computed by the application. Existing rows are
+-- filled by the re-encryption job. The trigram index serves the
+-- LIKE '% token %' patterns built by GormRepository.Search.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_tokens TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_users_search_tokens_trgm ON users USING GIN (search_tokens gin_trgm_ops);
@@ -0,0 +1,22 @@
+-- Audit entries and outbox events leave the server, through the admin API
+-- and the event publishers, so they carry the public ID of the entity they
+-- are about, and audit entries that of their actor, next to the internal
+-- IDs used for joins and ordering. Existing rows about users are backfilled.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS entity_public_id VARCHAR(36);
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS actor_public_id VARCHAR(36);
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS aggregate_public_id VARCHAR(36);
+
+UPDATE audit_log SET entity_public_id = users.public_id
+FROM users
+WHERE audit_log.entity_type = 'users' AND audit_log.entity_id = users.id;
+
+UPDATE audit_log SET actor_public_id = users.public_id
+FROM users
+WHERE audit_log.actor_id = users.id;
+
+UPDATE outbox_events SET aggregate_public_id = users.public_id
+FROM users
+WHERE outbox_events.aggregate_type = 'users' AND outbox_events.aggregate_id = users.id;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity_public_id ON audit_log (entity_type, entity_public_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_public_id ON audit_log (actor_public_id, created_at);
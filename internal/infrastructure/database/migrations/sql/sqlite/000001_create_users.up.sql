@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    email         VARCHAR(255) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    first_name    VARCHAR(255),
+    last_name     VARCHAR(255),
+    role          VARCHAR(50)  DEFAULT 'user',
+    last_login    DATETIME,
+    active        BOOLEAN      DEFAULT true
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
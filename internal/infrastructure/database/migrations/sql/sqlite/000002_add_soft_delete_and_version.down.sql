@@ -0,0 +1,7 @@
+-- Fails if a deleted user shares its email with a live one
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type VARCHAR(100) NOT NULL,
+    entity_id   INTEGER      NOT NULL,
+    action      VARCHAR(20)  NOT NULL,
+    actor_id    INTEGER,
+    request_id  VARCHAR(100),
+    changes     TEXT         NOT NULL DEFAULT '{}',
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
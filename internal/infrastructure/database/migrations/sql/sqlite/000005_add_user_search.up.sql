@@ -0,0 +1,3 @@
+-- SQLite searches with LIKE '%term%', which no index can serve; this
+-- migration only keeps the version numbers aligned with Postgres.
+SELECT 1;
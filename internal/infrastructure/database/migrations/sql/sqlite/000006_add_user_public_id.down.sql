@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_public_id;
+
+ALTER TABLE users DROP COLUMN public_id;
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN public_id VARCHAR(36);
+
+-- Backfill existing rows with UUIDv7 built from their creation time, so that
+-- public IDs sort like the rows they identify. SQLite cannot add a NOT NULL
+-- column without a default; the repositories always set it.
+UPDATE users SET public_id = (
+    SELECT lower(
+        substr(ts, 1, 8) || '-' || substr(ts, 9, 4) || '-7' ||
+        substr(hex(randomblob(2)), 2) || '-' ||
+        substr('89ab', 1 + abs(random() % 4), 1) || substr(hex(randomblob(2)), 2) || '-' ||
+        hex(randomblob(6))
+    )
+    FROM (SELECT printf('%012x', CAST((julianday(users.created_at) - 2440587.5) * 86400000 AS INTEGER)) AS ts)
+)
+WHERE public_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_public_id ON users (public_id);
@@ -0,0 +1,6 @@
+-- Values stay encrypted: decrypt them before rolling back
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS idx_users_email_index;
+
+ALTER TABLE users DROP COLUMN email_index;
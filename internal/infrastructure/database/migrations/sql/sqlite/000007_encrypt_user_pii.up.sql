@@ -0,0 +1,14 @@
+-- Emails and names are encrypted by the application from now on; ciphertexts
+-- differ every time a value is written, so uniqueness and lookups move to the
+-- blind index of the email. Existing rows keep their plaintext until they
+-- are backfilled with email_index. SQLite does not enforce VARCHAR lengths,
+-- so the columns are left as they are.
+ALTER TABLE users ADD COLUMN email_index VARCHAR(64);
+
+-- Like emails, blind indexes are unique among live users only
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_index ON users (email_index) WHERE deleted_at IS NULL;
+
+-- Rows without email_index are kept unique by their plaintext email until
+-- they are backfilled, which the server does at startup
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email) WHERE email_index IS NULL AND deleted_at IS NULL;
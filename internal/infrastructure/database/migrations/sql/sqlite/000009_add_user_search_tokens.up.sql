@@ -0,0 +1,4 @@
+-- Encrypted emails and names are searched through search_tokens, a blind
+-- index of their trigrams computed by the application. Existing rows are
+-- filled by the re-encryption job.
+ALTER TABLE users ADD COLUMN search_tokens TEXT;
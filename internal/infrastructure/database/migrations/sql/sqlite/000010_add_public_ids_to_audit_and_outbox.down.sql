@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_audit_log_actor_public_id;
+DROP INDEX IF EXISTS idx_audit_log_entity_public_id;
+
+ALTER TABLE outbox_events DROP COLUMN aggregate_public_id;
+ALTER TABLE audit_log DROP COLUMN actor_public_id;
+ALTER TABLE audit_log DROP COLUMN entity_public_id;
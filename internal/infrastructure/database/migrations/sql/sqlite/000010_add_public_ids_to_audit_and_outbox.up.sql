@@ -0,0 +1,19 @@
+-- Audit entries and outbox events leave the server, through the admin API
+-- and the event publishers, so they carry the public ID of the entity they
+-- are about, and audit entries that of their actor, next to the internal
+-- IDs used for joins and ordering. Existing rows about users are backfilled.
+ALTER TABLE audit_log ADD COLUMN entity_public_id VARCHAR(36);
+ALTER TABLE audit_log ADD COLUMN actor_public_id VARCHAR(36);
+ALTER TABLE outbox_events ADD COLUMN aggregate_public_id VARCHAR(36);
+
+UPDATE audit_log SET entity_public_id = (SELECT public_id FROM users WHERE users.id = audit_log.entity_id)
+WHERE entity_type = 'users';
+
+UPDATE audit_log SET actor_public_id = (SELECT public_id FROM users WHERE users.id = audit_log.actor_id)
+WHERE actor_id IS NOT NULL;
+
+UPDATE outbox_events SET aggregate_public_id = (SELECT public_id FROM users WHERE users.id = outbox_events.aggregate_id)
+WHERE aggregate_type = 'users';
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity_public_id ON audit_log (entity_type, entity_public_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor_public_id ON audit_log (actor_public_id, created_at);
@@ -1,21 +1,30 @@
 package models
 
 import (
+	"fmt"
+	"net/mail"
 	"time"
 
 	"go-server-boilerplate/internal/app/domain"
+	// Registers the encrypted serializer used by the fields below
+	_ "go-server-boilerplate/internal/infrastructure/database/encryption"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// User represents a user in the system
+// User represents a user in the system. The email and names are stored
+// encrypted; the email is matched through its blind index EmailIndex, and
+// the trigrams of their words are searched through SearchTokens. The database
+// layer computes both on every write.
 type User struct {
 	domain.BaseEntity
-	Email        string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	PasswordHash string     `gorm:"type:varchar(255);not null" json:"-"`
-	FirstName    string     `gorm:"type:varchar(255)" json:"first_name"`
-	LastName     string     `gorm:"type:varchar(255)" json:"last_name"`
+	Email        string     `gorm:"type:text;not null;serializer:encrypted" json:"email"`
+	EmailIndex   string     `gorm:"type:varchar(64);uniqueIndex:idx_users_email_index,where:deleted_at IS NULL" json:"-" blindindex:"email"`
+	SearchTokens string     `gorm:"type:text" json:"-" audit:"-" searchtokens:"email,first_name,last_name"`
+	PasswordHash string     `gorm:"type:varchar(255);not null" json:"-" audit:"redact"`
+	FirstName    string     `gorm:"type:text;serializer:encrypted" json:"first_name"`
+	LastName     string     `gorm:"type:text;serializer:encrypted" json:"last_name"`
 	Role         string     `gorm:"type:varchar(50);default:'user'" json:"role"`
 	LastLogin    *time.Time `json:"last_login"`
 	Active       bool       `gorm:"default:true" json:"active"`
@@ -35,6 +44,32 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// maxNameLength bounds the length of names, matching their columns
+const maxNameLength = 255
+
+// Validate checks the invariants of a user; services call it before every
+// create and update
+func (u *User) Validate() error {
+	var errs domain.ValidationErrors
+	if u.Email == "" {
+		errs.Add("email", "is required")
+	} else if address, err := mail.ParseAddress(u.Email); err != nil || address.Address != u.Email {
+		errs.Add("email", "is not a valid email address")
+	} else if len(u.Email) > maxNameLength {
+		errs.Add("email", "is too long")
+	}
+	if len(u.FirstName) > maxNameLength {
+		errs.Add("first_name", "is too long")
+	}
+	if len(u.LastName) > maxNameLength {
+		errs.Add("last_name", "is too long")
+	}
+	if u.Role != "" && u.Role != "user" && u.Role != "admin" {
+		errs.Add("role", "must be user or admin")
+	}
+	return errs.Err()
+}
+
 // SetPassword hashes the password and sets it to the user
 func (u *User) SetPassword(password string) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -57,6 +92,22 @@ func (u *User) UpdateLastLogin() {
 	u.LastLogin = &now
 }
 
+// erasedPasswordHash is not a bcrypt hash, so no password matches it
+const erasedPasswordHash = "!erased"
+
+// Anonymize replaces the personal data of the user, for erasure requests.
+// The record itself is kept so that data referring to it stays valid: its
+// email becomes a unique placeholder address, its names are cleared and it
+// can no longer log in.
+func (u *User) Anonymize() {
+	u.Email = fmt.Sprintf("erased-%s@erased.invalid", u.PublicID)
+	u.FirstName = ""
+	u.LastName = ""
+	u.PasswordHash = erasedPasswordHash
+	u.LastLogin = nil
+	u.Active = false
+}
+
 // DisplayName returns the full name of the user or their email if not available
 func (u *User) DisplayName() string {
 	if u.FirstName != "" || u.LastName != "" {
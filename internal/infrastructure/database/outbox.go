@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// outboxLockKey is the Postgres advisory lock held while claiming events
+const outboxLockKey = 7_206_436_105
+
+// maxOutboxErrorLength bounds the delivery error stored with an event
+const maxOutboxErrorLength = 1000
+
+// GormOutboxRepository implements ports.OutboxRepository using GORM
+type GormOutboxRepository struct {
+	db *gorm.DB
+}
+
+// NewGormOutboxRepository creates a new GORM outbox repository
+func NewGormOutboxRepository(db *gorm.DB) *GormOutboxRepository {
+	return &GormOutboxRepository{
+		db: db,
+	}
+}
+
+// withContext returns the GORM DB instance, joining the transaction carried
+// by ctx if there is one
+func (r *GormOutboxRepository) withContext(ctx context.Context) *gorm.DB {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
+	return r.db.WithContext(ctx)
+}
+
+// Add stores events, joining the transaction carried by ctx
+func (r *GormOutboxRepository) Add(ctx context.Context, events ...*domain.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, event := range events {
+		if event.NextAttemptAt.IsZero() {
+			event.NextAttemptAt = now
+		}
+	}
+
+	if err := r.withContext(ctx).Create(events).Error; err != nil {
+		logger.Error("Failed to add outbox events", zap.Int("count", len(events)), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Claim retrieves up to limit unpublished events in ID order, leaving out
+// aggregates whose oldest unpublished event is not yet due, and leases them
+// until the given time by moving their next attempt there.
+//
+// When called inside a transaction on Postgres it first takes a
+// transaction-scoped advisory lock, so that concurrent relays cannot claim
+// the same events; if another relay holds the lock no events are returned.
+// The transaction is meant to commit right after the claim, before the
+// events are published.
+func (r *GormOutboxRepository) Claim(ctx context.Context, limit int, until time.Time) ([]domain.OutboxEvent, error) {
+	db := r.withContext(ctx)
+
+	if txFromContext(ctx) != nil && db.Dialector.Name() == "postgres" {
+		var locked bool
+		if err := db.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+			logger.Error("Failed to lock outbox", zap.Error(err))
+			return nil, err
+		}
+		if !locked {
+			return nil, nil
+		}
+	}
+
+	var events []domain.OutboxEvent
+	result := db.
+		Where("published_at IS NULL").
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events blocking
+			WHERE blocking.published_at IS NULL
+			AND blocking.aggregate_type = outbox_events.aggregate_type
+			AND blocking.aggregate_id = outbox_events.aggregate_id
+			AND blocking.id <= outbox_events.id
+			AND blocking.next_attempt_at > ?
+		)`, time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&events)
+	if result.Error != nil {
+		logger.Error("Failed to fetch pending outbox events", zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	ids := make([]uint, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+		events[i].NextAttemptAt = until
+	}
+	result = db.
+		Model(&domain.OutboxEvent{}).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		logger.Error("Failed to claim outbox events", zap.Int("count", len(ids)), zap.Error(result.Error))
+		return nil, result.Error
+	}
+	return events, nil
+}
+
+// Release makes claimed events due again without counting an attempt
+func (r *GormOutboxRepository) Release(ctx context.Context, ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	result := r.withContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("id IN ? AND published_at IS NULL", ids).
+		Update("next_attempt_at", time.Now())
+	if result.Error != nil {
+		logger.Error("Failed to release outbox events", zap.Int("count", len(ids)), zap.Error(result.Error))
+		return result.Error
+	}
+	return nil
+}
+
+// MarkPublished records that an event was delivered
+func (r *GormOutboxRepository) MarkPublished(ctx context.Context, id uint) error {
+	result := r.withContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"published_at": time.Now(),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		})
+	if result.Error != nil {
+		logger.Error("Failed to mark outbox event published", zap.Uint("id", id), zap.Error(result.Error))
+		return result.Error
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to try again
+func (r *GormOutboxRepository) MarkFailed(ctx context.Context, id uint, cause error, nextAttempt time.Time) error {
+	message := cause.Error()
+	if len(message) > maxOutboxErrorLength {
+		message = message[:maxOutboxErrorLength]
+	}
+
+	result := r.withContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      message,
+			"next_attempt_at": nextAttempt,
+		})
+	if result.Error != nil {
+		logger.Error("Failed to mark outbox event failed", zap.Uint("id", id), zap.Error(result.Error))
+		return result.Error
+	}
+	return nil
+}
+
+// DeletePublished removes events published before the given time
+func (r *GormOutboxRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	result := r.withContext(ctx).
+		Where("published_at IS NOT NULL AND published_at < ?", publishedBefore).
+		Delete(&domain.OutboxEvent{})
+	if result.Error != nil {
+		logger.Error("Failed to delete published outbox events", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,60 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database"
+)
+
+func TestGormOutboxClaimHoldsBackFailedAggregates(t *testing.T) {
+	ctx := context.Background()
+	outbox := database.NewGormOutboxRepository(connect(t))
+
+	events := []*domain.OutboxEvent{
+		{AggregateType: "users", AggregateID: 1, EventType: "users.created", Payload: []byte(`{}`)},
+		{AggregateType: "users", AggregateID: 2, EventType: "users.created", Payload: []byte(`{}`)},
+		{AggregateType: "users", AggregateID: 1, EventType: "users.updated", Payload: []byte(`{}`)},
+		{AggregateType: "users", AggregateID: 2, EventType: "users.updated", Payload: []byte(`{}`)},
+	}
+	if err := outbox.Add(ctx, events...); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if err := outbox.MarkFailed(ctx, events[0].ID, errors.New("unavailable"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if err := outbox.MarkPublished(ctx, events[1].ID); err != nil {
+		t.Fatalf("MarkPublished: %v", err)
+	}
+
+	claimed, err := outbox.Claim(ctx, 10, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != events[3].ID {
+		t.Fatalf("Claim returned %+v, want only event %d", claimed, events[3].ID)
+	}
+
+	// Claimed events are leased until released
+	if again, err := outbox.Claim(ctx, 10, time.Now().Add(time.Hour)); err != nil || len(again) != 0 {
+		t.Fatalf("Claim returned %+v, %v; want no events while leased", again, err)
+	}
+	if err := outbox.Release(ctx, events[3].ID); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if again, err := outbox.Claim(ctx, 10, time.Now().Add(time.Hour)); err != nil || len(again) != 1 || again[0].Attempts != 0 {
+		t.Fatalf("Claim returned %+v, %v; want the released event without an attempt", again, err)
+	}
+
+	deleted, err := outbox.DeletePublished(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeletePublished: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("DeletePublished removed %d events, want 1", deleted)
+	}
+}
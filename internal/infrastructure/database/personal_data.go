@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// erasedPayload replaces the payload of outbox events about an erased user
+var erasedPayload = json.RawMessage(`{}`)
+
+// AuditDataProvider implements ports.PersonalDataProvider for the audit log:
+// the entries recording changes to a user and those the user made
+type AuditDataProvider struct {
+	db         *gorm.DB
+	entityType string
+}
+
+// NewAuditDataProvider creates a provider for the audit entries of users
+// stored as entityType, e.g. "users"
+func NewAuditDataProvider(db *gorm.DB, entityType string) *AuditDataProvider {
+	return &AuditDataProvider{
+		db:         db,
+		entityType: entityType,
+	}
+}
+
+// withContext returns the GORM DB instance, joining the transaction carried
+// by ctx if there is one
+func (p *AuditDataProvider) withContext(ctx context.Context) *gorm.DB {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
+	return p.db.WithContext(ctx)
+}
+
+// Name identifies the provider
+func (p *AuditDataProvider) Name() string {
+	return "audit_log"
+}
+
+// Export returns the entries about the user and those the user made, oldest
+// first
+func (p *AuditDataProvider) Export(ctx context.Context, userID uint) (any, error) {
+	entries := []domain.AuditEntry{}
+	err := p.withContext(ctx).
+		Where("(entity_type = ? AND entity_id = ?) OR actor_id = ?", p.entityType, userID, userID).
+		Order("id").
+		Find(&entries).Error
+	if err != nil {
+		logger.Error("Failed to export audit entries", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Erase redacts the values recorded in the entries about the user. The
+// entries themselves are kept, as is the actor of changes the user made to
+// other entities: the audit trail keeps its shape without the data.
+func (p *AuditDataProvider) Erase(ctx context.Context, userID uint) error {
+	db := p.withContext(ctx)
+	var entries []domain.AuditEntry
+	if err := db.Where("entity_type = ? AND entity_id = ?", p.entityType, userID).Find(&entries).Error; err != nil {
+		logger.Error("Failed to read audit entries to erase", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	for i := range entries {
+		redacted := make(map[string]domain.AuditChange, len(entries[i].Changes))
+		for field, change := range entries[i].Changes {
+			if change.Old != nil {
+				change.Old = domain.AuditRedacted
+			}
+			if change.New != nil {
+				change.New = domain.AuditRedacted
+			}
+			redacted[field] = change
+		}
+		// A struct update, so that the changes go through their serializer
+		if err := db.Model(&entries[i]).Select("changes").Updates(domain.AuditEntry{Changes: redacted}).Error; err != nil {
+			logger.Error("Failed to redact audit entry", zap.Uint("id", entries[i].ID), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// OutboxDataProvider implements ports.PersonalDataProvider for the outbox
+// events of a user aggregate, which carry the user as it was when they were
+// recorded
+type OutboxDataProvider struct {
+	db            *gorm.DB
+	aggregateType string
+}
+
+// NewOutboxDataProvider creates a provider for the outbox events of users
+// recorded as aggregateType, e.g. "users"
+func NewOutboxDataProvider(db *gorm.DB, aggregateType string) *OutboxDataProvider {
+	return &OutboxDataProvider{
+		db:            db,
+		aggregateType: aggregateType,
+	}
+}
+
+// withContext returns the GORM DB instance, joining the transaction carried
+// by ctx if there is one
+func (p *OutboxDataProvider) withContext(ctx context.Context) *gorm.DB {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx.WithContext(ctx)
+	}
+	return p.db.WithContext(ctx)
+}
+
+// Name identifies the provider
+func (p *OutboxDataProvider) Name() string {
+	return "events"
+}
+
+// Export returns the events of the user, oldest first
+func (p *OutboxDataProvider) Export(ctx context.Context, userID uint) (any, error) {
+	events := []domain.OutboxEvent{}
+	err := p.withContext(ctx).
+		Where("aggregate_type = ? AND aggregate_id = ?", p.aggregateType, userID).
+		Order("id").
+		Find(&events).Error
+	if err != nil {
+		logger.Error("Failed to export outbox events", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return events, nil
+}
+
+// Erase empties the payloads of the user's events. Events not yet
+// published are still delivered, without the data.
+func (p *OutboxDataProvider) Erase(ctx context.Context, userID uint) error {
+	err := p.withContext(ctx).
+		Model(&domain.OutboxEvent{}).
+		Where("aggregate_type = ? AND aggregate_id = ?", p.aggregateType, userID).
+		Update("payload", erasedPayload).Error
+	if err != nil {
+		logger.Error("Failed to erase outbox events", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxFingerprints bounds the number of distinct queries tracked; further
+	// queries are aggregated under OtherFingerprint
+	maxFingerprints = 1000
+
+	// latencySamples is the number of recent latencies kept per query for
+	// the percentiles
+	latencySamples = 512
+
+	// maxFingerprintLength truncates very long statements
+	maxFingerprintLength = 2000
+)
+
+// OtherFingerprint aggregates queries once maxFingerprints are tracked
+const OtherFingerprint = "(other)"
+
+var (
+	sqlLineComment  = regexp.MustCompile(`--[^\n]*`)
+	sqlBlockComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	sqlString       = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlPlaceholder  = regexp.MustCompile(`\$\d+`)
+	sqlNumber       = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+	sqlBoolean      = regexp.MustCompile(`(?i)\b(?:true|false)\b`)
+	sqlSpace        = regexp.MustCompile(`\s+`)
+	sqlList         = regexp.MustCompile(`(?i)\bIN \(\?(?:, ?\?)*\)`)
+	sqlRows         = regexp.MustCompile(`(?i)\bVALUES \(\?(?:, ?\?)*\)(?:, ?\(\?(?:, ?\?)*\))*`)
+)
+
+// Fingerprint normalizes a SQL statement so that executions differing only
+// in their values map to the same string: comments are removed, literals
+// and placeholders become ?, IN lists and VALUES rows of any length become
+// (?+) and whitespace is collapsed
+func Fingerprint(sql string) string {
+	sql = sqlBlockComment.ReplaceAllString(sql, " ")
+	sql = sqlLineComment.ReplaceAllString(sql, " ")
+	sql = sqlString.ReplaceAllString(sql, "?")
+	sql = sqlPlaceholder.ReplaceAllString(sql, "?")
+	sql = sqlNumber.ReplaceAllString(sql, "?")
+	sql = sqlBoolean.ReplaceAllString(sql, "?")
+	sql = strings.TrimSpace(sqlSpace.ReplaceAllString(sql, " "))
+	sql = strings.NewReplacer("( ", "(", " )", ")", " ,", ",").Replace(sql)
+	sql = sqlList.ReplaceAllString(sql, "IN (?+)")
+	sql = sqlRows.ReplaceAllString(sql, "VALUES (?+)")
+	if len(sql) > maxFingerprintLength {
+		sql = sql[:maxFingerprintLength] + "..."
+	}
+	return sql
+}
+
+// QueryStat holds the aggregated statistics of one query fingerprint.
+// Percentiles are computed over the most recent executions.
+type QueryStat struct {
+	Fingerprint string        `json:"fingerprint"`
+	Count       uint64        `json:"count"`
+	Errors      uint64        `json:"errors"`
+	Slow        uint64        `json:"slow"`
+	Rows        int64         `json:"rows"`
+	TotalTime   time.Duration `json:"total_time_ns"`
+	MeanTime    time.Duration `json:"mean_time_ns"`
+	P50         time.Duration `json:"p50_ns"`
+	P95         time.Duration `json:"p95_ns"`
+	Max         time.Duration `json:"max_ns"`
+	LastSeen    time.Time     `json:"last_seen"`
+}
+
+// queryEntry accumulates the statistics of one fingerprint
+type queryEntry struct {
+	stat    QueryStat
+	samples []time.Duration
+	next    int
+}
+
+// QueryStats aggregates executed queries by fingerprint
+type QueryStats struct {
+	mu      sync.Mutex
+	entries map[string]*queryEntry
+}
+
+// NewQueryStats creates an empty set of query statistics
+func NewQueryStats() *QueryStats {
+	return &QueryStats{
+		entries: make(map[string]*queryEntry),
+	}
+}
+
+// Record adds one execution of sql
+func (s *QueryStats) Record(sql string, elapsed time.Duration, rows int64, slow bool, err error) {
+	fingerprint := Fingerprint(sql)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[fingerprint]
+	if !ok {
+		if len(s.entries) >= maxFingerprints {
+			fingerprint = OtherFingerprint
+			entry = s.entries[fingerprint]
+		}
+		if entry == nil {
+			entry = &queryEntry{
+				stat:    QueryStat{Fingerprint: fingerprint},
+				samples: make([]time.Duration, 0, 16),
+			}
+			s.entries[fingerprint] = entry
+		}
+	}
+
+	stat := &entry.stat
+	stat.Count++
+	if err != nil {
+		stat.Errors++
+	}
+	if slow {
+		stat.Slow++
+	}
+	if rows > 0 {
+		stat.Rows += rows
+	}
+	stat.TotalTime += elapsed
+	stat.Max = max(stat.Max, elapsed)
+	stat.LastSeen = time.Now()
+
+	if len(entry.samples) < latencySamples {
+		entry.samples = append(entry.samples, elapsed)
+	} else {
+		entry.samples[entry.next] = elapsed
+		entry.next = (entry.next + 1) % latencySamples
+	}
+}
+
+// Top returns the n fingerprints with the highest total time, highest first
+func (s *QueryStats) Top(n int) []QueryStat {
+	s.mu.Lock()
+	stats := make([]QueryStat, 0, len(s.entries))
+	samples := make([][]time.Duration, 0, len(s.entries))
+	for _, entry := range s.entries {
+		stats = append(stats, entry.stat)
+		samples = append(samples, slices.Clone(entry.samples))
+	}
+	s.mu.Unlock()
+
+	for i := range stats {
+		slices.Sort(samples[i])
+		stats[i].P50 = percentile(samples[i], 50)
+		stats[i].P95 = percentile(samples[i], 95)
+		stats[i].MeanTime = stats[i].TotalTime / time.Duration(stats[i].Count)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalTime != stats[j].TotalTime {
+			return stats[i].TotalTime > stats[j].TotalTime
+		}
+		return stats[i].Fingerprint < stats[j].Fingerprint
+	})
+
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// Reset discards every statistic
+func (s *QueryStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*queryEntry)
+}
+
+// percentile returns the p-th percentile of sorted samples using the
+// nearest-rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
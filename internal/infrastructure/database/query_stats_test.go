@@ -0,0 +1,66 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		`SELECT * FROM "users" WHERE email = 'a@example.com' AND id = 42 LIMIT 1`: `SELECT * FROM "users" WHERE email = ? AND id = ? LIMIT ?`,
+		`SELECT * FROM users WHERE id IN (1, 2,3) AND active = true`:              `SELECT * FROM users WHERE id IN (?+) AND active = ?`,
+		"INSERT INTO t (a,b) VALUES ($1,$2),($3,$4),\n ($5, $6) -- bulk":          `INSERT INTO t (a,b) VALUES (?+)`,
+		`SELECT 'it''s' /* note */ FROM users2 WHERE score > 1.5e3`:               `SELECT ? FROM users2 WHERE score > ?`,
+	}
+	for sql, want := range cases {
+		if got := database.Fingerprint(sql); got != want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestQueryStatsAggregatesByFingerprint(t *testing.T) {
+	ctx := context.Background()
+	stats := database.NewQueryStats()
+	db, err := database.Connect(database.Config{
+		URL:        "sqlite://file::memory:",
+		Migrate:    true,
+		QueryStats: stats,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	stats.Reset()
+
+	repo := database.NewGormRepository[models.User](db)
+	for i := 0; i < 5; i++ {
+		repo.FindByID(ctx, uint(i+1))
+	}
+
+	top := stats.Top(10)
+	var lookup *database.QueryStat
+	for i := range top {
+		if strings.Contains(top[i].Fingerprint, "`users`.`id` = ?") {
+			lookup = &top[i]
+		}
+	}
+	if lookup == nil || lookup.Count != 5 || lookup.Errors != 0 {
+		t.Fatalf("expected 5 lookups under one fingerprint, got %+v", top)
+	}
+	if lookup.P50 <= 0 || lookup.P95 < lookup.P50 || lookup.Max < lookup.P95 || lookup.MeanTime <= 0 {
+		t.Fatalf("inconsistent latencies %+v", lookup)
+	}
+
+	for i := 0; i < 3; i++ {
+		stats.Record("SELECT pg_sleep(1)", time.Second, 0, true, nil)
+	}
+	if top := stats.Top(1); len(top) != 1 || top[0].Fingerprint != "SELECT pg_sleep(?)" || top[0].Slow != 3 {
+		t.Fatalf("expected the slowest query first, got %+v", top)
+	}
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// defaultReencryptBatchSize is the number of rows read per batch by a
+// Reencryptor unless configured otherwise
+const defaultReencryptBatchSize = 500
+
+// Reencryptor rewrites the encrypted fields of T that are not encrypted
+// with the primary key of the keyring in use: values written before a key
+// rotation, and plaintext written before encryption was enabled. It also
+// fills the blind indexes and search tokens those rows lack. Run it periodically, as a
+// background job, after adding a new primary key; old keys can be removed
+// from the keyring once a run finds nothing left to rewrite.
+type Reencryptor[T domain.Entity] struct {
+	db        *gorm.DB
+	batchSize int
+}
+
+// NewReencryptor creates a reencryptor reading batchSize rows at a time; a
+// batchSize of zero or less uses the default
+func NewReencryptor[T domain.Entity](db *gorm.DB, batchSize int) *Reencryptor[T] {
+	if batchSize <= 0 {
+		batchSize = defaultReencryptBatchSize
+	}
+	return &Reencryptor[T]{db: db, batchSize: batchSize}
+}
+
+// Run rewrites every stale row, soft-deleted ones included, and returns the
+// number of rows rewritten. Rows are updated in place without touching their
+// version or update time, and only if their version is unchanged since they
+// were read, so concurrent updates win; such rows are picked up by the next
+// run if they still need it.
+func (r *Reencryptor[T]) Run(ctx context.Context) (int64, error) {
+	return r.run(ctx, true)
+}
+
+// Backfill rewrites, like Run, the rows lacking a blind index or search
+// tokens: those written before encryption was enabled. Until it has run,
+// such rows are kept unique by their plaintext only, so new rows could
+// duplicate them; run it at startup, before serving requests.
+func (r *Reencryptor[T]) Backfill(ctx context.Context) (int64, error) {
+	return r.run(ctx, false)
+}
+
+// run rewrites the rows lacking a blind index or search tokens and, if
+// rotate is set, those not encrypted with the primary key
+func (r *Reencryptor[T]) run(ctx context.Context, rotate bool) (int64, error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return 0, err
+	}
+	var fields []*schema.Field
+	for _, field := range stmt.Schema.Fields {
+		if encryption.IsEncrypted(field) {
+			fields = append(fields, field)
+		}
+	}
+	indexes := encryption.BlindIndexes(stmt.Schema)
+	tokens, _ := encryption.SearchTokensField(stmt.Schema)
+
+	keyring := encryption.Keyring()
+	var conditions []string
+	var args []any
+	if rotate {
+		for _, field := range fields {
+			conditions = append(conditions, fmt.Sprintf(`%s NOT LIKE ? ESCAPE '\'`, stmt.Quote(field.DBName)))
+			args = append(args, escapeLike(keyring.PrimaryPrefix())+"%")
+		}
+	}
+	for index := range indexes {
+		conditions = append(conditions, stmt.Quote(index.DBName)+" IS NULL")
+	}
+	if tokens != nil {
+		conditions = append(conditions, stmt.Quote(tokens.DBName)+" IS NULL")
+	}
+	if len(conditions) == 0 {
+		return 0, nil
+	}
+	stale := strings.Join(conditions, " OR ")
+
+	var rewritten int64
+	var after uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return rewritten, err
+		}
+
+		var batch []T
+		if err := r.db.WithContext(ctx).
+			Unscoped().
+			Where("id > ?", after).
+			Where(stale, args...).
+			Order("id").
+			Limit(r.batchSize).
+			Find(&batch).Error; err != nil {
+			return rewritten, fmt.Errorf("failed to read rows to re-encrypt: %w", err)
+		}
+
+		for i := range batch {
+			updated, err := r.rewrite(ctx, stmt, fields, indexes, &batch[i])
+			if err != nil {
+				return rewritten, err
+			}
+			if updated {
+				rewritten++
+			}
+		}
+		if len(batch) < r.batchSize {
+			return rewritten, nil
+		}
+		after = batch[len(batch)-1].GetID()
+	}
+}
+
+// rewrite encrypts the fields of entity with the primary key and updates
+// its row if it has not changed since it was read
+func (r *Reencryptor[T]) rewrite(ctx context.Context, stmt *gorm.Statement, fields []*schema.Field, indexes map[*schema.Field]*schema.Field, entity *T) (bool, error) {
+	keyring := encryption.Keyring()
+	value := reflect.ValueOf(entity).Elem()
+	updates := make(map[string]any, len(fields)+len(indexes))
+	for _, field := range fields {
+		encrypted, err := keyring.Encrypt(encryption.Plaintext(field.ReflectValueOf(ctx, value)))
+		if err != nil {
+			return false, err
+		}
+		updates[field.DBName] = encrypted
+	}
+	for index, source := range indexes {
+		updates[index.DBName] = keyring.BlindIndex(source.ReflectValueOf(ctx, value).String())
+	}
+	if tokens, sources := encryption.SearchTokensField(stmt.Schema); tokens != nil {
+		updates[tokens.DBName] = encryption.SearchTokensValue(ctx, sources, value)
+	}
+
+	// Without a model GORM runs no hooks, serializers or audit callbacks
+	query := r.db.WithContext(ctx).Table(stmt.Schema.Table).Where("id = ?", (*entity).GetID())
+	if base := domain.BaseOf(entity); base != nil {
+		query = query.Where("version = ?", base.Version)
+	}
+	result := query.UpdateColumns(updates)
+	if result.Error != nil {
+		logger.Error("Failed to re-encrypt row",
+			zap.String("table", stmt.Schema.Table),
+			zap.Uint("id", (*entity).GetID()),
+			zap.Error(result.Error),
+		)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// replicaPingTimeout bounds each replica health check
+const replicaPingTimeout = 2 * time.Second
+
+// replica is one read-only database connection with its health state
+type replica struct {
+	index   int
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// ReplicaSet routes reads across read replicas, skipping replicas whose
+// health check is failing
+type ReplicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// NewReplicaSet creates a replica set from open replica connections, all
+// initially considered healthy
+func NewReplicaSet(dbs ...*gorm.DB) *ReplicaSet {
+	rs := &ReplicaSet{}
+	for i, db := range dbs {
+		r := &replica{index: i, db: db}
+		r.healthy.Store(true)
+		rs.replicas = append(rs.replicas, r)
+	}
+	return rs
+}
+
+// ConnectReplicas opens a connection to each replica URL using the pool
+// settings of cfg. Migrations never run against replicas, and neither does
+// the circuit breaker: unhealthy replicas are already skipped by Reader.
+func ConnectReplicas(cfg Config, urls []string) (*ReplicaSet, error) {
+	dbs := make([]*gorm.DB, 0, len(urls))
+	for i, url := range urls {
+		replicaCfg := cfg
+		replicaCfg.URL = url
+		replicaCfg.Migrate = false
+		replicaCfg.AutoMigrate = false
+		replicaCfg.Breaker = nil
+
+		db, err := Connect(replicaCfg)
+		if err != nil {
+			for _, opened := range dbs {
+				Close(opened)
+			}
+			return nil, fmt.Errorf("failed to connect to replica %d: %w", i, err)
+		}
+		dbs = append(dbs, db)
+	}
+	return NewReplicaSet(dbs...), nil
+}
+
+// Reader returns a healthy replica in round-robin order, or nil if none is
+// healthy and reads must fall back to the primary
+func (rs *ReplicaSet) Reader() *gorm.DB {
+	n := len(rs.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := rs.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := rs.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+// Healthy returns the number of replicas currently passing health checks
+func (rs *ReplicaSet) Healthy() int {
+	healthy := 0
+	for _, r := range rs.replicas {
+		if r.healthy.Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+// Size returns the number of configured replicas
+func (rs *ReplicaSet) Size() int {
+	return len(rs.replicas)
+}
+
+// Start pings every replica at the given interval until ctx is cancelled,
+// taking failing replicas out of rotation and returning recovered ones
+func (rs *ReplicaSet) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				rs.check(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// check pings every replica once and records the result
+func (rs *ReplicaSet) check(ctx context.Context) {
+	for _, r := range rs.replicas {
+		err := ping(ctx, r.db, replicaPingTimeout)
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.Info("Database replica recovered", zap.Int("replica", r.index))
+			} else {
+				logger.Warn("Database replica unhealthy, routing reads elsewhere", zap.Int("replica", r.index), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Close closes every replica connection
+func (rs *ReplicaSet) Close() {
+	for _, r := range rs.replicas {
+		Close(r.db)
+	}
+}
+
+// ping checks a connection with a bounded timeout
+func ping(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+type primaryKeyType struct{}
+
+type readYourWritesKeyType struct{}
+
+// WithPrimary returns a context whose reads always go to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKeyType{}, true)
+}
+
+// WithReadYourWrites returns a context whose reads switch to the primary as
+// soon as a write has been made with it, so a request sees its own changes
+// despite replication lag
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKeyType{}, new(atomic.Bool))
+}
+
+// markWrite records that a write was made with ctx
+func markWrite(ctx context.Context) {
+	if wrote, ok := ctx.Value(readYourWritesKeyType{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+}
+
+// usePrimary reports whether reads made with ctx must go to the primary
+func usePrimary(ctx context.Context) bool {
+	if forced, _ := ctx.Value(primaryKeyType{}).(bool); forced {
+		return true
+	}
+	wrote, ok := ctx.Value(readYourWritesKeyType{}).(*atomic.Bool)
+	return ok && wrote.Load()
+}
@@ -0,0 +1,128 @@
+package database_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/resilience"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{driver.ErrBadConn, true},
+		{gorm.ErrRecordNotFound, false},
+		{context.Canceled, false},
+	} {
+		if got := database.IsRetryable(tc.err); got != tc.retryable {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.retryable)
+		}
+	}
+}
+
+func TestIsTransactionRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		retryable bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{&pgconn.PgError{Code: "08006"}, false},
+		{fmt.Errorf("commit: %w", syscall.ECONNRESET), false},
+		{driver.ErrBadConn, false},
+		{errors.New("database is locked"), false},
+		{context.DeadlineExceeded, false},
+	} {
+		if got := database.IsTransactionRetryable(tc.err); got != tc.retryable {
+			t.Errorf("IsTransactionRetryable(%v) = %v, want %v", tc.err, got, tc.retryable)
+		}
+	}
+}
+
+func TestTransactionRetryRestoresEntities(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	repo := database.NewGormRepository[models.User](db)
+	serialization := &pgconn.PgError{Code: "40001"}
+	txManager := database.NewTransactionManager(db, database.WithTransactionRetry(resilience.RetryPolicy{
+		Attempts: 3,
+		Backoff:  resilience.Backoff{Initial: time.Millisecond},
+	}))
+
+	existing := &models.User{Email: "existing@example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, existing); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	user := &models.User{Email: "retry@example.com", PasswordHash: "hash"}
+	attempts := 0
+	err := txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		attempts++
+		if user.ID != 0 || existing.Version != 1 {
+			t.Errorf("attempt %d: expected entities to be restored, got ID %d and version %d", attempts, user.ID, existing.Version)
+		}
+		if err := repo.Create(ctx, user); err != nil {
+			return err
+		}
+		existing.FirstName = "Updated"
+		if err := repo.Update(ctx, existing); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return fmt.Errorf("commit: %w", serialization)
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("expected success on the second attempt, got %v after %d attempts", err, attempts)
+	}
+	if _, total, _ := repo.List(ctx, 1, 10); total != 2 || existing.Version != 2 {
+		t.Fatalf("expected 2 users and version 2, got %d users and version %d", total, existing.Version)
+	}
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	repo := database.NewGormRepository[models.User](db)
+
+	// Rejected statements do not count against the database
+	breaker := database.NewCircuitBreaker("database", resilience.WithFailureThreshold(1))
+	if err := database.RegisterCircuitBreaker(db, breaker); err != nil {
+		t.Fatalf("RegisterCircuitBreaker: %v", err)
+	}
+	if err := db.Exec("SELECT * FROM missing_table").Error; err == nil {
+		t.Fatal("expected the statement to fail")
+	}
+	if breaker.State() != resilience.StateClosed {
+		t.Fatalf("expected a rejected statement to keep the breaker closed, got %s", breaker.State())
+	}
+
+	// Open the breaker as connection failures would
+	for range 5 {
+		_ = breaker.Execute(func() error { return syscall.ECONNREFUSED })
+	}
+	if _, err := repo.FindByID(ctx, 1); !errors.Is(err, apperrs.ErrServiceUnavailable) {
+		t.Fatalf("expected an open breaker to fail fast, got %v", err)
+	}
+	if err := breaker.HealthCheck(ctx); err == nil {
+		t.Fatal("expected the breaker health check to fail")
+	}
+}
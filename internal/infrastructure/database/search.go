@@ -0,0 +1,501 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// maxSearchTerms bounds the number of words of a query that are matched
+const maxSearchTerms = 8
+
+// Searching encrypted columns through their search tokens: words shorter
+// than minTokenWord are ignored, at most maxSearchCandidates rows sharing
+// tokens with the query are checked against their decrypted values, and a
+// misspelled word matches a word whose trigram similarity to it is at least
+// similarityThreshold, as with the default of pg_trgm
+const (
+	minTokenWord        = 2
+	maxSearchCandidates = 500
+	similarityThreshold = 0.3
+)
+
+// searchHit is the ID and score of a matched row
+type searchHit struct {
+	ID    uint
+	Score float64
+}
+
+// candidateHit is a row that may match through its search tokens: Matched
+// is set when it matches otherwise, and Overlap counts the tokens it shares
+// with the query
+type candidateHit struct {
+	ID      uint
+	Score   float64
+	Matched bool
+	Overlap int
+}
+
+// Search finds entities matching query in the columns configured with
+// WithSearchFields, best matches first.
+//
+// On Postgres rows match when the full-text document of the columns
+// contains every word of the query as a prefix, or when a column is
+// trigram-similar to the query (pg_trgm), which tolerates typos; the score
+// combines ts_rank and the best similarity. Other dialects match rows
+// containing every word in some column with LIKE and score exact prefixes
+// above substrings.
+//
+// Encrypted columns cannot be searched by content. Those with a blind index
+// match when the whole query equals their value, case-insensitively. Those
+// covered by a search tokens field are searched by trigram: the rows sharing
+// the most tokens with the query words of at least minTokenWord characters
+// are decrypted, and match when every such word is a word of theirs, the
+// start of one or a misspelling of one, scored in that order. Other
+// encrypted columns are skipped. A query without such a word is rejected
+// when only encrypted columns are searched.
+func (r *GormRepository[T]) Search(ctx context.Context, query string, page, pageSize int) ([]ports.SearchResult[T], int64, error) {
+	if len(r.searchFields) == 0 {
+		return nil, 0, fmt.Errorf("repository is not searchable: %w", apperrs.ErrBadRequest)
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, 0, fmt.Errorf("search query is empty: %w", apperrs.ErrInvalidInput)
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+
+	var columns, indexColumns []string
+	var tokenColumn string
+	var tokenSources []*schema.Field
+	for _, field := range r.searchFields {
+		stmt, schemaField, err := r.lookUp(field)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !encryption.IsEncrypted(schemaField) {
+			columns = append(columns, stmt.Quote(schemaField.DBName))
+			continue
+		}
+		if index := encryption.BlindIndexOf(stmt.Schema, schemaField); index != nil {
+			indexColumns = append(indexColumns, stmt.Quote(index.DBName))
+		}
+		if tokens := encryption.SearchTokensOf(stmt.Schema, schemaField); tokens != nil {
+			tokenColumn = stmt.Quote(tokens.DBName)
+			_, tokenSources = encryption.SearchTokensField(stmt.Schema)
+		}
+	}
+	if len(columns) == 0 && len(indexColumns) == 0 && tokenColumn == "" {
+		return nil, 0, fmt.Errorf("repository has no searchable fields: %w", apperrs.ErrBadRequest)
+	}
+
+	var words []string
+	if tokenColumn != "" {
+		words = tokenWords(terms)
+		if len(words) == 0 && len(columns) == 0 {
+			return nil, 0, fmt.Errorf("search words must have at least %d characters: %w", minTokenWord, apperrs.ErrInvalidInput)
+		}
+	}
+
+	var match, score string
+	var matchArgs, scoreArgs []any
+	switch {
+	case len(columns) == 0:
+		match, score = "1 = 0", "0"
+	case r.db.Dialector.Name() == "postgres":
+		match, matchArgs, score, scoreArgs = postgresSearch(columns, terms, query)
+	default:
+		match, matchArgs, score, scoreArgs = likeSearch(columns, terms)
+	}
+	if len(indexColumns) > 0 {
+		exact, exactArgs := exactSearch(indexColumns, encryption.BlindIndex(query))
+		match = "(" + match + " OR " + exact + ")"
+		matchArgs = append(matchArgs, exactArgs...)
+		score = fmt.Sprintf("%s + CASE WHEN %s THEN 1 ELSE 0 END", score, exact)
+		scoreArgs = append(scoreArgs, exactArgs...)
+	}
+	if len(words) > 0 {
+		return r.searchTokens(ctx, terms, words, tokenColumn, tokenSources, match, matchArgs, score, scoreArgs, page, pageSize)
+	}
+
+	var total int64
+	if err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).Model(new(T)).Where(match, matchArgs...).Count(&total).Error
+	}); err != nil {
+		logger.Error("Failed to count search results", zap.Error(err))
+		return nil, 0, err
+	}
+
+	var hits []searchHit
+	if err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).
+			Model(new(T)).
+			Select("id, "+score+" AS score", scoreArgs...).
+			Where(match, matchArgs...).
+			Order("score DESC, id").
+			Offset((page - 1) * pageSize).
+			Limit(pageSize).
+			Scan(&hits).Error
+	}); err != nil {
+		logger.Error("Failed to search entities", zap.Error(err))
+		return nil, 0, err
+	}
+	if len(hits) == 0 {
+		return []ports.SearchResult[T]{}, total, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	byID, err := r.loadSearchHits(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	results, err := r.searchResults(ctx, hits, byID, terms)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// searchTokens runs a search covering a search tokens column. Rows matching
+// match, or sharing tokens with words, are candidates; the best of them are
+// decrypted and kept if they match match or if fuzzyScore accepts the
+// values of sources. Candidates are ranked and paginated in memory, so the
+// total counts the matches among the candidates only.
+func (r *GormRepository[T]) searchTokens(ctx context.Context, terms, words []string, column string, sources []*schema.Field, match string, matchArgs []any, score string, scoreArgs []any, page, pageSize int) ([]ports.SearchResult[T], int64, error) {
+	tokenMatch, tokenMatchArgs, overlap, overlapArgs := tokenSearch(column, words)
+
+	selectArgs := append(append(append([]any{}, scoreArgs...), matchArgs...), overlapArgs...)
+	var candidates []candidateHit
+	if err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).
+			Model(new(T)).
+			Select(fmt.Sprintf("id, %s AS score, CASE WHEN %s THEN 1 ELSE 0 END AS matched, %s AS overlap", score, match, overlap), selectArgs...).
+			Where("("+match+" OR "+tokenMatch+")", append(append([]any{}, matchArgs...), tokenMatchArgs...)...).
+			Order("matched DESC, score DESC, overlap DESC, id").
+			Limit(maxSearchCandidates).
+			Scan(&candidates).Error
+	}); err != nil {
+		logger.Error("Failed to search entities", zap.Error(err))
+		return nil, 0, err
+	}
+	if len(candidates) == 0 {
+		return []ports.SearchResult[T]{}, 0, nil
+	}
+
+	ids := make([]uint, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ID
+	}
+	byID, err := r.loadSearchHits(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]searchHit, 0, len(candidates))
+	for _, candidate := range candidates {
+		entity, ok := byID[candidate.ID]
+		if !ok {
+			continue
+		}
+		value := reflect.ValueOf(&entity).Elem()
+		values := make([]string, 0, len(sources))
+		for _, source := range sources {
+			values = append(values, fmt.Sprint(source.ReflectValueOf(ctx, value).Interface()))
+		}
+		fuzzy := fuzzyScore(words, values)
+		if !candidate.Matched && fuzzy == 0 {
+			continue
+		}
+		hits = append(hits, searchHit{ID: candidate.ID, Score: candidate.Score + fuzzy})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	total := int64(len(hits))
+	from := min((page-1)*pageSize, len(hits))
+	to := min(from+pageSize, len(hits))
+	results, err := r.searchResults(ctx, hits[from:to], byID, terms)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// loadSearchHits loads the entities with the given IDs by ID
+func (r *GormRepository[T]) loadSearchHits(ctx context.Context, ids []uint) (map[uint]T, error) {
+	var entities []T
+	if err := r.idempotent(ctx, func() error {
+		return r.reader(ctx).Where("id IN ?", ids).Find(&entities).Error
+	}); err != nil {
+		logger.Error("Failed to load search results", zap.Error(err))
+		return nil, err
+	}
+	byID := make(map[uint]T, len(entities))
+	for _, entity := range entities {
+		byID[entity.GetID()] = entity
+	}
+	return byID, nil
+}
+
+// searchResults returns the results of hits, in order, with the matches of
+// terms highlighted in their search fields
+func (r *GormRepository[T]) searchResults(ctx context.Context, hits []searchHit, byID map[uint]T, terms []string) ([]ports.SearchResult[T], error) {
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	pattern := highlightPattern(terms)
+
+	results := make([]ports.SearchResult[T], 0, len(hits))
+	for _, hit := range hits {
+		entity, ok := byID[hit.ID]
+		if !ok {
+			// Deleted between the two queries
+			continue
+		}
+
+		highlights := make(map[string]string)
+		for _, field := range r.searchFields {
+			schemaField := stmt.Schema.LookUpField(field)
+			// The struct value, as ValueOf wraps serialized fields
+			value := schemaField.ReflectValueOf(ctx, reflect.ValueOf(&entity).Elem()).Interface()
+			if marked, ok := highlight(fmt.Sprint(value), pattern); ok {
+				highlights[schemaField.DBName] = marked
+			}
+		}
+
+		results = append(results, ports.SearchResult[T]{
+			Entity:     entity,
+			Score:      hit.Score,
+			Highlights: highlights,
+		})
+	}
+	return results, nil
+}
+
+// postgresSearch builds the full-text and trigram match condition and score.
+// The document expression must stay identical to the one indexed by the
+// migrations for the index to be used.
+func postgresSearch(columns, terms []string, query string) (string, []any, string, []any) {
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = fmt.Sprintf("coalesce(%s, '')", column)
+	}
+	document := fmt.Sprintf("to_tsvector('simple', %s)", strings.Join(parts, " || ' ' || "))
+
+	// to_tsquery has its own syntax, so only letters and digits are passed
+	var lexemes []string
+	for _, term := range terms {
+		for _, word := range strings.FieldsFunc(term, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			lexemes = append(lexemes, word+":*")
+		}
+	}
+
+	var conditions, similarities []string
+	var matchArgs, scoreArgs []any
+	rank := "0"
+	if len(lexemes) > 0 {
+		tsquery := strings.Join(lexemes, " & ")
+		conditions = append(conditions, document+" @@ to_tsquery('simple', ?)")
+		matchArgs = append(matchArgs, tsquery)
+		rank = fmt.Sprintf("ts_rank(%s, to_tsquery('simple', ?))", document)
+		scoreArgs = append(scoreArgs, tsquery)
+	}
+	for _, column := range columns {
+		conditions = append(conditions, column+" % ?")
+		matchArgs = append(matchArgs, query)
+		similarities = append(similarities, fmt.Sprintf("similarity(%s, ?)", column))
+		scoreArgs = append(scoreArgs, query)
+	}
+
+	match := "(" + strings.Join(conditions, " OR ") + ")"
+	score := fmt.Sprintf("%s + GREATEST(%s)", rank, strings.Join(similarities, ", "))
+	return match, matchArgs, score, scoreArgs
+}
+
+// likeSearch builds a portable condition requiring every term to occur in
+// some column, scored 2 per column starting with a term and 1 per column
+// merely containing it
+func likeSearch(columns, terms []string) (string, []any, string, []any) {
+	var conditions, scores []string
+	var matchArgs, scoreArgs []any
+	for _, term := range terms {
+		escaped := escapeLike(term)
+		var alternatives []string
+		for _, column := range columns {
+			alternatives = append(alternatives, fmt.Sprintf(`LOWER(%s) LIKE ? ESCAPE '\'`, column))
+			matchArgs = append(matchArgs, "%"+escaped+"%")
+			scores = append(scores, fmt.Sprintf(`CASE WHEN LOWER(%[1]s) LIKE ? ESCAPE '\' THEN 2 WHEN LOWER(%[1]s) LIKE ? ESCAPE '\' THEN 1 ELSE 0 END`, column))
+			scoreArgs = append(scoreArgs, escaped+"%", "%"+escaped+"%")
+		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+	}
+	return strings.Join(conditions, " AND "), matchArgs, "(" + strings.Join(scores, " + ") + ")", scoreArgs
+}
+
+// tokenWords returns the words of terms that search tokens can match
+func tokenWords(terms []string) []string {
+	var words []string
+	for _, term := range terms {
+		for _, word := range fieldcrypt.Words(term) {
+			if len([]rune(word)) >= minTokenWord {
+				words = append(words, word)
+			}
+		}
+	}
+	return words
+}
+
+// tokenSearch builds the condition selecting the rows whose search tokens
+// column holds a token of the trigrams of words, and the number of those
+// tokens a row holds. Tokens are short and shared by unrelated trigrams, so
+// rows selected this way are only candidates.
+func tokenSearch(column string, words []string) (string, []any, string, []any) {
+	keyring := encryption.Keyring()
+	seen := make(map[string]bool)
+	var conditions, overlaps []string
+	var args []any
+	for _, word := range words {
+		for _, token := range keyring.WordTokens(word) {
+			if seen[token] {
+				continue
+			}
+			seen[token] = true
+			conditions = append(conditions, column+" LIKE ?")
+			overlaps = append(overlaps, fmt.Sprintf("CASE WHEN %s LIKE ? THEN 1 ELSE 0 END", column))
+			args = append(args, "% "+token+" %")
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, "(" + strings.Join(overlaps, " + ") + ")", args
+}
+
+// fuzzyScore scores how well words match the words of values: each scores
+// 1 if it is one of them, 0.75 if it starts one and, if it is a misspelling
+// of one, half their trigram similarity. It returns 0 unless every word
+// matches.
+func fuzzyScore(words []string, values []string) float64 {
+	var candidates []string
+	for _, value := range values {
+		candidates = append(candidates, fieldcrypt.Words(value)...)
+	}
+
+	var total float64
+	for _, word := range words {
+		var best float64
+		for _, candidate := range candidates {
+			var score float64
+			switch {
+			case candidate == word:
+				score = 1
+			case strings.HasPrefix(candidate, word):
+				score = 0.75
+			default:
+				if similarity := trigramSimilarity(word, candidate); similarity >= similarityThreshold {
+					score = similarity / 2
+				}
+			}
+			best = max(best, score)
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// trigramSimilarity returns the share of the trigrams of a and b that both
+// have, as pg_trgm's similarity does
+func trigramSimilarity(a, b string) float64 {
+	trigrams := make(map[string]bool)
+	for _, trigram := range fieldcrypt.Trigrams(a) {
+		trigrams[trigram] = true
+	}
+	shared, union := 0, len(trigrams)
+	for _, trigram := range fieldcrypt.Trigrams(b) {
+		if trigrams[trigram] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// exactSearch builds the condition matching rows with one of the blind index
+// columns equal to index
+func exactSearch(indexColumns []string, index string) (string, []any) {
+	conditions := make([]string, len(indexColumns))
+	args := make([]any, len(indexColumns))
+	for i, column := range indexColumns {
+		conditions[i] = column + " = ?"
+		args[i] = index
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// highlightPattern matches any of the terms case-insensitively, longest first
+func highlightPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	sort.Slice(quoted, func(i, j int) bool {
+		return len(quoted[i]) > len(quoted[j])
+	})
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// highlight HTML-escapes value and wraps the matches of pattern in <mark>
+// tags; it reports whether anything matched
+func highlight(value string, pattern *regexp.Regexp) (string, bool) {
+	matches := pattern.FindAllStringIndex(value, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(html.EscapeString(value[last:m[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(value[m[0]:m[1]]))
+		b.WriteString("</mark>")
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(value[last:]))
+	return b.String(), true
+}
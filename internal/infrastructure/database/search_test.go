@@ -0,0 +1,161 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// contact is a model stored in plaintext, whose columns can be searched by
+// content
+type contact struct {
+	domain.BaseEntity
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+func TestGormRepositorySearch(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	if err := db.AutoMigrate(&contact{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	repo := database.NewGormRepository[contact](db, database.WithSearchFields("email", "first_name", "last_name"))
+
+	users := []*contact{
+		{Email: "grace@example.com", FirstName: "Grace", LastName: "Hopper"},
+		{Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"},
+		{Email: "barbara@example.com", FirstName: "Barbara", LastName: "Adams"},
+		{Email: "deleted.ada@example.com", FirstName: "Ada", LastName: "Deleted"},
+		{Email: "percent@example.com", FirstName: "100%", LastName: "Sure"},
+	}
+	for _, user := range users {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, users[3].ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	results, total, err := repo.Search(ctx, "ADA", 1, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("got %d results (total %d), want 2", len(results), total)
+	}
+	if results[0].Entity.ID != users[1].ID || results[1].Entity.ID != users[2].ID {
+		t.Fatalf("got users %d, %d; want Ada ranked above Adams", results[0].Entity.ID, results[1].Entity.ID)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Fatalf("scores %v and %v are not descending", results[0].Score, results[1].Score)
+	}
+	if got := results[0].Highlights["first_name"]; got != "<mark>Ada</mark>" {
+		t.Fatalf("first_name highlight = %q", got)
+	}
+	if _, ok := results[0].Highlights["last_name"]; ok {
+		t.Fatalf("unexpected highlight of a non-matching field: %v", results[0].Highlights)
+	}
+
+	// Every word must match
+	if _, total, _ := repo.Search(ctx, "ada hopper", 1, 10); total != 0 {
+		t.Fatalf("got %d results for words matching different users, want 0", total)
+	}
+
+	// LIKE wildcards in the query are literal
+	results, total, err = repo.Search(ctx, "0%", 1, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 1 || results[0].Entity.ID != users[4].ID {
+		t.Fatalf("got %d results for a literal %%, want the user named 100%%", total)
+	}
+}
+
+func TestGormRepositorySearchEncryptedFields(t *testing.T) {
+	ctx := context.Background()
+	repo := database.NewGormRepository[models.User](connect(t), database.WithSearchFields("email", "first_name", "last_name"))
+
+	for _, user := range []*models.User{
+		{Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"},
+		{Email: "grace@example.com", FirstName: "Grace", LastName: "Hopper"},
+	} {
+		user.PasswordHash = "hash"
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	// The encrypted email matches as a whole through its blind index
+	results, total, err := repo.Search(ctx, "Ada@Example.com", 1, 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 1 || results[0].Entity.Email != "ada@example.com" {
+		t.Fatalf("got %d results for an exact email, want ada", total)
+	}
+	if got := results[0].Highlights["email"]; got != "<mark>ada@example.com</mark>" {
+		t.Fatalf("email highlight = %q", got)
+	}
+
+	// Words of the email and names match whole, by their start or misspelled,
+	// through their search tokens
+	for query, want := range map[string]string{
+		"ada":          "ada@example.com",
+		"LOVEL":        "ada@example.com",
+		"lovelase":     "ada@example.com",
+		"hopper grace": "grace@example.com",
+		"hoper":        "grace@example.com",
+		"gr@example":   "grace@example.com",
+	} {
+		results, total, err := repo.Search(ctx, query, 1, 10)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", query, err)
+		}
+		if total != 1 || results[0].Entity.Email != want {
+			t.Fatalf("got %d results for %q, want %s", total, query, want)
+		}
+	}
+	for _, query := range []string{"ada hopper", "turing", "xyz"} {
+		if _, total, _ := repo.Search(ctx, query, 1, 10); total != 0 {
+			t.Fatalf("got %d results for %q, want 0", total, query)
+		}
+	}
+
+	// Whole words rank above starts of words, and those above misspellings
+	for _, user := range []*models.User{
+		{Email: "adam@example.com", FirstName: "Adam", LastName: "Smith"},
+		{Email: "edna@example.com", FirstName: "Edna", LastName: "Lovelock"},
+	} {
+		user.PasswordHash = "hash"
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	results, total, err = repo.Search(ctx, "ada", 1, 10)
+	if err != nil || total != 2 || results[0].Entity.Email != "ada@example.com" || results[0].Score <= results[1].Score {
+		t.Fatalf("got %d results (%v) for ada, want Ada ranked above Adam", total, err)
+	}
+	results, total, err = repo.Search(ctx, "lovelace", 1, 10)
+	if err != nil || total != 2 || results[0].Entity.Email != "ada@example.com" || results[1].Entity.Email != "edna@example.com" {
+		t.Fatalf("got %d results (%v) for lovelace, want Lovelace ranked above Lovelock", total, err)
+	}
+
+	// Pages are cut from the ranked matches
+	results, total, err = repo.Search(ctx, "lovelace", 2, 1)
+	if err != nil || total != 2 || len(results) != 1 || results[0].Entity.Email != "edna@example.com" {
+		t.Fatalf("got %d results of %d (%v) on the second page, want Lovelock", len(results), total, err)
+	}
+
+	// Queries without a word long enough to have tokens are rejected
+	if _, _, err := repo.Search(ctx, "a", 1, 10); !errors.Is(err, apperrs.ErrInvalidInput) {
+		t.Fatalf("Search(a): got %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,197 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// managedFields are maintained by the database and cannot be seeded
+var managedFields = []string{"id", "created_at", "updated_at", "deleted_at", "version"}
+
+// EntitySeeder upserts entities of type T matched by a key field
+type EntitySeeder[T domain.Entity] struct {
+	entity  string
+	key     string
+	prepare func(entity *T, record Record) error
+}
+
+// NewEntitySeeder creates a seeder for the fixture key entity. Records are
+// matched with existing rows, including soft-deleted ones, on the key
+// field. Record fields are decoded onto the entity with its JSON field
+// names; prepare, if not nil, then handles fields the JSON representation
+// does not carry, such as secrets.
+func NewEntitySeeder[T domain.Entity](entity, key string, prepare func(entity *T, record Record) error) *EntitySeeder[T] {
+	return &EntitySeeder[T]{
+		entity:  entity,
+		key:     key,
+		prepare: prepare,
+	}
+}
+
+// UserSeeder seeds users matched by email. A record's password is hashed;
+// the stored hash is kept when it already matches.
+func UserSeeder() *EntitySeeder[models.User] {
+	return NewEntitySeeder("users", "email", func(user *models.User, record Record) error {
+		password, ok := record["password"].(string)
+		if !ok || password == "" {
+			if user.PasswordHash == "" {
+				return errors.New("password is required")
+			}
+			return nil
+		}
+		if user.PasswordHash != "" && user.CheckPassword(password) {
+			return nil
+		}
+		return user.SetPassword(password)
+	})
+}
+
+// Entity returns the fixture key handled by the seeder
+func (s *EntitySeeder[T]) Entity() string {
+	return s.entity
+}
+
+// Seed creates entities without a matching row and updates rows whose
+// seeded fields differ from the record. Fields absent from a record keep
+// their stored value; soft-deleted rows are restored.
+func (s *EntitySeeder[T]) Seed(ctx context.Context, db *gorm.DB, records []Record) (Result, error) {
+	var result Result
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return result, err
+	}
+	keyField := stmt.Schema.LookUpField(s.key)
+	if keyField == nil {
+		return result, fmt.Errorf("unknown key field %q", s.key)
+	}
+	// Encrypted keys are matched on their blind index, or on their plaintext
+	// in rows written before encryption that have no index yet
+	keyCondition := stmt.Quote(keyField.DBName) + " = ?"
+	keyIndex := encryption.BlindIndexOf(stmt.Schema, keyField)
+	if keyIndex != nil {
+		keyCondition = fmt.Sprintf("(%[1]s = ? OR (%[1]s IS NULL AND %[2]s = ?))", stmt.Quote(keyIndex.DBName), stmt.Quote(keyField.DBName))
+	}
+
+	for i, record := range records {
+		for _, field := range managedFields {
+			if _, ok := record[field]; ok {
+				return result, fmt.Errorf("record %d: %s cannot be seeded", i, field)
+			}
+		}
+		key, ok := record[s.key]
+		if !ok {
+			return result, fmt.Errorf("record %d: missing %s", i, s.key)
+		}
+
+		keyArgs := []any{key}
+		if keyIndex != nil {
+			keyArgs = []any{encryption.BlindIndex(fmt.Sprint(key)), key}
+		}
+
+		query := db.WithContext(ctx).Unscoped().Where(keyCondition, keyArgs...)
+		if stmt.Schema.LookUpField("deleted_at") != nil {
+			// Deleted rows may share their key with a live one, which wins
+			query = query.Order("deleted_at IS NOT NULL")
+		}
+		var matches []T
+		err := query.Limit(1).Find(&matches).Error
+		if err != nil {
+			return result, err
+		}
+		found := len(matches) > 0
+		var existing T
+		if found {
+			existing = matches[0]
+		}
+
+		// Decode onto a copy of the stored entity so that fields absent from
+		// the record are kept
+		entity := existing
+		if err := decode(record, &entity); err != nil {
+			return result, fmt.Errorf("record %d: %w", i, err)
+		}
+		if s.prepare != nil {
+			if err := s.prepare(&entity, record); err != nil {
+				return result, fmt.Errorf("record %d: %w", i, err)
+			}
+		}
+
+		base := domain.BaseOf(&entity)
+		switch {
+		case !found:
+			if base != nil {
+				base.Version = 1
+				if base.PublicID == "" {
+					base.PublicID = domain.NewPublicID()
+				}
+			}
+			// GORM writes a field's default instead of its zero value, so
+			// zero values such as active: false are written afterwards
+			zeros := zeroDefaults(ctx, stmt.Schema, &entity, record)
+			if err := db.WithContext(ctx).Create(&entity).Error; err != nil {
+				return result, fmt.Errorf("record %d: %w", i, err)
+			}
+			if len(zeros) > 0 {
+				if err := db.WithContext(ctx).Model(&entity).UpdateColumns(zeros).Error; err != nil {
+					return result, fmt.Errorf("record %d: %w", i, err)
+				}
+			}
+			result.Created++
+		case base != nil && base.DeletedAt.Valid:
+			base.DeletedAt = gorm.DeletedAt{}
+			fallthrough
+		case !reflect.DeepEqual(entity, existing):
+			if base != nil {
+				base.Version++
+			}
+			if err := db.WithContext(ctx).Unscoped().Save(&entity).Error; err != nil {
+				return result, fmt.Errorf("record %d: %w", i, err)
+			}
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// zeroDefaults returns the fields of entity set to their zero value by
+// record that have a default value, keyed by column
+func zeroDefaults(ctx context.Context, s *schema.Schema, entity any, record Record) map[string]any {
+	value := reflect.ValueOf(entity).Elem()
+	zeros := make(map[string]any)
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.PrimaryKey || field.DefaultValueInterface == nil {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if _, ok := record[name]; !ok {
+			continue
+		}
+		if v, isZero := field.ValueOf(ctx, value); isZero {
+			zeros[field.DBName] = v
+		}
+	}
+	return zeros
+}
+
+// decode sets the fields of entity named in record
+func decode(record Record, entity any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, entity)
+}
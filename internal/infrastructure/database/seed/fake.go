@@ -0,0 +1,104 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fakeBatchSize is the number of fake users inserted per statement
+const fakeBatchSize = 500
+
+var (
+	fakeFirstNames = []string{
+		"James", "Mary", "Robert", "Patricia", "John", "Jennifer", "Michael", "Linda",
+		"David", "Elizabeth", "William", "Barbara", "Richard", "Susan", "Joseph", "Jessica",
+		"Thomas", "Sarah", "Carlos", "Karen", "Wei", "Aiko", "Mohammed", "Fatima",
+		"Olga", "Ivan", "Priya", "Arjun", "Sofia", "Lucas", "Amara", "Kwame",
+		"Chloe", "Mateo", "Yuki", "Hana", "Liam", "Emma", "Noah", "Olivia",
+	}
+	fakeLastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis",
+		"Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor",
+		"Moore", "Jackson", "Martin", "Lee", "Nguyen", "Chen", "Kim", "Patel",
+		"Singh", "Müller", "Schmidt", "Rossi", "Dubois", "Silva", "Ivanova", "Tanaka",
+		"Okafor", "Mensah", "Haddad", "Kowalski", "Novak", "Larsen", "O'Brien", "Walsh",
+	}
+	fakeDomains = []string{"example.com", "example.org", "example.net", "mail.example.com"}
+)
+
+// FakeUsers inserts n users with random but realistic names, spread
+// creation dates and a 10% share of inactive accounts, for load testing.
+// All of them get the same password, hashed once. Users whose generated
+// email already exists are skipped; the number inserted is returned.
+func FakeUsers(ctx context.Context, db *gorm.DB, n int, password string, rng *rand.Rand) (int64, error) {
+	var template models.User
+	if err := template.SetPassword(password); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var inserted int64
+	for start := 0; start < n; start += fakeBatchSize {
+		size := min(fakeBatchSize, n-start)
+		users := make([]models.User, size)
+		var inactive []string
+		for i := range users {
+			first := fakeFirstNames[rng.Intn(len(fakeFirstNames))]
+			last := fakeLastNames[rng.Intn(len(fakeLastNames))]
+			users[i] = models.User{
+				Email:        fakeEmail(first, last, rng),
+				PasswordHash: template.PasswordHash,
+				FirstName:    first,
+				LastName:     last,
+				Role:         "user",
+				Active:       true,
+			}
+			if rng.Intn(10) == 0 {
+				inactive = append(inactive, encryption.BlindIndex(users[i].Email))
+			}
+			users[i].Version = 1
+			users[i].PublicID = domain.NewPublicID()
+			users[i].CreatedAt = now.Add(-time.Duration(rng.Int63n(int64(365 * 24 * time.Hour))))
+			users[i].UpdatedAt = users[i].CreatedAt
+		}
+
+		result := db.WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&users)
+		if result.Error != nil {
+			return inserted, result.Error
+		}
+		inserted += result.RowsAffected
+
+		// Active defaults to true in the schema, so inactive users are
+		// deactivated after the insert; rows updated since their creation
+		// existed before and are left alone
+		if len(inactive) > 0 {
+			err := db.WithContext(ctx).
+				Model(&models.User{}).
+				Where("email_index IN ? AND created_at = updated_at", inactive).
+				UpdateColumn("active", false).Error
+			if err != nil {
+				return inserted, err
+			}
+		}
+	}
+	return inserted, nil
+}
+
+// fakeEmail builds an email address from a name and a random number
+func fakeEmail(first, last string, rng *rand.Rand) string {
+	local := strings.ToLower(first + "." + last)
+	local = strings.NewReplacer("'", "", "ü", "u").Replace(local)
+	return fmt.Sprintf("%s%d@%s", local, rng.Intn(1_000_000), fakeDomains[rng.Intn(len(fakeDomains))])
+}
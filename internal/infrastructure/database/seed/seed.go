@@ -0,0 +1,181 @@
+// Package seed loads fixture data into the database.
+//
+// Fixtures are grouped in seed sets, one directory per set (development,
+// test, demo). Each YAML or JSON file of a set maps entity names to lists of
+// records:
+//
+//	users:
+//	  - email: admin@example.com
+//	    password: change-me
+//	    role: admin
+//
+// Every entity name is handled by a registered Seeder. Seeding is
+// idempotent: records are matched with existing rows by a key field, so
+// running a set again only applies what changed in the fixtures.
+package seed
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+//go:embed fixtures
+var embedded embed.FS
+
+// Record is one fixture record, keyed by the JSON field names of the entity
+type Record map[string]any
+
+// Result counts what a seeder changed
+type Result struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Seeder loads the fixture records of one entity
+type Seeder interface {
+	// Entity returns the fixture key handled by the seeder, e.g. "users"
+	Entity() string
+
+	// Seed creates or updates the entities described by records
+	Seed(ctx context.Context, db *gorm.DB, records []Record) (Result, error)
+}
+
+// Runner loads seed sets with the registered seeders
+type Runner struct {
+	db       *gorm.DB
+	fixtures fs.FS
+	seeders  []Seeder
+}
+
+// NewRunner creates a runner for the seed sets in fixtures, or for the
+// fixtures embedded in the binary if fixtures is nil. The default seeders
+// are registered.
+func NewRunner(db *gorm.DB, fixtures fs.FS) *Runner {
+	if fixtures == nil {
+		fixtures, _ = fs.Sub(embedded, "fixtures")
+	}
+	r := &Runner{
+		db:       db,
+		fixtures: fixtures,
+	}
+	r.Register(UserSeeder())
+	return r
+}
+
+// Register adds seeders; seeders run in registration order, so entities
+// must be registered after those they reference
+func (r *Runner) Register(seeders ...Seeder) {
+	r.seeders = append(r.seeders, seeders...)
+}
+
+// Sets returns the names of the available seed sets
+func (r *Runner) Sets() ([]string, error) {
+	entries, err := fs.ReadDir(r.fixtures, ".")
+	if err != nil {
+		return nil, err
+	}
+	var sets []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sets = append(sets, entry.Name())
+		}
+	}
+	return sets, nil
+}
+
+// Run loads every fixture file of set in a single transaction and returns
+// what changed per entity
+func (r *Runner) Run(ctx context.Context, set string) (map[string]Result, error) {
+	records, err := r.load(set)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]Result)
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, seeder := range r.seeders {
+			entity := seeder.Entity()
+			if len(records[entity]) == 0 {
+				continue
+			}
+			result, err := seeder.Seed(ctx, tx, records[entity])
+			if err != nil {
+				return fmt.Errorf("seeding %s: %w", entity, err)
+			}
+			results[entity] = result
+			logger.Info("Seeded entities",
+				zap.String("set", set),
+				zap.String("entity", entity),
+				zap.Int("created", result.Created),
+				zap.Int("updated", result.Updated),
+				zap.Int("unchanged", result.Unchanged),
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// load reads the fixture files of set, in name order, grouping records by
+// entity
+func (r *Runner) load(set string) (map[string][]Record, error) {
+	entries, err := fs.ReadDir(r.fixtures, set)
+	if err != nil {
+		return nil, fmt.Errorf("unknown seed set %q: %w", set, err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	known := make(map[string]bool, len(r.seeders))
+	for _, seeder := range r.seeders {
+		known[seeder.Entity()] = true
+	}
+
+	records := make(map[string][]Record)
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(path.Ext(name))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		content, err := fs.ReadFile(r.fixtures, path.Join(set, name))
+		if err != nil {
+			return nil, err
+		}
+		var file map[string][]Record
+		if ext == ".json" {
+			err = json.Unmarshal(content, &file)
+		} else {
+			err = yaml.Unmarshal(content, &file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s/%s: %w", set, name, err)
+		}
+
+		for entity, list := range file {
+			if !known[entity] {
+				return nil, fmt.Errorf("%s/%s: no seeder for %q", set, name, entity)
+			}
+			records[entity] = append(records[entity], list...)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,125 @@
+package seed_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"testing/fstest"
+
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/encryption"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/database/seed"
+
+	"gorm.io/gorm"
+)
+
+func connect(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Connect(database.Config{
+		URL:     "sqlite://file::memory:",
+		Migrate: true,
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	return db
+}
+
+func TestRunIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	runner := seed.NewRunner(db, nil)
+
+	for _, set := range []string{"development", "test", "demo"} {
+		if _, err := runner.Run(ctx, set); err != nil {
+			t.Fatalf("Run(%s): %v", set, err)
+		}
+	}
+
+	results, err := runner.Run(ctx, "development")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := results["users"]; got.Created != 0 || got.Updated != 0 || got.Unchanged != 3 {
+		t.Fatalf("second run changed users: %+v", got)
+	}
+
+	var inactive models.User
+	if err := db.Where("email_index = ?", encryption.BlindIndex("inactive@example.com")).First(&inactive).Error; err != nil {
+		t.Fatalf("loading seeded user: %v", err)
+	}
+	if inactive.Active || !inactive.CheckPassword("inactive12345") {
+		t.Fatalf("seeded user has active=%v and an unexpected password", inactive.Active)
+	}
+}
+
+func TestRunUpdatesChangedAndDeletedRecords(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+	fixtures := fstest.MapFS{
+		"dev/users.yaml": {Data: []byte("users:\n  - {email: a@example.com, password: secret123, first_name: Ada}\n")},
+	}
+	runner := seed.NewRunner(db, fixtures)
+	if _, err := runner.Run(ctx, "dev"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var user models.User
+	db.Where("email_index = ?", encryption.BlindIndex("a@example.com")).First(&user)
+	db.Model(&user).Update("last_name", "Lovelace")
+	db.Delete(&user)
+
+	fixtures["dev/users.yaml"].Data = []byte("users:\n  - {email: a@example.com, password: secret123, first_name: Augusta}\n")
+	results, err := runner.Run(ctx, "dev")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if results["users"].Updated != 1 {
+		t.Fatalf("expected one update, got %+v", results["users"])
+	}
+
+	var updated models.User
+	if err := db.Where("email_index = ?", encryption.BlindIndex("a@example.com")).First(&updated).Error; err != nil {
+		t.Fatalf("seeded user was not restored: %v", err)
+	}
+	if updated.FirstName != "Augusta" || updated.LastName != "Lovelace" || updated.Version != 2 {
+		t.Fatalf("got %q %q at version %d, want the fixture applied over the stored row", updated.FirstName, updated.LastName, updated.Version)
+	}
+}
+
+func TestRunRejectsInvalidFixtures(t *testing.T) {
+	ctx := context.Background()
+	for name, data := range map[string]string{
+		"unknown entity": "widgets:\n  - {name: x}\n",
+		"managed field":  "users:\n  - {id: 3, email: a@example.com, password: secret123}\n",
+		"missing key":    "users:\n  - {password: secret123}\n",
+	} {
+		runner := seed.NewRunner(connect(t), fstest.MapFS{"set/data.yaml": {Data: []byte(data)}})
+		if _, err := runner.Run(ctx, "set"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestFakeUsers(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t)
+
+	inserted, err := seed.FakeUsers(ctx, db, 1200, "password", rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatalf("FakeUsers: %v", err)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if inserted == 0 || inserted != count || count > 1200 {
+		t.Fatalf("inserted %d, counted %d", inserted, count)
+	}
+
+	var inactive int64
+	db.Model(&models.User{}).Where("active = ?", false).Count(&inactive)
+	if inactive == 0 {
+		t.Fatalf("expected some inactive fake users")
+	}
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/resilience"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type txKeyType struct{}
+
+// txState tracks one level of a (possibly nested) transaction
+type txState struct {
+	tx     *gorm.DB
+	parent *txState
+
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+	// rollbacks undo in-memory changes made by the transaction, such as IDs
+	// assigned to created entities, when it rolls back
+	rollbacks []func()
+}
+
+// addHook registers a function to run once the outermost transaction commits
+func (s *txState) addHook(fn func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// takeHooks returns and clears the registered hooks
+func (s *txState) takeHooks() []func(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := s.hooks
+	s.hooks = nil
+	return hooks
+}
+
+// addRollback registers a function to run if the transaction rolls back
+func (s *txState) addRollback(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollbacks = append(s.rollbacks, fn)
+}
+
+// takeRollbacks returns and clears the registered rollback functions
+func (s *txState) takeRollbacks() []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rollbacks := s.rollbacks
+	s.rollbacks = nil
+	return rollbacks
+}
+
+// onRollback registers fn to run if the transaction carried by ctx, or any
+// enclosing one, rolls back; outside a transaction it does nothing
+func onRollback(ctx context.Context, fn func()) {
+	if state := txFromContext(ctx); state != nil {
+		state.addRollback(fn)
+	}
+}
+
+// txFromContext returns the transaction state carried by ctx, if any
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKeyType{}).(*txState)
+	return state
+}
+
+// InTransaction reports whether ctx carries an open transaction
+func InTransaction(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
+}
+
+// TransactionManager implements ports.TransactionManager on top of GORM.
+// The transaction is propagated through the context, so every GormRepository
+// method called with that context joins it. Nested calls create savepoints.
+type TransactionManager struct {
+	db    *gorm.DB
+	retry *resilience.RetryPolicy
+}
+
+// TransactionOption configures a TransactionManager
+type TransactionOption func(*TransactionManager)
+
+// WithTransactionRetry runs outermost transactions that fail with a
+// transient error again, as a whole, following policy. A nil
+// policy.Retryable retries the errors accepted by IsTransactionRetryable,
+// which unlike IsRetryable leaves out failures whose outcome is unknown.
+// The function passed to WithTransaction may then run several times, so it
+// must not have side effects outside the transaction other than through
+// AfterCommit; entities created or updated through a GormRepository are
+// restored to their previous state when the transaction rolls back.
+func WithTransactionRetry(policy resilience.RetryPolicy) TransactionOption {
+	return func(m *TransactionManager) {
+		if policy.Retryable == nil {
+			policy.Retryable = IsTransactionRetryable
+		}
+		m.retry = &policy
+	}
+}
+
+// NewTransactionManager creates a new transaction manager
+func NewTransactionManager(db *gorm.DB, opts ...TransactionOption) *TransactionManager {
+	m := &TransactionManager{
+		db: db,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithTransaction executes fn in a transaction. If ctx already carries a
+// transaction, fn runs inside a savepoint that is rolled back on error
+// without aborting the enclosing transaction.
+func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent := txFromContext(ctx)
+	if parent != nil || m.retry == nil {
+		return m.run(ctx, parent, fn)
+	}
+
+	// Only the outermost transaction is retried: once a statement of a
+	// savepoint fails, the enclosing transaction cannot be trusted anyway
+	policy := *m.retry
+	if policy.OnRetry == nil {
+		policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+			logger.Warn("Retrying transaction after transient error",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		}
+	}
+	return resilience.Retry(ctx, policy, func(ctx context.Context) error {
+		return m.run(ctx, nil, fn)
+	})
+}
+
+// run executes fn in one transaction, or savepoint when parent is set
+func (m *TransactionManager) run(ctx context.Context, parent *txState, fn func(ctx context.Context) error) error {
+	db := m.db
+	if parent != nil {
+		db = parent.tx
+	}
+
+	state := &txState{parent: parent}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(ctx, txKeyType{}, state))
+	})
+	if err != nil {
+		// Hooks registered in a rolled back transaction or savepoint are
+		// dropped and the in-memory changes it made are undone, latest first
+		rollbacks := state.takeRollbacks()
+		for i := len(rollbacks) - 1; i >= 0; i-- {
+			rollbacks[i]()
+		}
+		return err
+	}
+
+	if parent != nil {
+		// Savepoint released: hooks wait for the outermost commit, and its
+		// changes are undone if the enclosing transaction rolls back
+		for _, hook := range state.takeHooks() {
+			parent.addHook(hook)
+		}
+		for _, rollback := range state.takeRollbacks() {
+			parent.addRollback(rollback)
+		}
+		return nil
+	}
+
+	runAfterCommitHooks(ctx, state.takeHooks())
+	return nil
+}
+
+// AfterCommit registers fn to run after the transaction carried by ctx
+// commits. Outside a transaction fn runs immediately.
+func (m *TransactionManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	state := txFromContext(ctx)
+	if state == nil {
+		runAfterCommitHooks(ctx, []func(ctx context.Context){fn})
+		return
+	}
+	state.addHook(fn)
+}
+
+// InTransaction reports whether ctx carries an open transaction
+func (m *TransactionManager) InTransaction(ctx context.Context) bool {
+	return InTransaction(ctx)
+}
+
+// runAfterCommitHooks runs hooks in registration order, isolating panics
+func runAfterCommitHooks(ctx context.Context, hooks []func(ctx context.Context)) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("After-commit hook panicked", zap.Any("panic", r))
+				}
+			}()
+			hook(ctx)
+		}()
+	}
+}
@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"go-server-boilerplate/internal/pkg/logger"
@@ -91,6 +92,28 @@ func (d *Dispatcher) Stop(ctx context.Context) {
 	d.isRunning = false
 }
 
+// QueueDepth returns the number of jobs waiting for a worker
+func (d *Dispatcher) QueueDepth() int {
+	return len(d.jobQueue)
+}
+
+// HealthCheck fails when the dispatcher is not running or its queue is at
+// least 90% full, in which case dispatching will soon block callers
+func (d *Dispatcher) HealthCheck(ctx context.Context) error {
+	d.mu.Lock()
+	running := d.isRunning
+	d.mu.Unlock()
+	if !running {
+		return fmt.Errorf("job dispatcher is not running")
+	}
+
+	depth, capacity := len(d.jobQueue), cap(d.jobQueue)
+	if depth*10 >= capacity*9 {
+		return fmt.Errorf("job queue is %d/%d full", depth, capacity)
+	}
+	return nil
+}
+
 // DispatchJob dispatches a job to be processed by a worker
 func (d *Dispatcher) DispatchJob(job Job) {
 	if !d.isRunning {
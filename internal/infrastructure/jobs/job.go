@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// funcJob is a job backed by a function
+type funcJob struct {
+	id   string
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewJob creates a one-off job that runs fn
+func NewJob(name string, fn func(ctx context.Context) error) Job {
+	return &funcJob{
+		id:   uuid.New().String(),
+		name: name,
+		fn:   fn,
+	}
+}
+
+// Execute runs the job
+func (j *funcJob) Execute(ctx context.Context) error {
+	return j.fn(ctx)
+}
+
+// Name returns the name of the job
+func (j *funcJob) Name() string {
+	return j.name
+}
+
+// ID returns the unique identifier of the job
+func (j *funcJob) ID() string {
+	return j.id
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// DataExportRepository is an in-memory implementation of
+// ports.DataExportRepository
+type DataExportRepository struct {
+	mu      sync.Mutex
+	exports map[uint]domain.DataExport
+	nextID  uint
+}
+
+// NewDataExportRepository creates an empty export repository. If tm is not
+// nil the repository takes part in its transactions.
+func NewDataExportRepository(tm *TransactionManager) *DataExportRepository {
+	r := &DataExportRepository{
+		exports: make(map[uint]domain.DataExport),
+		nextID:  1,
+	}
+	if tm != nil {
+		tm.register(r)
+	}
+	return r
+}
+
+// Create stores a new export
+func (r *DataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	export.ID = r.nextID
+	export.CreatedAt = time.Now()
+	r.nextID++
+	r.exports[export.ID] = *export
+	return nil
+}
+
+// FindLatest retrieves the most recent export of a user
+func (r *DataExportRepository) FindLatest(ctx context.Context, userID uint) (domain.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest domain.DataExport
+	for _, export := range r.exports {
+		if export.UserID == userID && export.ID > latest.ID {
+			latest = export
+		}
+	}
+	if latest.ID == 0 {
+		return latest, apperrs.ErrNotFound
+	}
+	return latest, nil
+}
+
+// Complete stores the outcome of a pending export
+func (r *DataExportRepository) Complete(ctx context.Context, export *domain.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.exports[export.ID]; ok && stored.Status == domain.DataExportPending {
+		r.exports[export.ID] = *export
+	}
+	return nil
+}
+
+// DeleteByUser removes every export of a user
+func (r *DataExportRepository) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	return r.deleteWhere(func(export domain.DataExport) bool {
+		return export.UserID == userID
+	}), nil
+}
+
+// DeleteExpired removes exports that expired before the given time
+func (r *DataExportRepository) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	return r.deleteWhere(func(export domain.DataExport) bool {
+		return export.ExpiresAt.Before(expiredBefore)
+	}), nil
+}
+
+// deleteWhere removes the exports matching match
+func (r *DataExportRepository) deleteWhere(match func(domain.DataExport) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, export := range r.exports {
+		if match(export) {
+			delete(r.exports, id)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// snapshot captures the repository state for transaction rollback
+func (r *DataExportRepository) snapshot() any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exports := make(map[uint]domain.DataExport, len(r.exports))
+	for id, export := range r.exports {
+		exports[id] = export
+	}
+	return dataExportState{exports: exports, nextID: r.nextID}
+}
+
+// restore resets the repository to a snapshot
+func (r *DataExportRepository) restore(snapshot any) {
+	state := snapshot.(dataExportState)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exports = state.exports
+	r.nextID = state.nextID
+}
+
+type dataExportState struct {
+	exports map[uint]domain.DataExport
+	nextID  uint
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+)
+
+// OutboxRepository is an in-memory implementation of ports.OutboxRepository
+type OutboxRepository struct {
+	mu     sync.Mutex
+	events map[uint]domain.OutboxEvent
+	nextID uint
+}
+
+// NewOutboxRepository creates an empty outbox. If tm is not nil the outbox
+// takes part in its transactions.
+func NewOutboxRepository(tm *TransactionManager) *OutboxRepository {
+	r := &OutboxRepository{
+		events: make(map[uint]domain.OutboxEvent),
+		nextID: 1,
+	}
+	if tm != nil {
+		tm.register(r)
+	}
+	return r
+}
+
+// Add stores events
+func (r *OutboxRepository) Add(ctx context.Context, events ...*domain.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, event := range events {
+		event.ID = r.nextID
+		event.CreatedAt = now
+		if event.NextAttemptAt.IsZero() {
+			event.NextAttemptAt = now
+		}
+		r.nextID++
+		r.events[event.ID] = *event
+	}
+	return nil
+}
+
+// Claim retrieves up to limit unpublished events in ID order, leaving out
+// aggregates whose oldest unpublished event is not yet due, and leases them
+// until the given time
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, until time.Time) ([]domain.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	claimed := []domain.OutboxEvent{}
+	for _, event := range r.sorted() {
+		if event.PublishedAt != nil {
+			continue
+		}
+		key := event.AggregateKey()
+		if blocked[key] || event.NextAttemptAt.After(now) {
+			blocked[key] = true
+			continue
+		}
+		if len(claimed) < limit {
+			event.NextAttemptAt = until
+			r.events[event.ID] = event
+			claimed = append(claimed, event)
+		}
+	}
+	return claimed, nil
+}
+
+// Release makes claimed events due again without counting an attempt
+func (r *OutboxRepository) Release(ctx context.Context, ids ...uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if event, ok := r.events[id]; ok && event.PublishedAt == nil {
+			event.NextAttemptAt = now
+			r.events[id] = event
+		}
+	}
+	return nil
+}
+
+// MarkPublished records that an event was delivered
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event, ok := r.events[id]; ok {
+		now := time.Now()
+		event.PublishedAt = &now
+		event.Attempts++
+		event.LastError = ""
+		r.events[id] = event
+	}
+	return nil
+}
+
+// MarkFailed records a failed delivery and when to try again
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uint, cause error, nextAttempt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event, ok := r.events[id]; ok {
+		event.Attempts++
+		event.LastError = cause.Error()
+		event.NextAttemptAt = nextAttempt
+		r.events[id] = event
+	}
+	return nil
+}
+
+// DeletePublished removes events published before the given time
+func (r *OutboxRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, event := range r.events {
+		if event.PublishedAt != nil && event.PublishedAt.Before(publishedBefore) {
+			delete(r.events, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Events returns every stored event in ID order
+func (r *OutboxRepository) Events() []domain.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sorted()
+}
+
+// sorted returns the events ordered by ID; callers hold r.mu
+func (r *OutboxRepository) sorted() []domain.OutboxEvent {
+	events := make([]domain.OutboxEvent, 0, len(r.events))
+	for _, event := range r.events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	return events
+}
+
+// snapshot captures the outbox state for transaction rollback
+func (r *OutboxRepository) snapshot() any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make(map[uint]domain.OutboxEvent, len(r.events))
+	for id, event := range r.events {
+		events[id] = event
+	}
+	return outboxState{events: events, nextID: r.nextID}
+}
+
+// restore resets the outbox to a snapshot
+func (r *OutboxRepository) restore(snapshot any) {
+	state := snapshot.(outboxState)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = state.events
+	r.nextID = state.nextID
+}
+
+type outboxState struct {
+	events map[uint]domain.OutboxEvent
+	nextID uint
+}
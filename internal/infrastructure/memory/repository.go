@@ -0,0 +1,485 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Repository is a concurrency-safe, in-memory implementation of
+// ports.Repository with the same observable semantics as
+// database.GormRepository: sequential IDs, timestamps, versioned updates,
+// soft deletes and ID-ordered pagination. T must embed domain.BaseEntity.
+// Database constraints such as unique indexes are not enforced.
+type Repository[T domain.Entity] struct {
+	mu     sync.RWMutex
+	rows   map[uint]T
+	nextID uint
+	schema *schema.Schema
+}
+
+// NewRepository creates an empty repository. If tm is not nil the repository
+// takes part in its transactions.
+func NewRepository[T domain.Entity](tm *TransactionManager) *Repository[T] {
+	s, err := schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(fmt.Sprintf("memory: cannot parse schema of %T: %v", *new(T), err))
+	}
+	if domain.BaseOf(new(T)) == nil {
+		panic(fmt.Sprintf("memory: %T does not embed domain.BaseEntity", *new(T)))
+	}
+
+	r := &Repository[T]{
+		rows:   make(map[uint]T),
+		nextID: 1,
+		schema: s,
+	}
+	if tm != nil {
+		tm.register(r)
+	}
+	return r
+}
+
+// Create creates a new entity, assigning its ID, timestamps, version and,
+// unless it has one, public ID
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.create(entity, time.Now())
+	return nil
+}
+
+// create stores a new entity; callers hold r.mu
+func (r *Repository[T]) create(entity *T, now time.Time) {
+	base := domain.BaseOf(entity)
+	base.ID = r.nextID
+	base.CreatedAt = now
+	base.UpdatedAt = now
+	base.DeletedAt = gorm.DeletedAt{}
+	if base.Version == 0 {
+		base.Version = 1
+	}
+	if base.PublicID == "" {
+		base.PublicID = domain.NewPublicID()
+	}
+
+	r.nextID++
+	r.rows[base.ID] = *entity
+}
+
+// FindByID retrieves an entity by its ID
+func (r *Repository[T]) FindByID(ctx context.Context, id uint) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entity, ok := r.rows[id]
+	if !ok || domain.BaseOf(&entity).IsDeleted() {
+		var zero T
+		return zero, fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
+	}
+	return entity, nil
+}
+
+// FindByPublicID retrieves an entity by its public ID
+func (r *Repository[T]) FindByPublicID(ctx context.Context, publicID string) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entity, ok := r.findPublic(publicID, false); ok {
+		return entity, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("entity %s: %w", publicID, apperrs.ErrNotFound)
+}
+
+// findPublic returns the entity with the given public ID if its deletion
+// state matches deleted; callers hold r.mu
+func (r *Repository[T]) findPublic(publicID string, deleted bool) (T, bool) {
+	for _, entity := range r.rows {
+		base := domain.BaseOf(&entity)
+		if base.PublicID == publicID && base.IsDeleted() == deleted {
+			return entity, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// FindOneBy retrieves the first entity, by ID, whose field equals value.
+// String values are compared case-insensitively.
+func (r *Repository[T]) FindOneBy(ctx context.Context, field string, value any) (T, error) {
+	var zero T
+	schemaField := r.schema.LookUpField(field)
+	if schemaField == nil || schemaField.DBName == "" {
+		return zero, fmt.Errorf("unknown field %q: %w", field, apperrs.ErrInvalidInput)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, entity := range r.sorted(false) {
+		fieldValue := schemaField.ReflectValueOf(ctx, reflect.ValueOf(&entity).Elem()).Interface()
+		if equal(fieldValue, value) {
+			return entity, nil
+		}
+	}
+	return zero, fmt.Errorf("entity with %s %v: %w", field, value, apperrs.ErrNotFound)
+}
+
+// Update updates an existing entity, failing with ErrConflict if its version
+// no longer matches the stored one
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUpdate(entity); err != nil {
+		return err
+	}
+	r.update(entity, time.Now())
+	return nil
+}
+
+// checkUpdate reports why entity cannot be updated, if it cannot; callers hold r.mu
+func (r *Repository[T]) checkUpdate(entity *T) error {
+	base := domain.BaseOf(entity)
+	stored, ok := r.rows[base.ID]
+	if !ok || domain.BaseOf(&stored).IsDeleted() {
+		return fmt.Errorf("entity %d: %w", base.ID, apperrs.ErrNotFound)
+	}
+	if domain.BaseOf(&stored).Version != base.Version {
+		return fmt.Errorf("entity %d was modified concurrently (expected version %d): %w", base.ID, base.Version, apperrs.ErrConflict)
+	}
+	return nil
+}
+
+// update stores a checked entity with its version bumped; callers hold r.mu
+func (r *Repository[T]) update(entity *T, now time.Time) {
+	base := domain.BaseOf(entity)
+	stored := r.rows[base.ID]
+
+	base.Version++
+	base.CreatedAt = domain.BaseOf(&stored).CreatedAt
+	base.DeletedAt = gorm.DeletedAt{}
+	base.UpdatedAt = now
+	r.rows[base.ID] = *entity
+}
+
+// Delete soft deletes an entity
+func (r *Repository[T]) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.live(id) {
+		return fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
+	}
+	r.delete(id, time.Now())
+	return nil
+}
+
+// DeleteVersion soft deletes an entity if it is still at version
+func (r *Repository[T]) DeleteVersion(ctx context.Context, id uint, version uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.live(id) {
+		return fmt.Errorf("entity %d: %w", id, apperrs.ErrNotFound)
+	}
+	stored := r.rows[id]
+	if domain.BaseOf(&stored).Version != version {
+		return fmt.Errorf("entity %d was modified concurrently (expected version %d): %w", id, version, apperrs.ErrConflict)
+	}
+	r.delete(id, time.Now())
+	return nil
+}
+
+// live reports whether id refers to an entity that is not deleted; callers hold r.mu
+func (r *Repository[T]) live(id uint) bool {
+	entity, ok := r.rows[id]
+	return ok && !domain.BaseOf(&entity).IsDeleted()
+}
+
+// delete marks a live entity as deleted; callers hold r.mu
+func (r *Repository[T]) delete(id uint, now time.Time) {
+	entity := r.rows[id]
+	base := domain.BaseOf(&entity)
+	base.DeletedAt.Time = now
+	base.DeletedAt.Valid = true
+	r.rows[id] = entity
+}
+
+// List retrieves entities with pagination, ordered by ID
+func (r *Repository[T]) List(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entities := r.sorted(false)
+	return paginate(entities, page, pageSize), int64(len(entities)), nil
+}
+
+// ListBy retrieves the entities whose fields equal the values in filters,
+// with pagination in ID order
+func (r *Repository[T]) ListBy(ctx context.Context, filters map[string]any, page, pageSize int) ([]T, int64, error) {
+	fields := make(map[*schema.Field]any, len(filters))
+	for field, value := range filters {
+		schemaField := r.schema.LookUpField(field)
+		if schemaField == nil || schemaField.DBName == "" {
+			return nil, 0, fmt.Errorf("unknown field %q: %w", field, apperrs.ErrInvalidInput)
+		}
+		fields[schemaField] = value
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entities []T
+	for _, entity := range r.sorted(false) {
+		matches := true
+		for schemaField, value := range fields {
+			fieldValue := schemaField.ReflectValueOf(ctx, reflect.ValueOf(&entity).Elem()).Interface()
+			matches = matches && equal(fieldValue, value)
+		}
+		if matches {
+			entities = append(entities, entity)
+		}
+	}
+	return paginate(entities, page, pageSize), int64(len(entities)), nil
+}
+
+// ForEachBatch calls fn with batches of the live entities in ID order. The
+// entities are read under the lock one batch at a time, as the GORM
+// implementation does, so fn may use the repository.
+func (r *Repository[T]) ForEachBatch(ctx context.Context, batchSize int, fn func(batch []T) error) error {
+	var after uint
+	for {
+		r.mu.RLock()
+		var batch []T
+		for _, entity := range r.sorted(false) {
+			if entity.GetID() > after {
+				batch = append(batch, entity)
+				if len(batch) == batchSize {
+					break
+				}
+			}
+		}
+		r.mu.RUnlock()
+
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		after = batch[len(batch)-1].GetID()
+	}
+}
+
+// CreateMany creates entities, assigning consecutive IDs
+func (r *Repository[T]) CreateMany(ctx context.Context, entities []*T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, entity := range entities {
+		r.create(entity, now)
+	}
+	return nil
+}
+
+// UpdateMany updates entities if every one of them passes the version check
+func (r *Repository[T]) UpdateMany(ctx context.Context, entities []*T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[uint]bool, len(entities))
+	for i, entity := range entities {
+		if err := r.checkUpdate(entity); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+		// A second update of the same entity would fail its version check
+		id := domain.BaseOf(entity).ID
+		if seen[id] {
+			return fmt.Errorf("item %d: entity %d was modified concurrently: %w", i, id, apperrs.ErrConflict)
+		}
+		seen[id] = true
+	}
+
+	now := time.Now()
+	for _, entity := range entities {
+		r.update(entity, now)
+	}
+	return nil
+}
+
+// DeleteMany soft deletes the entities with the given IDs if all of them exist
+func (r *Repository[T]) DeleteMany(ctx context.Context, ids []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	missing := 0
+	for _, id := range ids {
+		if !r.live(id) {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d entities: %w", missing, len(ids), apperrs.ErrNotFound)
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		if r.live(id) {
+			r.delete(id, now)
+		}
+	}
+	return nil
+}
+
+// ListDeleted retrieves soft-deleted entities with pagination, most recently deleted first
+func (r *Repository[T]) ListDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entities := r.sorted(true)
+	sort.SliceStable(entities, func(i, j int) bool {
+		return domain.BaseOf(&entities[i]).DeletedAt.Time.After(domain.BaseOf(&entities[j]).DeletedAt.Time)
+	})
+	return paginate(entities, page, pageSize), int64(len(entities)), nil
+}
+
+// FindDeletedByPublicID retrieves a soft-deleted entity by its public ID
+func (r *Repository[T]) FindDeletedByPublicID(ctx context.Context, publicID string) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entity, ok := r.findPublic(publicID, true); ok {
+		return entity, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("deleted entity %s: %w", publicID, apperrs.ErrNotFound)
+}
+
+// Restore clears the deletion mark of a soft-deleted entity
+func (r *Repository[T]) Restore(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entity, ok := r.rows[id]
+	base := domain.BaseOf(&entity)
+	if !ok || !base.IsDeleted() {
+		return fmt.Errorf("deleted entity %d: %w", id, apperrs.ErrNotFound)
+	}
+
+	base.DeletedAt = gorm.DeletedAt{}
+	r.rows[id] = entity
+	return nil
+}
+
+// Purge permanently removes entities soft deleted before the given time
+func (r *Repository[T]) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, entity := range r.rows {
+		base := domain.BaseOf(&entity)
+		if base.IsDeleted() && base.DeletedAt.Time.Before(deletedBefore) {
+			delete(r.rows, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// sorted returns live (or deleted) entities ordered by ID; callers hold r.mu
+func (r *Repository[T]) sorted(deleted bool) []T {
+	entities := make([]T, 0, len(r.rows))
+	for _, entity := range r.rows {
+		if domain.BaseOf(&entity).IsDeleted() == deleted {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].GetID() < entities[j].GetID()
+	})
+	return entities
+}
+
+// snapshot captures the repository state for transaction rollback
+func (r *Repository[T]) snapshot() any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rows := make(map[uint]T, len(r.rows))
+	for id, entity := range r.rows {
+		rows[id] = entity
+	}
+	return repositoryState[T]{rows: rows, nextID: r.nextID}
+}
+
+// restore resets the repository to a snapshot
+func (r *Repository[T]) restore(snapshot any) {
+	state := snapshot.(repositoryState[T])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rows = state.rows
+	r.nextID = state.nextID
+}
+
+type repositoryState[T domain.Entity] struct {
+	rows   map[uint]T
+	nextID uint
+}
+
+// paginate returns the requested 1-based page of entities
+func paginate[T any](entities []T, page, pageSize int) []T {
+	offset := (page - 1) * pageSize
+	if offset < 0 || offset >= len(entities) || pageSize <= 0 {
+		return []T{}
+	}
+	end := offset + pageSize
+	if end > len(entities) {
+		end = len(entities)
+	}
+	return entities[offset:end]
+}
+
+// equal compares a stored field value with a lookup value the way
+// GormRepository.FindOneBy does: strings case-insensitively, everything else
+// after dereferencing pointers
+func equal(fieldValue, value any) bool {
+	if s, ok := value.(string); ok {
+		fs, ok := fieldValue.(string)
+		return ok && strings.EqualFold(fs, s)
+	}
+	fv := reflect.ValueOf(fieldValue)
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return value == nil
+		}
+		fv = fv.Elem()
+	}
+	v := reflect.ValueOf(value)
+	if !fv.IsValid() || !v.IsValid() {
+		return !fv.IsValid() && !v.IsValid()
+	}
+	if v.Type().ConvertibleTo(fv.Type()) {
+		return reflect.DeepEqual(fv.Interface(), v.Convert(fv.Type()).Interface())
+	}
+	return false
+}
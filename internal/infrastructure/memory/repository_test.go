@@ -0,0 +1,89 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/app/ports/porttest"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+)
+
+func userHarness() porttest.RepositoryHarness[models.User] {
+	return porttest.RepositoryHarness[models.User]{
+		New: func(t *testing.T) (ports.Repository[models.User], ports.TransactionManager) {
+			tm := memory.NewTransactionManager()
+			return memory.NewRepository[models.User](tm), tm
+		},
+		NewEntity: func(i int) *models.User {
+			return &models.User{
+				Email:        fmt.Sprintf("user%d@example.com", i),
+				PasswordHash: "hash",
+				FirstName:    "First",
+				LastName:     "Last",
+				Role:         "user",
+				Active:       true,
+			}
+		},
+		Mutate: func(user *models.User) {
+			user.FirstName += "x"
+		},
+		Equal: func(a, b models.User) bool {
+			return a.ID == b.ID && a.Version == b.Version && a.Email == b.Email &&
+				a.FirstName == b.FirstName && a.LastName == b.LastName
+		},
+		LookupField: "email",
+		LookupValue: func(user models.User) string { return user.Email },
+	}
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	porttest.RunRepositoryConformance(t, userHarness())
+}
+
+func TestRepositoryConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository[models.User](nil)
+
+	user := &models.User{Email: "user@example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := *user
+			update.FirstName = fmt.Sprintf("writer%d", i)
+			errs <- repo.Update(ctx, &update)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one update to win, got %d", succeeded)
+	}
+
+	stored, err := repo.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if domain.BaseOf(&stored).Version != 2 {
+		t.Fatalf("expected version 2, got %d", stored.Version)
+	}
+}
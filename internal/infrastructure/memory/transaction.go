@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// participant is a repository whose state can be rolled back
+type participant interface {
+	snapshot() any
+	restore(snapshot any)
+}
+
+type txKeyType struct{}
+
+// txState tracks one level of a (possibly nested) transaction
+type txState struct {
+	parent *txState
+
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+// TransactionManager implements ports.TransactionManager for in-memory
+// repositories. Transactions are serialized and rolled back by restoring a
+// snapshot of every registered repository; nested transactions behave like
+// savepoints. Writes made outside a transaction while one is running are not
+// isolated from it and are lost if it rolls back.
+type TransactionManager struct {
+	mu sync.Mutex
+
+	participantsMu sync.Mutex
+	participants   []participant
+}
+
+// NewTransactionManager creates a new transaction manager
+func NewTransactionManager() *TransactionManager {
+	return &TransactionManager{}
+}
+
+// register adds a repository to the set rolled back on failure
+func (m *TransactionManager) register(p participant) {
+	m.participantsMu.Lock()
+	defer m.participantsMu.Unlock()
+	m.participants = append(m.participants, p)
+}
+
+// WithTransaction executes fn in a transaction; on error every registered
+// repository is restored to its state before fn ran
+func (m *TransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	parent, _ := ctx.Value(txKeyType{}).(*txState)
+	if parent == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	}
+
+	m.participantsMu.Lock()
+	participants := append([]participant(nil), m.participants...)
+	m.participantsMu.Unlock()
+
+	snapshots := make([]any, len(participants))
+	for i, p := range participants {
+		snapshots[i] = p.snapshot()
+	}
+
+	state := &txState{parent: parent}
+	if err := fn(context.WithValue(ctx, txKeyType{}, state)); err != nil {
+		for i, p := range participants {
+			p.restore(snapshots[i])
+		}
+		return err
+	}
+
+	hooks := state.takeHooks()
+	if parent != nil {
+		for _, hook := range hooks {
+			parent.addHook(hook)
+		}
+		return nil
+	}
+	runHooks(ctx, hooks)
+	return nil
+}
+
+// AfterCommit registers fn to run after the transaction carried by ctx
+// commits. Outside a transaction fn runs immediately.
+func (m *TransactionManager) AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	state, _ := ctx.Value(txKeyType{}).(*txState)
+	if state == nil {
+		runHooks(ctx, []func(ctx context.Context){fn})
+		return
+	}
+	state.addHook(fn)
+}
+
+// InTransaction reports whether ctx carries an open transaction
+func (m *TransactionManager) InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKeyType{}).(*txState)
+	return ok
+}
+
+// addHook registers a function to run once the outermost transaction commits
+func (s *txState) addHook(fn func(ctx context.Context)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// takeHooks returns and clears the registered hooks
+func (s *txState) takeHooks() []func(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := s.hooks
+	s.hooks = nil
+	return hooks
+}
+
+// runHooks runs hooks in registration order, isolating panics
+func runHooks(ctx context.Context, hooks []func(ctx context.Context)) {
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("After-commit hook panicked", zap.Any("panic", r))
+				}
+			}()
+			hook(ctx)
+		}()
+	}
+}
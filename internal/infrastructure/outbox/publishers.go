@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Envelope is the representation of an event sent to other systems; the
+// aggregate is identified by its public ID
+type Envelope struct {
+	ID            uint            `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps an outbox event for delivery
+func NewEnvelope(event domain.OutboxEvent) Envelope {
+	return Envelope{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregatePublicID,
+		OccurredAt:    event.CreatedAt,
+		Payload:       event.Payload,
+	}
+}
+
+// LogPublisher writes events to the application log
+type LogPublisher struct{}
+
+// NewLogPublisher creates a new log publisher
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish logs the event
+func (p *LogPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	logger.Info("Domain event",
+		zap.Uint("event_id", event.ID),
+		zap.String("event_type", event.EventType),
+		zap.String("aggregate", event.AggregateKey()),
+		zap.ByteString("payload", event.Payload),
+	)
+	return nil
+}
+
+// WebhookPublisher POSTs events as JSON envelopes to an HTTP endpoint. The
+// X-Event-ID header lets receivers discard duplicates; when a secret is set
+// the body is signed with HMAC-SHA256 in the X-Signature header.
+type WebhookPublisher struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a webhook publisher
+func NewWebhookPublisher(url, secret string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish sends the event; any non-2xx response is a failed delivery
+func (p *WebhookPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	body, err := json.Marshal(NewEnvelope(event))
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", fmt.Sprint(event.ID))
+	req.Header.Set("X-Event-Type", event.EventType)
+	if len(p.secret) > 0 {
+		req.Header.Set("X-Signature", "sha256="+Sign(p.secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of body, as sent in X-Signature
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// MemoryPublisher records published events, for tests
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []domain.OutboxEvent
+	err    error
+}
+
+// NewMemoryPublisher creates a new memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish records the event, or fails with the error set by SetError
+func (p *MemoryPublisher) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// SetError makes subsequent deliveries fail with err until it is set to nil
+func (p *MemoryPublisher) SetError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Events returns the published events in delivery order
+func (p *MemoryPublisher) Events() []domain.OutboxEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.OutboxEvent(nil), p.events...)
+}
@@ -0,0 +1,198 @@
+// Package outbox relays domain events stored in the transactional outbox to
+// an event publisher.
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize  = 100
+	defaultMaxBackoff = 5 * time.Minute
+	defaultLease      = 15 * time.Minute
+)
+
+// Relay publishes pending outbox events. Delivery is at least once: an event
+// is marked published only after the publisher accepted it. Events of one
+// aggregate are delivered in order; after a failed delivery the aggregate's
+// later events wait until the failed one is retried successfully, with
+// exponential backoff between attempts.
+//
+// Each run claims a batch in a short transaction, publishes it outside of
+// any transaction and marks every event published or failed in a
+// transaction of its own, so that no transaction or lock is held while a
+// publisher is waited on. Events claimed by a relay that stops before
+// marking them are published again once their lease runs out.
+type Relay struct {
+	repository ports.OutboxRepository
+	publisher  ports.EventPublisher
+	txManager  ports.TransactionManager
+	batchSize  int
+	maxBackoff time.Duration
+	lease      time.Duration
+	retention  time.Duration
+
+	// running prevents overlapping runs when a batch outlasts the schedule
+	running sync.Mutex
+}
+
+// Option configures a Relay
+type Option func(*Relay)
+
+// WithBatchSize sets the maximum number of events published per run
+func WithBatchSize(size int) Option {
+	return func(r *Relay) {
+		if size > 0 {
+			r.batchSize = size
+		}
+	}
+}
+
+// WithMaxBackoff caps the delay between delivery attempts of an event
+func WithMaxBackoff(backoff time.Duration) Option {
+	return func(r *Relay) {
+		if backoff > 0 {
+			r.maxBackoff = backoff
+		}
+	}
+}
+
+// WithLease sets how long a claimed batch is reserved for the relay that
+// claimed it; it should outlast the publication of a whole batch
+func WithLease(lease time.Duration) Option {
+	return func(r *Relay) {
+		if lease > 0 {
+			r.lease = lease
+		}
+	}
+}
+
+// WithRetention deletes published events once they are older than retention
+func WithRetention(retention time.Duration) Option {
+	return func(r *Relay) {
+		r.retention = retention
+	}
+}
+
+// NewRelay creates a relay. Batches are claimed, and events marked, in
+// transactions of txManager, if given, so that the repository can keep
+// concurrent relays from claiming the same events.
+func NewRelay(repository ports.OutboxRepository, publisher ports.EventPublisher, txManager ports.TransactionManager, opts ...Option) *Relay {
+	r := &Relay{
+		repository: repository,
+		publisher:  publisher,
+		txManager:  txManager,
+		batchSize:  defaultBatchSize,
+		maxBackoff: defaultMaxBackoff,
+		lease:      defaultLease,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run publishes one batch of pending events; it is meant to be scheduled as
+// a jobs.ScheduledJob
+func (r *Relay) Run(ctx context.Context) error {
+	if !r.running.TryLock() {
+		return nil
+	}
+	defer r.running.Unlock()
+
+	var events []domain.OutboxEvent
+	err := r.transaction(ctx, func(ctx context.Context) error {
+		var err error
+		events, err = r.repository.Claim(ctx, r.batchSize, time.Now().Add(r.lease))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var published, failed int
+	var skipped []uint
+	blocked := make(map[string]bool)
+	for _, event := range events {
+		key := event.AggregateKey()
+		if blocked[key] {
+			skipped = append(skipped, event.ID)
+			continue
+		}
+
+		if cause := r.publisher.Publish(ctx, event); cause != nil {
+			blocked[key] = true
+			failed++
+			logger.Warn("Failed to publish outbox event",
+				zap.Uint("event_id", event.ID),
+				zap.String("event_type", event.EventType),
+				zap.Int("attempts", event.Attempts+1),
+				zap.Error(cause),
+			)
+			nextAttempt := time.Now().Add(r.backoff(event.Attempts + 1))
+			if err := r.transaction(ctx, func(ctx context.Context) error {
+				return r.repository.MarkFailed(ctx, event.ID, cause, nextAttempt)
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.transaction(ctx, func(ctx context.Context) error {
+			return r.repository.MarkPublished(ctx, event.ID)
+		}); err != nil {
+			return err
+		}
+		published++
+	}
+
+	// Events queued behind a failed one wait for it, not for their lease
+	if len(skipped) > 0 {
+		if err := r.transaction(ctx, func(ctx context.Context) error {
+			return r.repository.Release(ctx, skipped...)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if published > 0 || failed > 0 {
+		logger.Info("Relayed outbox events", zap.Int("published", published), zap.Int("failed", failed))
+	}
+
+	if r.retention > 0 {
+		if _, err := r.repository.DeletePublished(ctx, time.Now().Add(-r.retention)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transaction runs fn in a transaction of the relay's transaction manager,
+// if it has one
+func (r *Relay) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.txManager == nil {
+		return fn(ctx)
+	}
+	return r.txManager.WithTransaction(ctx, fn)
+}
+
+// backoff returns the delay before the next attempt after the given number
+// of failed attempts: 1s, 2s, 4s, ... up to the maximum
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,232 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/services"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	"go-server-boilerplate/internal/infrastructure/outbox"
+)
+
+func newOutboxService(t *testing.T) (*services.BaseService[models.User], *memory.OutboxRepository, *memory.TransactionManager) {
+	t.Helper()
+	tm := memory.NewTransactionManager()
+	events := memory.NewOutboxRepository(tm)
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm,
+		services.WithOutbox[models.User](events, "users"))
+	return service, events, tm
+}
+
+func eventTypes(events []outbox.Envelope) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func published(publisher *outbox.MemoryPublisher) []outbox.Envelope {
+	var envelopes []outbox.Envelope
+	for _, event := range publisher.Events() {
+		envelopes = append(envelopes, outbox.NewEnvelope(event))
+	}
+	return envelopes
+}
+
+func TestRelayPublishesEventsInOrder(t *testing.T) {
+	ctx := context.Background()
+	service, events, tm := newOutboxService(t)
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	if err := service.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	user.FirstName = "Ada"
+	if err := service.Update(ctx, user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := service.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	publisher := outbox.NewMemoryPublisher()
+	relay := outbox.NewRelay(events, publisher, tm)
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	envelopes := published(publisher)
+	got := eventTypes(envelopes)
+	want := []string{"users.created", "users.updated", "users.deleted"}
+	if len(got) != len(want) {
+		t.Fatalf("published %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("published %v, want %v", got, want)
+		}
+	}
+
+	// Every event of the aggregate identifies the user by its public ID
+	for _, envelope := range envelopes {
+		var payload struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil || payload.ID != user.PublicID {
+			t.Fatalf("%s payload %s does not carry public ID %s", envelope.Type, envelope.Payload, user.PublicID)
+		}
+		if envelope.AggregateID != user.PublicID {
+			t.Fatalf("%s has aggregate %s, want public ID %s", envelope.Type, envelope.AggregateID, user.PublicID)
+		}
+	}
+
+	// Published events are not delivered again
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := len(publisher.Events()); n != len(want) {
+		t.Fatalf("published %d events after second run, want %d", n, len(want))
+	}
+}
+
+func TestRolledBackWritesRecordNoEvents(t *testing.T) {
+	ctx := context.Background()
+	service, events, _ := newOutboxService(t)
+
+	errAbort := errors.New("abort")
+	err := service.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := service.Create(ctx, &models.User{Email: "a@example.com", PasswordHash: "hash"}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction: got %v, want %v", err, errAbort)
+	}
+	if n := len(events.Events()); n != 0 {
+		t.Fatalf("outbox holds %d events after rollback, want 0", n)
+	}
+}
+
+func TestRelayRetriesFailedAggregateInOrder(t *testing.T) {
+	ctx := context.Background()
+	service, events, tm := newOutboxService(t)
+
+	first := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	second := &models.User{Email: "b@example.com", PasswordHash: "hash"}
+	if err := service.Create(ctx, first); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	publisher := outbox.NewMemoryPublisher()
+	relay := outbox.NewRelay(events, publisher, tm, outbox.WithMaxBackoff(time.Hour))
+
+	// The first user's event fails; later events of other users are not
+	// held back, but the first user's update waits for the retry
+	publisher.SetError(errors.New("unavailable"))
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	publisher.SetError(nil)
+	if err := service.Create(ctx, second); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	first.FirstName = "Ada"
+	if err := service.Update(ctx, first); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := published(publisher)
+	if len(got) != 1 || got[0].AggregateID != second.PublicID {
+		t.Fatalf("published %v, want only the second user's event", eventTypes(got))
+	}
+	failed := events.Events()[0]
+	if failed.Attempts != 1 || failed.LastError != "unavailable" || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed event = %+v, want one attempt with a future retry", failed)
+	}
+
+	// Once due, the failed event is retried before the aggregate's later one
+	if err := events.MarkFailed(ctx, failed.ID, errors.New("unavailable"), time.Now()); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got = published(publisher)
+	if len(got) != 3 {
+		t.Fatalf("published %v, want 3 events", eventTypes(got))
+	}
+	if got[1].AggregateID != first.PublicID || got[1].Type != "users.created" || got[2].Type != "users.updated" {
+		t.Fatalf("published %v, want the first user's created event before its update", eventTypes(got))
+	}
+}
+
+// publisherFunc adapts a function to ports.EventPublisher
+type publisherFunc func(ctx context.Context, event domain.OutboxEvent) error
+
+func (f publisherFunc) Publish(ctx context.Context, event domain.OutboxEvent) error {
+	return f(ctx, event)
+}
+
+func TestRelayPublishesOutsideTransaction(t *testing.T) {
+	ctx := context.Background()
+	service, events, tm := newOutboxService(t)
+
+	first := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	if err := service.Create(ctx, first); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	other := outbox.NewMemoryPublisher()
+	otherRelay := outbox.NewRelay(events, other, tm)
+	var second *models.User
+	publisher := publisherFunc(func(ctx context.Context, event domain.OutboxEvent) error {
+		if second != nil {
+			return nil
+		}
+
+		// Writes are not held up by a delivery in progress
+		second = &models.User{Email: "b@example.com", PasswordHash: "hash"}
+		done := make(chan error, 1)
+		go func() { done <- service.Create(context.Background(), second) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				return err
+			}
+		case <-time.After(time.Second):
+			return errors.New("write blocked by the relay")
+		}
+
+		// Another relay skips the claimed event but delivers the new one
+		if err := otherRelay.Run(context.Background()); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	relay := outbox.NewRelay(events, publisher, tm)
+	if err := relay.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := published(other)
+	if len(got) != 1 || got[0].AggregateID != second.PublicID {
+		t.Fatalf("other relay published %v, want only the second user's event", eventTypes(got))
+	}
+	for _, event := range events.Events() {
+		if event.PublishedAt == nil || event.Attempts != 1 {
+			t.Fatalf("event %+v, want published after one attempt", event)
+		}
+	}
+}
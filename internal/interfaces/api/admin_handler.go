@@ -0,0 +1,366 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/cache"
+	"go-server-boilerplate/internal/infrastructure/database"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/middleware"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles administrative HTTP requests
+type AdminHandler struct {
+	userService  ports.SoftDeleteService[models.User]
+	auditService ports.AuditService
+	caches       map[string]func() cache.Stats
+	queryStats   *database.QueryStats
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(userService ports.SoftDeleteService[models.User], auditService ports.AuditService) *AdminHandler {
+	return &AdminHandler{
+		userService:  userService,
+		auditService: auditService,
+		caches:       make(map[string]func() cache.Stats),
+	}
+}
+
+// RegisterCache exposes the statistics of a named cache on the cache stats
+// endpoint
+func (h *AdminHandler) RegisterCache(name string, stats func() cache.Stats) {
+	h.caches[name] = stats
+}
+
+// DeletedUserResponse represents a soft-deleted user
+type DeletedUserResponse struct {
+	UserResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ListDeletedUsersResponse represents the response for listing deleted users
+type ListDeletedUsersResponse struct {
+	Users      []DeletedUserResponse `json:"users"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"page_size"`
+	TotalPages int                   `json:"total_pages"`
+}
+
+// ListAuditEntriesResponse represents the response for listing audit entries
+type ListAuditEntriesResponse struct {
+	Entries    []domain.AuditEntry `json:"entries"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// SetQueryStats exposes query statistics on the queries endpoints
+func (h *AdminHandler) SetQueryStats(stats *database.QueryStats) {
+	h.queryStats = stats
+}
+
+// TopQueriesResponse represents the most expensive queries
+type TopQueriesResponse struct {
+	Queries []database.QueryStat `json:"queries"`
+}
+
+// CacheStatsResponse represents the statistics of one cache
+type CacheStatsResponse struct {
+	cache.Stats
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// RegisterAdminRoutes registers admin routes
+func (h *AdminHandler) RegisterAdminRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	api := router.PathPrefix("/api/v1/admin").Subrouter()
+
+	api.Use(authMiddleware.AuthRequiredMiddleware)
+	api.Use(authMiddleware.RoleRequired("admin"))
+	api.HandleFunc("/users/deleted", h.ListDeletedUsers).Methods(http.MethodGet)
+	api.HandleFunc("/users/{id}/restore", h.RestoreUser).Methods(http.MethodPost)
+	api.HandleFunc("/audit", h.ListAuditEntries).Methods(http.MethodGet)
+	api.HandleFunc("/cache", h.CacheStats).Methods(http.MethodGet)
+	api.HandleFunc("/queries", h.TopQueries).Methods(http.MethodGet)
+	api.HandleFunc("/queries", h.ResetQueries).Methods(http.MethodDelete)
+}
+
+// ListDeletedUsers godoc
+// @Summary List deleted users
+// @Description Get a paginated list of soft-deleted users awaiting purge
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} ListDeletedUsersResponse
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/deleted [get]
+func (h *AdminHandler) ListDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	page := 1
+	pageSize := 10
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	users, total, err := h.userService.ListDeleted(r.Context(), page, pageSize)
+	if err != nil {
+		logger.Error("Failed to list deleted users", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+
+	userResponses := make([]DeletedUserResponse, len(users))
+	for i, user := range users {
+		userResponses[i] = DeletedUserResponse{
+			UserResponse: UserResponse{
+				ID:        user.PublicID,
+				Email:     user.Email,
+				FirstName: user.FirstName,
+				LastName:  user.LastName,
+				Role:      user.Role,
+				Active:    user.Active,
+				Version:   user.Version,
+			},
+			DeletedAt: user.DeletedAt.Time,
+		}
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	response := ListDeletedUsersResponse{
+		Users:      userResponses,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreUser godoc
+// @Summary Restore deleted user
+// @Description Restore a soft-deleted user by ID, unless another user has since registered its email
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User public ID"
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/restore [post]
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := domain.ParsePublicID(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetDeletedByPublicID(r.Context(), id)
+	if err == nil {
+		err = h.userService.Restore(r.Context(), user.ID)
+	}
+	if err != nil {
+		if errors.Is(err, apperrs.ErrNotFound) {
+			http.Error(w, "Deleted user not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, apperrs.ErrAlreadyExists) {
+			http.Error(w, "Another user has the same email", http.StatusConflict)
+			return
+		}
+		logger.Error("Failed to restore user", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAuditEntries godoc
+// @Summary List audit log entries
+// @Description Get a paginated list of entity changes, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param entity query string false "Entity type (table name), e.g. users"
+// @Param entity_id query string false "Public ID of the entity"
+// @Param actor query string false "Public ID of the user who made the change"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge)
+// @Param from query string false "Start of the time range (RFC 3339, inclusive)"
+// @Param to query string false "End of the time range (RFC 3339, exclusive)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} ListAuditEntriesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/audit [get]
+func (h *AdminHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page := 1
+	pageSize := 10
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	filter := domain.AuditFilter{
+		EntityType: query.Get("entity"),
+		Action:     domain.AuditAction(query.Get("action")),
+	}
+	if v := query.Get("entity_id"); v != "" {
+		id, ok := domain.ParsePublicID(v)
+		if !ok {
+			http.Error(w, "Invalid entity_id", http.StatusBadRequest)
+			return
+		}
+		filter.EntityID = id
+	}
+	if v := query.Get("actor"); v != "" {
+		id, ok := domain.ParsePublicID(v)
+		if !ok {
+			http.Error(w, "Invalid actor", http.StatusBadRequest)
+			return
+		}
+		filter.ActorID = id
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "Invalid "+name+": expected RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+
+	entries, total, err := h.auditService.List(r.Context(), filter, page, pageSize)
+	if err != nil {
+		if errors.Is(err, apperrs.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Error("Failed to list audit entries", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	response := ListAuditEntriesResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CacheStats godoc
+// @Summary Get cache statistics
+// @Description Get hit, miss, coalesced load, invalidation and error counts of every repository cache since startup
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]CacheStatsResponse
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/cache [get]
+func (h *AdminHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	response := make(map[string]CacheStatsResponse, len(h.caches))
+	for name, stats := range h.caches {
+		snapshot := stats()
+		response[name] = CacheStatsResponse{Stats: snapshot, HitRatio: snapshot.HitRatio()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// TopQueries godoc
+// @Summary List the most expensive queries
+// @Description Get statistics of the SQL statements with the highest total time since startup or the last reset, aggregated by fingerprint: statements differing only in their values share a fingerprint. Percentiles cover the most recent executions.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Number of queries" default(20)
+// @Success 200 {object} TopQueriesResponse
+// @Failure 403 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/queries [get]
+func (h *AdminHandler) TopQueries(w http.ResponseWriter, r *http.Request) {
+	if h.queryStats == nil {
+		http.Error(w, "Query statistics are disabled", http.StatusNotImplemented)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TopQueriesResponse{Queries: h.queryStats.Top(limit)})
+}
+
+// ResetQueries godoc
+// @Summary Reset query statistics
+// @Description Discard the statistics of every query, e.g. before measuring a load test
+// @Tags admin
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/queries [delete]
+func (h *AdminHandler) ResetQueries(w http.ResponseWriter, r *http.Request) {
+	if h.queryStats == nil {
+		http.Error(w, "Query statistics are disabled", http.StatusNotImplemented)
+		return
+	}
+
+	h.queryStats.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"errors"
+	"go-server-boilerplate/internal/app/events"
 	"go-server-boilerplate/internal/app/ports"
 	"go-server-boilerplate/internal/infrastructure/auth"
 	"go-server-boilerplate/internal/infrastructure/database/models"
@@ -20,13 +21,15 @@ import (
 type AuthHandler struct {
 	userService ports.Service[models.User]
 	jwtManager  *auth.JWTManager
+	events      *events.Bus
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(userService ports.Service[models.User], jwtManager *auth.JWTManager) *AuthHandler {
+// NewAuthHandler creates a new auth handler; bus may be nil
+func NewAuthHandler(userService ports.Service[models.User], jwtManager *auth.JWTManager, bus *events.Bus) *AuthHandler {
 	return &AuthHandler{
 		userService: userService,
 		jwtManager:  jwtManager,
+		events:      bus,
 	}
 }
 
@@ -81,20 +84,16 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find user by email
-	// Note: This is a simplified implementation. In a real app, you'd have a FindByEmail method
-	users, _, err := h.userService.List(r.Context(), 1, 1)
-	if err != nil {
+	found, err := h.userService.FindOneBy(r.Context(), "email", req.Email)
+	if err != nil && !errors.Is(err, apperrs.ErrNotFound) {
 		logger.Error("Failed to query users", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
 	var user *models.User
-	for _, u := range users {
-		if u.Email == req.Email {
-			user = &u
-			break
-		}
+	if err == nil {
+		user = &found
 	}
 
 	if user == nil || !user.CheckPassword(req.Password) {
@@ -108,18 +107,19 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(user.ID, user.Role)
+	token, err := h.jwtManager.GenerateToken(user.ID, user.PublicID, user.Role)
 	if err != nil {
 		logger.Error("Failed to generate token", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
 	// Update last login
 	user.UpdateLastLogin()
-	if err := h.userService.Update(r.Context(), *user); err != nil {
+	if err := h.userService.Update(r.Context(), user); err != nil {
 		logger.Warn("Failed to update last login", zap.Error(err))
 	}
+	events.Publish(r.Context(), h.events, events.UserLoggedIn{UserID: user.ID, Email: user.Email, At: time.Now()})
 
 	// Calculate expiration time
 	expiresAt := time.Now().Add(time.Duration(24) * time.Hour) // Default 24 hours
@@ -128,12 +128,13 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		Token:     token,
 		ExpiresAt: expiresAt,
 		User: UserResponse{
-			ID:        user.ID,
+			ID:        user.PublicID,
 			Email:     user.Email,
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
 			Role:      user.Role,
 			Active:    user.Active,
+			Version:   user.Version,
 		},
 	}
 
@@ -161,20 +162,15 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if user already exists
-	users, _, err := h.userService.List(r.Context(), 1, 1)
-	if err != nil {
+	if _, err := h.userService.FindOneBy(r.Context(), "email", req.Email); err == nil {
+		http.Error(w, "User already exists", http.StatusConflict)
+		return
+	} else if !errors.Is(err, apperrs.ErrNotFound) {
 		logger.Error("Failed to query users", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
-	for _, u := range users {
-		if u.Email == req.Email {
-			http.Error(w, "User already exists", http.StatusConflict)
-			return
-		}
-	}
-
 	// Create new user
 	user := models.User{
 		Email:     req.Email,
@@ -187,24 +183,30 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Hash password
 	if err := user.SetPassword(req.Password); err != nil {
 		logger.Error("Failed to hash password", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
 	// Create user
-	if err := h.userService.Create(r.Context(), user); err != nil {
+	if err := h.userService.Create(r.Context(), &user); err != nil {
+		if errors.Is(err, apperrs.ErrInvalidInput) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		logger.Error("Failed to create user", zap.Error(err))
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		serverError(w, err, "Failed to create user")
 		return
 	}
+	events.Publish(r.Context(), h.events, events.UserRegistered{UserID: user.ID, Email: user.Email})
 
 	response := UserResponse{
-		ID:        user.ID,
+		ID:        user.PublicID,
 		Email:     user.Email,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Role:      user.Role,
 		Active:    user.Active,
+		Version:   user.Version,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -252,7 +254,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		logger.Error("Failed to get user", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
@@ -262,10 +264,10 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate new token
-	newToken, err := h.jwtManager.GenerateToken(user.ID, user.Role)
+	newToken, err := h.jwtManager.GenerateToken(user.ID, user.PublicID, user.Role)
 	if err != nil {
 		logger.Error("Failed to generate token", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		serverError(w, err, "Internal server error")
 		return
 	}
 
@@ -275,12 +277,13 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		Token:     newToken,
 		ExpiresAt: expiresAt,
 		User: UserResponse{
-			ID:        user.ID,
+			ID:        user.PublicID,
 			Email:     user.Email,
 			FirstName: user.FirstName,
 			LastName:  user.LastName,
 			Role:      user.Role,
 			Active:    user.Active,
+			Version:   user.Version,
 		},
 	}
 
@@ -0,0 +1,666 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/jsonpatch"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/middleware"
+	"go-server-boilerplate/internal/pkg/validator"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Pagination bounds of list routes
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// CRUDOperation identifies one of the routes registered by a CRUDHandler
+type CRUDOperation string
+
+// CRUD operations
+const (
+	OpList   CRUDOperation = "list"
+	OpGet    CRUDOperation = "get"
+	OpCreate CRUDOperation = "create"
+	OpUpdate CRUDOperation = "update"
+	OpDelete CRUDOperation = "delete"
+)
+
+// CRUDMapper converts between the requests and responses of a resource and
+// its entities. An error returned by Create or Update that matches
+// apperrs.ErrInvalidInput is reported to the client with 400; any other
+// error is a server error.
+type CRUDMapper[T domain.Entity, C any, U any, R any] struct {
+	// Create builds a new entity from a validated create request
+	Create func(req C) (*T, error)
+
+	// Update replaces the updatable fields of a stored entity with a
+	// validated update request
+	Update func(entity *T, req U) error
+
+	// Updatable renders the updatable fields of an entity as an update
+	// request, the document PATCH requests are applied to
+	Updatable func(entity T) U
+
+	// Response renders an entity
+	Response func(entity T) R
+}
+
+// FilterParser converts the value of a list query parameter to the value
+// the entity field is compared with
+type FilterParser func(value string) (any, error)
+
+// Filter parsers for common field types
+var (
+	StringFilter FilterParser = func(value string) (any, error) { return value, nil }
+	BoolFilter   FilterParser = func(value string) (any, error) { return strconv.ParseBool(value) }
+	IntFilter    FilterParser = func(value string) (any, error) { return strconv.ParseInt(value, 10, 64) }
+)
+
+// crudRoutes holds the route options of a CRUDHandler
+type crudRoutes struct {
+	auth       *middleware.AuthMiddleware
+	public     map[CRUDOperation]bool
+	roles      map[CRUDOperation][]string
+	filters    map[string]FilterParser
+	operations []CRUDOperation
+}
+
+// CRUDOption configures the routes of a CRUDHandler
+type CRUDOption func(*crudRoutes)
+
+// WithAuth requires an authenticated user on every route except the public
+// operations
+func WithAuth(auth *middleware.AuthMiddleware, public ...CRUDOperation) CRUDOption {
+	return func(r *crudRoutes) {
+		r.auth = auth
+		for _, op := range public {
+			r.public[op] = true
+		}
+	}
+}
+
+// WithRoles restricts operations to users having one of roles; it requires
+// WithAuth, and all operations are restricted when none are given
+func WithRoles(roles []string, ops ...CRUDOperation) CRUDOption {
+	return func(r *crudRoutes) {
+		if len(ops) == 0 {
+			ops = allCRUDOperations
+		}
+		for _, op := range ops {
+			r.roles[op] = roles
+		}
+	}
+}
+
+// WithFilter allows the list route to filter on field, given as a query
+// parameter of the same name whose value parse converts
+func WithFilter(field string, parse FilterParser) CRUDOption {
+	return func(r *crudRoutes) {
+		r.filters[field] = parse
+	}
+}
+
+// WithOperations registers only the given operations
+func WithOperations(ops ...CRUDOperation) CRUDOption {
+	return func(r *crudRoutes) {
+		r.operations = ops
+	}
+}
+
+// allCRUDOperations lists the operations registered by default
+var allCRUDOperations = []CRUDOperation{OpList, OpGet, OpCreate, OpUpdate, OpDelete}
+
+// CRUDHandler serves list, get, create, update and delete routes for the
+// entities of a service. Create and update bodies are decoded into C and U
+// and validated with their validate tags; entities are rendered as R. PUT
+// replaces every field of U, while PATCH applies a JSON Merge Patch or a
+// JSON Patch to the U rendered from the stored entity.
+// Entities are addressed by public ID, versions are exposed as ETags and
+// honoured in If-Match and If-None-Match, and errors are rendered the same
+// way for every resource.
+type CRUDHandler[T domain.Entity, C any, U any, R any] struct {
+	name       string
+	collection string
+	service    ports.Service[T]
+	mapper     CRUDMapper[T, C, U, R]
+	validator  *validator.Validator
+	routes     crudRoutes
+}
+
+// NewCRUDHandler creates a handler for the resource called name, e.g.
+// "user", whose lists are returned under collection, e.g. "users"
+func NewCRUDHandler[T domain.Entity, C any, U any, R any](name, collection string, service ports.Service[T], mapper CRUDMapper[T, C, U, R]) *CRUDHandler[T, C, U, R] {
+	return &CRUDHandler[T, C, U, R]{
+		name:       name,
+		collection: collection,
+		service:    service,
+		mapper:     mapper,
+		validator:  validator.New(),
+	}
+}
+
+// RegisterRoutes registers the routes of the resource on router, which is
+// usually a subrouter for the resource path: GET and POST on its root, and
+// GET, PUT, PATCH and DELETE on /{id}. The options decide which routes are
+// registered, who may use them and how lists may be filtered.
+func (h *CRUDHandler[T, C, U, R]) RegisterRoutes(router *mux.Router, opts ...CRUDOption) {
+	h.routes = crudRoutes{
+		public:     make(map[CRUDOperation]bool),
+		roles:      make(map[CRUDOperation][]string),
+		filters:    make(map[string]FilterParser),
+		operations: allCRUDOperations,
+	}
+	for _, opt := range opts {
+		opt(&h.routes)
+	}
+
+	for _, op := range h.routes.operations {
+		switch op {
+		case OpList:
+			router.Handle("", h.protect(op, h.List)).Methods(http.MethodGet)
+		case OpGet:
+			router.Handle("/{id}", h.protect(op, h.Get)).Methods(http.MethodGet)
+		case OpCreate:
+			router.Handle("", h.protect(op, h.Create)).Methods(http.MethodPost)
+		case OpUpdate:
+			router.Handle("/{id}", h.protect(op, h.Update)).Methods(http.MethodPut)
+			router.Handle("/{id}", h.protect(op, h.Patch)).Methods(http.MethodPatch)
+		case OpDelete:
+			router.Handle("/{id}", h.protect(op, h.Delete)).Methods(http.MethodDelete)
+		}
+	}
+}
+
+// protect wraps the handler of op with the configured authentication and
+// role checks
+func (h *CRUDHandler[T, C, U, R]) protect(op CRUDOperation, next http.HandlerFunc) http.Handler {
+	var handler http.Handler = next
+	if h.routes.auth == nil || h.routes.public[op] {
+		return handler
+	}
+	if roles := h.routes.roles[op]; len(roles) > 0 {
+		handler = h.routes.auth.RoleRequired(roles...)(handler)
+	}
+	return h.routes.auth.AuthRequiredMiddleware(handler)
+}
+
+// List serves a page of entities, optionally filtered by the allowed query
+// parameters
+func (h *CRUDHandler[T, C, U, R]) List(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := pagination(r)
+
+	filters := make(map[string]any)
+	query := r.URL.Query()
+	for field, parse := range h.routes.filters {
+		if !query.Has(field) {
+			continue
+		}
+		value, err := parse(query.Get(field))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s filter", field), http.StatusBadRequest)
+			return
+		}
+		filters[field] = value
+	}
+
+	entities, total, err := h.service.ListBy(r.Context(), filters, page, pageSize)
+	if err != nil {
+		h.fail(w, r, err, "list")
+		return
+	}
+
+	items := make([]R, len(entities))
+	for i, entity := range entities {
+		items[i] = h.mapper.Response(entity)
+	}
+	body, err := marshalPage(h.collection, items, Pagination{
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+	if err != nil {
+		h.fail(w, r, err, "list")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// Get serves one entity
+func (h *CRUDHandler[T, C, U, R]) Get(w http.ResponseWriter, r *http.Request) {
+	entity, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+
+	version := versionOf(&entity)
+	setETag(w, version)
+	if ifNoneMatchSatisfied(r, version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.respond(w, http.StatusOK, entity)
+}
+
+// Create creates an entity from the request body
+func (h *CRUDHandler[T, C, U, R]) Create(w http.ResponseWriter, r *http.Request) {
+	var req C
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	entity, err := h.mapper.Create(req)
+	if err != nil {
+		h.fail(w, r, err, "create")
+		return
+	}
+	if err := h.service.Create(r.Context(), entity); err != nil {
+		h.fail(w, r, err, "create")
+		return
+	}
+
+	setETag(w, versionOf(entity))
+	h.respond(w, http.StatusCreated, *entity)
+}
+
+// Update replaces the updatable fields of an entity with the request body,
+// which must set every one of them; the write is conditional on the version
+// read, and on If-Match when given
+func (h *CRUDHandler[T, C, U, R]) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.publicID(w, r)
+	if !ok {
+		return
+	}
+	var req U
+	if !h.decodeReplacement(w, r, &req) {
+		return
+	}
+
+	entity, err := h.service.GetByPublicID(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err, "get")
+		return
+	}
+	if ifMatchFailed(r, versionOf(&entity)) {
+		http.Error(w, h.title()+" has been modified", http.StatusPreconditionFailed)
+		return
+	}
+	h.save(w, r, &entity, req)
+}
+
+// Patch applies the request body to the updatable fields of an entity, as
+// a JSON Merge Patch or a JSON Patch depending on its Content-Type; plain
+// JSON is taken as a merge patch. The patched fields are validated as for
+// Update, and the write is conditional in the same way.
+func (h *CRUDHandler[T, C, U, R]) Patch(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.publicID(w, r)
+	if !ok {
+		return
+	}
+	apply, ok := h.decodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	entity, err := h.service.GetByPublicID(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err, "get")
+		return
+	}
+	if ifMatchFailed(r, versionOf(&entity)) {
+		http.Error(w, h.title()+" has been modified", http.StatusPreconditionFailed)
+		return
+	}
+
+	current, err := json.Marshal(h.mapper.Updatable(entity))
+	if err != nil {
+		h.fail(w, r, err, "update")
+		return
+	}
+	patched, err := apply(current)
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, jsonpatch.ErrInvalidPath):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		h.fail(w, r, err, "update")
+		return
+	}
+
+	var req U
+	if err := decodeStrict(bytes.NewReader(patched), &req); err != nil {
+		http.Error(w, "Patched "+h.name+" is invalid: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.save(w, r, &entity, req)
+}
+
+// save applies a validated update request to entity and stores it
+func (h *CRUDHandler[T, C, U, R]) save(w http.ResponseWriter, r *http.Request, entity *T, req U) {
+	if err := h.mapper.Update(entity, req); err != nil {
+		h.fail(w, r, err, "update")
+		return
+	}
+	if err := h.service.Update(r.Context(), entity); err != nil {
+		h.fail(w, r, err, "update")
+		return
+	}
+
+	setETag(w, versionOf(entity))
+	h.respond(w, http.StatusOK, *entity)
+}
+
+// Delete removes an entity, conditionally on If-Match when given. The delete
+// is conditional on the version read either way, so that a change made in
+// between is reported rather than deleted unseen.
+func (h *CRUDHandler[T, C, U, R]) Delete(w http.ResponseWriter, r *http.Request) {
+	entity, ok := h.find(w, r)
+	if !ok {
+		return
+	}
+	if ifMatchFailed(r, versionOf(&entity)) {
+		http.Error(w, h.title()+" has been modified", http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.service.DeleteVersion(r.Context(), entity.GetID(), versionOf(&entity)); err != nil {
+		h.fail(w, r, err, "delete")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// find loads the entity addressed by the request, rendering the error if it
+// cannot
+func (h *CRUDHandler[T, C, U, R]) find(w http.ResponseWriter, r *http.Request) (T, bool) {
+	var zero T
+	id, ok := h.publicID(w, r)
+	if !ok {
+		return zero, false
+	}
+	entity, err := h.service.GetByPublicID(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err, "get")
+		return zero, false
+	}
+	return entity, true
+}
+
+// publicID parses the public ID in the request path
+func (h *CRUDHandler[T, C, U, R]) publicID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, ok := domain.ParsePublicID(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "Invalid "+h.name+" ID", http.StatusBadRequest)
+	}
+	return id, ok
+}
+
+// decode decodes and validates the request body into req
+func (h *CRUDHandler[T, C, U, R]) decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// decodeReplacement decodes and validates a PUT body into req. As the body
+// replaces the resource, it must set every field of the request, null
+// included, and may not set any other.
+func (h *CRUDHandler[T, C, U, R]) decodeReplacement(w http.ResponseWriter, r *http.Request, req *U) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	for _, field := range jsonFields(reflect.TypeFor[U]()) {
+		if _, ok := members[field]; !ok {
+			http.Error(w, fmt.Sprintf("Missing %s: PUT replaces the whole %s, use PATCH to change some fields", field, h.name), http.StatusBadRequest)
+			return false
+		}
+	}
+	if err := decodeStrict(bytes.NewReader(body), req); err != nil {
+		http.Error(w, "Invalid "+h.name+": "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// decodePatch reads a PATCH body and returns the function applying it to a
+// JSON document, chosen by the Content-Type of the request
+func (h *CRUDHandler[T, C, U, R]) decodePatch(w http.ResponseWriter, r *http.Request) (func(doc []byte) ([]byte, error), bool) {
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			http.Error(w, "Invalid Content-Type", http.StatusBadRequest)
+			return nil, false
+		}
+		mediaType = parsed
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+
+	switch mediaType {
+	case jsonpatch.MergePatchMediaType, "application/json":
+		if !json.Valid(body) {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return nil, false
+		}
+		return func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}, true
+	case jsonpatch.PatchMediaType:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		return patch.Apply, true
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		http.Error(w, "Unsupported patch format "+mediaType, http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+}
+
+// acceptPatch lists the patch formats PATCH routes accept
+var acceptPatch = jsonpatch.MergePatchMediaType + ", " + jsonpatch.PatchMediaType
+
+// decodeStrict decodes one JSON value into v, rejecting unknown fields
+func decodeStrict(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// jsonFields returns the names of the fields of struct type t as they are
+// encoded in JSON, including those of embedded structs
+func jsonFields(t reflect.Type) []string {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || len(field.Index) > 1 && !promoted(t, field.Index) {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// promoted reports whether the field at index is promoted into t through
+// untagged embedded structs, as encoding/json does
+func promoted(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := t.Field(i)
+		if !field.Anonymous || field.Tag.Get("json") != "" {
+			return false
+		}
+		t = indirect(field.Type)
+	}
+	return true
+}
+
+// indirect returns the type t points to, or t if it is not a pointer
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// respond renders entity with status
+func (h *CRUDHandler[T, C, U, R]) respond(w http.ResponseWriter, status int, entity T) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(h.mapper.Response(entity))
+}
+
+// fail renders the error of an action: client errors with their status and
+// anything else as a logged server error
+func (h *CRUDHandler[T, C, U, R]) fail(w http.ResponseWriter, r *http.Request, err error, action string) {
+	switch {
+	case errors.Is(err, apperrs.ErrNotFound):
+		http.Error(w, h.title()+" not found", http.StatusNotFound)
+	case errors.Is(err, apperrs.ErrConflict):
+		if r.Header.Get("If-Match") != "" {
+			http.Error(w, h.title()+" has been modified", http.StatusPreconditionFailed)
+			return
+		}
+		http.Error(w, h.title()+" was modified concurrently", http.StatusConflict)
+	case errors.Is(err, apperrs.ErrAlreadyExists):
+		http.Error(w, h.title()+" already exists", http.StatusConflict)
+	case errors.Is(err, apperrs.ErrInvalidInput), errors.Is(err, apperrs.ErrBadRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrs.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		logger.Error("Failed to "+action+" "+h.name, zap.Error(err))
+		serverError(w, err, "Failed to "+action+" "+h.name)
+	}
+}
+
+// title returns the resource name capitalized for messages
+func (h *CRUDHandler[T, C, U, R]) title() string {
+	if h.name == "" {
+		return h.name
+	}
+	return strings.ToUpper(h.name[:1]) + h.name[1:]
+}
+
+// Pagination describes the page returned by a list route
+type Pagination struct {
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// pagination reads the page and page_size query parameters, ignoring
+// invalid values
+func pagination(r *http.Request) (int, int) {
+	page, pageSize := 1, defaultPageSize
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(r.URL.Query().Get("page_size")); err == nil && ps > 0 && ps <= maxPageSize {
+		pageSize = ps
+	}
+	return page, pageSize
+}
+
+// marshalPage encodes items under collection followed by the pagination
+// fields, e.g. {"users":[...],"total":1,...}
+func marshalPage[R any](collection string, items []R, p Pagination) ([]byte, error) {
+	key, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+	list, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	buf.Write(key)
+	buf.WriteByte(':')
+	buf.Write(list)
+	buf.WriteByte(',')
+	buf.Write(fields[1:])
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// versionOf returns the version of an entity embedding domain.BaseEntity,
+// or 0 for other entities
+func versionOf[T domain.Entity](entity *T) uint {
+	if base := domain.BaseOf(entity); base != nil {
+		return base.Version
+	}
+	return 0
+}
@@ -0,0 +1,217 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/app/services"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	"go-server-boilerplate/internal/interfaces/api"
+
+	"github.com/gorilla/mux"
+)
+
+type noteRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type noteUpdate struct {
+	Email string `json:"email" validate:"required,email"`
+	Title string `json:"title"`
+}
+
+type noteResponse struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Title string `json:"title"`
+}
+
+func newCRUDRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	tm := memory.NewTransactionManager()
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm)
+
+	router := mux.NewRouter()
+	newNoteHandler(service).RegisterRoutes(router.PathPrefix("/notes").Subrouter(),
+		api.WithFilter("email", api.StringFilter),
+		api.WithOperations(api.OpList, api.OpGet, api.OpCreate, api.OpUpdate),
+	)
+	return router
+}
+
+func newNoteHandler(service ports.Service[models.User]) *api.CRUDHandler[models.User, noteRequest, noteUpdate, noteResponse] {
+	return api.NewCRUDHandler("note", "notes", service, api.CRUDMapper[models.User, noteRequest, noteUpdate, noteResponse]{
+		Create: func(req noteRequest) (*models.User, error) {
+			return &models.User{Email: req.Email, PasswordHash: "hash", Role: "user"}, nil
+		},
+		Update: func(user *models.User, req noteUpdate) error {
+			user.Email = req.Email
+			user.FirstName = req.Title
+			return nil
+		},
+		Updatable: func(user models.User) noteUpdate {
+			return noteUpdate{Email: user.Email, Title: user.FirstName}
+		},
+		Response: func(user models.User) noteResponse {
+			return noteResponse{ID: user.PublicID, Email: user.Email, Title: user.FirstName}
+		},
+	})
+}
+
+func serve(router http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCRUDHandler(t *testing.T) {
+	router := newCRUDRouter(t)
+
+	if rec := serve(router, http.MethodPost, "/notes", `{"email":"nope"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected an invalid request to be rejected, got %d", rec.Code)
+	}
+	var created noteResponse
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		rec := serve(router, http.MethodPost, "/notes", `{"email":"`+email+`"}`)
+		if rec.Code != http.StatusCreated || rec.Header().Get("ETag") != `"1"` {
+			t.Fatalf("create: got %d with ETag %q", rec.Code, rec.Header().Get("ETag"))
+		}
+		json.NewDecoder(rec.Body).Decode(&created)
+	}
+
+	rec := serve(router, http.MethodGet, "/notes?email=B@example.com", "")
+	var page struct {
+		Notes []noteResponse `json:"notes"`
+		Total int64          `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil || page.Total != 1 || page.Notes[0].ID != created.ID {
+		t.Fatalf("expected the filter to find only %s, got %+v (%v)", created.ID, page, err)
+	}
+
+	if rec := serve(router, http.MethodGet, "/notes/"+created.ID, "", "If-None-Match", `"1"`); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for a current ETag, got %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodPut, "/notes/"+created.ID, `{"email":"c@example.com","title":""}`, "If-Match", `"7"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a stale ETag, got %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodPatch, "/notes/"+created.ID, `{"email":"c@example.com"}`, "If-Match", `"1"`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("update: got %d with ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	if rec := serve(router, http.MethodGet, "/notes/42", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid ID, got %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodDelete, "/notes/"+created.ID, ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected the delete route to be disabled, got %d", rec.Code)
+	}
+}
+
+// racingService changes every entity it loads by public ID before handing
+// it out, as a concurrent request between a handler's find and its write would
+type racingService struct {
+	ports.Service[models.User]
+}
+
+func (s racingService) GetByPublicID(ctx context.Context, publicID string) (models.User, error) {
+	user, err := s.Service.GetByPublicID(ctx, publicID)
+	if err != nil {
+		return user, err
+	}
+	changed := user
+	changed.FirstName = "changed"
+	if err := s.Service.Update(ctx, &changed); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+func TestCRUDHandlerDeleteIsConditional(t *testing.T) {
+	tm := memory.NewTransactionManager()
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm)
+	router := mux.NewRouter()
+	newNoteHandler(racingService{service}).RegisterRoutes(router.PathPrefix("/notes").Subrouter())
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash", Role: "user"}
+	if err := service.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if rec := serve(router, http.MethodDelete, "/notes/"+user.PublicID, "", "If-Match", `"1"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 for a change made after the If-Match check, got %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodDelete, "/notes/"+user.PublicID, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a change made before the delete, got %d", rec.Code)
+	}
+	if _, err := service.GetByPublicID(context.Background(), user.PublicID); err != nil {
+		t.Fatalf("expected the changed entity to survive, got %v", err)
+	}
+}
+
+func TestCRUDHandlerReplaceAndPatch(t *testing.T) {
+	router := newCRUDRouter(t)
+	rec := serve(router, http.MethodPost, "/notes", `{"email":"a@example.com"}`)
+	var note noteResponse
+	json.NewDecoder(rec.Body).Decode(&note)
+	target := "/notes/" + note.ID
+
+	// PUT replaces the whole resource
+	if rec := serve(router, http.MethodPut, target, `{"email":"b@example.com"}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "title") {
+		t.Fatalf("expected a PUT without title to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodPut, target, `{"email":"b@example.com","title":"","role":"admin"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a PUT with an unknown field to be rejected, got %d", rec.Code)
+	}
+	if rec := serve(router, http.MethodPut, target, `{"email":"b@example.com","title":"first"}`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("replace: got %d with ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        noteResponse
+	}{
+		{"merge patch", "application/merge-patch+json", `{"title":"second"}`, http.StatusOK,
+			noteResponse{Email: "b@example.com", Title: "second"}},
+		{"merge patch removing a member", "application/merge-patch+json; charset=utf-8", `{"title":null}`, http.StatusOK,
+			noteResponse{Email: "b@example.com"}},
+		{"invalid merge patch", "application/merge-patch+json", `{"title":`, http.StatusBadRequest, noteResponse{}},
+		{"merge patch failing validation", "application/merge-patch+json", `{"email":"nope"}`, http.StatusBadRequest, noteResponse{}},
+		{"merge patch adding a field", "application/merge-patch+json", `{"role":"admin"}`, http.StatusUnprocessableEntity, noteResponse{}},
+		{"json patch", "application/json-patch+json",
+			`[{"op":"test","path":"/email","value":"b@example.com"},{"op":"replace","path":"/title","value":"third"},{"op":"copy","from":"/title","path":"/email"},{"op":"replace","path":"/email","value":"c@example.com"}]`,
+			http.StatusOK, noteResponse{Email: "c@example.com", Title: "third"}},
+		{"json patch failing a test", "application/json-patch+json",
+			`[{"op":"test","path":"/email","value":"b@example.com"},{"op":"replace","path":"/title","value":"lost"}]`, http.StatusConflict, noteResponse{}},
+		{"json patch on a missing member", "application/json-patch+json", `[{"op":"replace","path":"/role","value":"admin"}]`, http.StatusUnprocessableEntity, noteResponse{}},
+		{"malformed json patch", "application/json-patch+json", `[{"op":"jump","path":"/title"}]`, http.StatusBadRequest, noteResponse{}},
+		{"unsupported format", "text/plain", `title=x`, http.StatusUnsupportedMediaType, noteResponse{}},
+	}
+	for _, c := range cases {
+		rec := serve(router, http.MethodPatch, target, c.body, "Content-Type", c.contentType)
+		if rec.Code != c.status {
+			t.Fatalf("%s: expected %d, got %d: %s", c.name, c.status, rec.Code, rec.Body)
+		}
+		if c.status == http.StatusUnsupportedMediaType && !strings.Contains(rec.Header().Get("Accept-Patch"), "application/json-patch+json") {
+			t.Fatalf("%s: expected Accept-Patch to list the formats, got %q", c.name, rec.Header().Get("Accept-Patch"))
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		var got noteResponse
+		json.NewDecoder(rec.Body).Decode(&got)
+		if got.Email != c.want.Email || got.Title != c.want.Title {
+			t.Fatalf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
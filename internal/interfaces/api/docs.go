@@ -29,3 +29,6 @@ package api
 
 // @tag.name health
 // @tag.description Health check endpoints
+
+// @tag.name admin
+// @tag.description Administrative endpoints
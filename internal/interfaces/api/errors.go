@@ -0,0 +1,23 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// retryAfter is the Retry-After value, in seconds, sent with 503 responses
+const retryAfter = "5"
+
+// serverError responds to a failure the client cannot fix: 503 with a
+// Retry-After header when a dependency is unavailable, such as the database
+// behind an open circuit breaker, otherwise 500 with message
+func serverError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, apperrs.ErrServiceUnavailable) {
+		w.Header().Set("Retry-After", retryAfter)
+		http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// entityTag returns the strong ETag representing an entity version
+func entityTag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// setETag sets the ETag response header for an entity version
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", entityTag(version))
+}
+
+// matchesETag reports whether a comma-separated If-Match/If-None-Match header
+// value matches the given entity version. Weak tags never match because
+// versions are compared strongly.
+func matchesETag(header string, version uint) bool {
+	current := entityTag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchFailed reports whether the request carries an If-Match precondition
+// that the current entity version does not satisfy
+func ifMatchFailed(r *http.Request, version uint) bool {
+	header := r.Header.Get("If-Match")
+	return header != "" && !matchesETag(header, version)
+}
+
+// ifNoneMatchSatisfied reports whether the client already holds the current
+// entity version, allowing a 304 Not Modified response
+func ifNoneMatchSatisfied(r *http.Request, version uint) bool {
+	header := r.Header.Get("If-None-Match")
+	return header != "" && matchesETag(header, version)
+}
@@ -5,73 +5,94 @@ import (
 	"net/http"
 	"time"
 
+	"go-server-boilerplate/internal/pkg/health"
+
 	"github.com/gorilla/mux"
 )
 
 // HealthResponse represents the health check response structure
 type HealthResponse struct {
-	Status    string    `json:"status" example:"ok"`
-	Timestamp time.Time `json:"timestamp" example:"2023-01-01T12:00:00Z"`
-	Version   string    `json:"version" example:"1.0.0"`
-	Services  []Service `json:"services"`
+	Status    string          `json:"status" example:"ok"`
+	Timestamp time.Time       `json:"timestamp" example:"2023-01-01T12:00:00Z"`
+	Version   string          `json:"version" example:"1.0.0"`
+	Checks    []health.Result `json:"checks"`
+}
+
+// HealthHandler serves the probes backed by a health check registry
+type HealthHandler struct {
+	registry *health.Registry
+	version  string
 }
 
-// Service represents a service health check
-type Service struct {
-	Name   string `json:"name" example:"database"`
-	Status string `json:"status" example:"ok"`
-	Error  string `json:"error,omitempty" example:""`
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(registry *health.Registry, version string) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+		version:  version,
+	}
 }
 
 // RegisterHealthRoutesMux registers health check routes on Gorilla Mux
-func RegisterHealthRoutesMux(router *mux.Router) {
-	router.HandleFunc("/health", HealthCheck).Methods(http.MethodGet)
+func (h *HealthHandler) RegisterHealthRoutesMux(router *mux.Router) {
+	router.HandleFunc("/livez", h.Livez).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", h.Readyz).Methods(http.MethodGet)
+	router.HandleFunc("/startupz", h.Startupz).Methods(http.MethodGet)
+	router.HandleFunc("/health", h.Readyz).Methods(http.MethodGet)
 }
 
-// HealthCheck godoc
-// @Summary Health check endpoint
-// @Description Get the health status of the API and its dependencies
+// Livez godoc
+// @Summary Liveness probe
+// @Description Report whether the process is alive. Fails only when a liveness check fails, in which case the instance should be restarted.
 // @Tags health
-// @Accept json
 // @Produce json
 // @Success 200 {object} HealthResponse
 // @Failure 503 {object} HealthResponse
-// @Router /health [get]
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	health := HealthResponse{
-		Status:    "ok",
-		Timestamp: time.Now(),
-		Version:   "1.0.0",
-		Services:  []Service{},
-	}
-
-	// Check database status
-	dbService := Service{
-		Name:   "database",
-		Status: "ok",
-	}
+// @Router /livez [get]
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	h.probe(w, r, health.Liveness)
+}
 
-	// Check cache status
-	cacheService := Service{
-		Name:   "cache",
-		Status: "ok",
-	}
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Report whether the instance can serve traffic. Fails when a critical dependency is unhealthy or the server is shutting down; non-critical failures report a degraded status with 200. Also served at /health.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	h.probe(w, r, health.Readiness)
+}
 
-	// Add services health
-	health.Services = append(health.Services, dbService, cacheService)
+// Startupz godoc
+// @Summary Startup probe
+// @Description Report whether the instance has finished starting, e.g. whether its migrations are applied. Once it has passed it always succeeds.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /startupz [get]
+func (h *HealthHandler) Startupz(w http.ResponseWriter, r *http.Request) {
+	h.probe(w, r, health.Startup)
+}
 
-	// Check if any service is not ok
-	for _, service := range health.Services {
-		if service.Status != "ok" {
-			health.Status = "degraded"
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_ = json.NewEncoder(w).Encode(health)
-			return
-		}
+// probe runs the checks of a probe and writes the report
+func (h *HealthHandler) probe(w http.ResponseWriter, r *http.Request, probe health.Probe) {
+	report := h.registry.Run(r.Context(), probe)
+	response := HealthResponse{
+		Status:    report.Status,
+		Timestamp: time.Now(),
+		Version:   h.version,
+		Checks:    report.Checks,
 	}
 
+	// Probes must never be cached by proxies
+	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(health)
+	if report.Status == health.StatusFailing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = json.NewEncoder(w).Encode(response)
 }
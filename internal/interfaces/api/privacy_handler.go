@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-server-boilerplate/internal/app/domain"
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+	"go-server-boilerplate/internal/pkg/middleware"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// exportRetryAfter is the Retry-After value, in seconds, sent while a data
+// export is being built
+const exportRetryAfter = 5
+
+// PrivacyHandler handles data subject requests: exports and erasure of the
+// personal data held about a user
+type PrivacyHandler struct {
+	privacyService ports.PrivacyService
+	userService    ports.Service[models.User]
+}
+
+// NewPrivacyHandler creates a new privacy handler
+func NewPrivacyHandler(privacyService ports.PrivacyService, userService ports.Service[models.User]) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+		userService:    userService,
+	}
+}
+
+// DataExportResponse represents the state of a data export being built
+type DataExportResponse struct {
+	Status    domain.DataExportStatus `json:"status"`
+	CreatedAt time.Time               `json:"created_at"`
+	ExpiresAt time.Time               `json:"expires_at"`
+}
+
+// EraseAccountRequest represents the request to erase one's own account
+type EraseAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// RegisterPrivacyRoutes registers privacy routes. They must be registered
+// ahead of the user routes, whose /{id} would otherwise match "me".
+func (h *PrivacyHandler) RegisterPrivacyRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	me := router.PathPrefix("/api/v1/users/me").Subrouter()
+	me.Use(authMiddleware.AuthRequiredMiddleware)
+	me.HandleFunc("/data-export", h.ExportMyData).Methods(http.MethodGet)
+	me.HandleFunc("", h.EraseMyAccount).Methods(http.MethodDelete)
+
+	admin := router.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(authMiddleware.AuthRequiredMiddleware)
+	admin.Use(authMiddleware.RoleRequired("admin"))
+	admin.HandleFunc("/users/{id}/erase", h.EraseUser).Methods(http.MethodPost)
+}
+
+// ExportMyData godoc
+// @Summary Export my data
+// @Description Download a zip archive of all the personal data held about the authenticated user: profile, audit entries and related records, one JSON file per source. The archive is built in the background: the first request starts it and returns 202 with a Retry-After header until it is ready. A ready archive can be downloaded until it expires; the next request then starts a new one.
+// @Tags users
+// @Produce application/zip
+// @Produce json
+// @Success 200 {file} file
+// @Success 202 {object} DataExportResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/me/data-export [get]
+func (h *PrivacyHandler) ExportMyData(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.ExtractUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	export, err := h.privacyService.Export(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to export user data", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+
+	if export.Status != domain.DataExportReady {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(exportRetryAfter))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(DataExportResponse{
+			Status:    export.Status,
+			CreatedAt: export.CreatedAt,
+			ExpiresAt: export.ExpiresAt,
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("data-export-%s.zip", export.CompletedAt.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Archive)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(export.Archive)
+}
+
+// EraseMyAccount godoc
+// @Summary Erase my account
+// @Description Erase the personal data held about the authenticated user, confirmed with their password. The account is anonymized and deactivated, related records are anonymized or deleted, and export archives are removed. This cannot be undone.
+// @Tags users
+// @Accept json
+// @Param request body EraseAccountRequest true "Password confirmation"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/me [delete]
+func (h *PrivacyHandler) EraseMyAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.ExtractUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req EraseAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, apperrs.ErrNotFound) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		logger.Error("Failed to get user", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	h.erase(w, r, user.ID)
+}
+
+// EraseUser godoc
+// @Summary Erase user
+// @Description Erase the personal data held about a user, e.g. to answer a deletion request received out of band. The user is anonymized and deactivated, related records are anonymized or deleted, and export archives are removed. This cannot be undone.
+// @Tags admin
+// @Param id path string true "User public ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/admin/users/{id}/erase [post]
+func (h *PrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := domain.ParsePublicID(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userService.GetByPublicID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, apperrs.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		logger.Error("Failed to get user", zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+
+	h.erase(w, r, user.ID)
+}
+
+// erase erases the data of a user and responds 204
+func (h *PrivacyHandler) erase(w http.ResponseWriter, r *http.Request, userID uint) {
+	if err := h.privacyService.Erase(r.Context(), userID); err != nil {
+		logger.Error("Failed to erase user data", zap.Uint("user_id", userID), zap.Error(err))
+		serverError(w, err, "Internal server error")
+		return
+	}
+	logger.Info("Erased user data", zap.Uint("user_id", userID))
+	w.WriteHeader(http.StatusNoContent)
+}
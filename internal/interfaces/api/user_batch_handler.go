@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// maxBatchItems bounds the number of items in a single batch request
+const maxBatchItems = 1000
+
+// Batch actions
+const (
+	BatchActionCreate = "create"
+	BatchActionUpdate = "update"
+	BatchActionDelete = "delete"
+)
+
+// BatchUsersRequest represents a bulk user operation. Items are
+// CreateUserRequest, BatchUpdateUserItem or BatchDeleteUserItem objects
+// depending on the action.
+type BatchUsersRequest struct {
+	Action string            `json:"action" validate:"required,oneof=create update delete"`
+	Atomic bool              `json:"atomic"`
+	Items  []json.RawMessage `json:"items" validate:"required,min=1"`
+}
+
+// BatchUpdateUserItem represents one update of a batch; the fields left out
+// keep their value, and the update only applies if the user is still at the
+// given version
+type BatchUpdateUserItem struct {
+	ID        string  `json:"id" validate:"required,uuid"`
+	Version   uint    `json:"version" validate:"required"`
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+	Active    *bool   `json:"active,omitempty"`
+}
+
+// BatchDeleteUserItem represents one deletion of a batch
+type BatchDeleteUserItem struct {
+	ID string `json:"id" validate:"required,uuid"`
+}
+
+// BatchItemResult reports the outcome of one batch item. Status is the HTTP
+// status the item would have had as a single request; items that succeeded
+// but were rolled back with an atomic batch report 424 Failed Dependency.
+type BatchItemResult struct {
+	Index  int           `json:"index"`
+	Status int           `json:"status"`
+	Error  string        `json:"error,omitempty"`
+	User   *UserResponse `json:"user,omitempty"`
+}
+
+// BatchUsersResponse represents the response of a bulk user operation
+type BatchUsersResponse struct {
+	Action    string            `json:"action"`
+	Atomic    bool              `json:"atomic"`
+	Committed bool              `json:"committed"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// BatchUsers godoc
+// @Summary Create, update or delete users in bulk
+// @Description Apply one action to many users. Each item is reported separately; failed items do not stop the others unless atomic is set, in which case nothing is committed if any item fails.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param batch body BatchUsersRequest true "Batch operation"
+// @Success 200 {object} BatchUsersResponse "All items succeeded"
+// @Success 207 {object} BatchUsersResponse "Some items failed"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users:batch [post]
+func (h *UserHandler) BatchUsers(w http.ResponseWriter, r *http.Request) {
+	var req BatchUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := h.validator.Validate(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) > maxBatchItems {
+		http.Error(w, fmt.Sprintf("A batch may contain at most %d items", maxBatchItems), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]BatchItemResult, len(req.Items))
+	for i := range results {
+		results[i].Index = i
+	}
+
+	// Items that cannot be decoded or validated fail without reaching the
+	// service; an atomic batch with such items is not attempted at all
+	var (
+		users []*models.User
+		ids   []uint
+		valid []int
+	)
+	switch req.Action {
+	case BatchActionCreate:
+		users, valid = h.decodeBatchCreates(req.Items, results)
+	case BatchActionUpdate:
+		users, valid = h.decodeBatchUpdates(r, req.Items, results)
+	case BatchActionDelete:
+		ids, valid = h.decodeBatchDeletes(r, req.Items, results)
+	}
+
+	var result ports.BatchResult
+	if len(valid) == len(req.Items) || !req.Atomic {
+		var err error
+		switch req.Action {
+		case BatchActionCreate:
+			result, err = h.userService.CreateMany(r.Context(), users, req.Atomic)
+		case BatchActionUpdate:
+			result, err = h.userService.UpdateMany(r.Context(), users, req.Atomic)
+		case BatchActionDelete:
+			result, err = h.userService.DeleteMany(r.Context(), ids, req.Atomic)
+		}
+		if err != nil {
+			logger.Error("Failed to process user batch", zap.String("action", req.Action), zap.Error(err))
+			serverError(w, err, "Internal server error")
+			return
+		}
+	}
+
+	for j, i := range valid {
+		if j < len(result.Errors) && result.Errors[j] != nil {
+			results[i].Status, results[i].Error = batchItemError(result.Errors[j])
+			continue
+		}
+		if !result.Committed {
+			results[i].Status = http.StatusFailedDependency
+			results[i].Error = "rolled back because another item failed"
+			continue
+		}
+		switch req.Action {
+		case BatchActionCreate:
+			results[i].Status = http.StatusCreated
+			results[i].User = newUserResponse(users[j])
+		case BatchActionUpdate:
+			results[i].Status = http.StatusOK
+			results[i].User = newUserResponse(users[j])
+		case BatchActionDelete:
+			results[i].Status = http.StatusNoContent
+		}
+	}
+
+	response := BatchUsersResponse{
+		Action:    req.Action,
+		Atomic:    req.Atomic,
+		Committed: result.Committed,
+		Results:   results,
+	}
+	for _, item := range results {
+		if item.Error == "" {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeBatchCreates builds users from create items, recording failures in
+// results; it returns the users and the indexes of the items they came from
+func (h *UserHandler) decodeBatchCreates(items []json.RawMessage, results []BatchItemResult) ([]*models.User, []int) {
+	users := make([]*models.User, 0, len(items))
+	valid := make([]int, 0, len(items))
+	for i, raw := range items {
+		var item CreateUserRequest
+		if err := h.decodeBatchItem(raw, &item); err != nil {
+			results[i].Status, results[i].Error = http.StatusBadRequest, err.Error()
+			continue
+		}
+
+		user := &models.User{
+			Email:     item.Email,
+			FirstName: item.FirstName,
+			LastName:  item.LastName,
+			Role:      "user",
+			Active:    true,
+		}
+		if err := user.SetPassword(item.Password); err != nil {
+			logger.Error("Failed to hash password", zap.Error(err))
+			results[i].Status, results[i].Error = http.StatusInternalServerError, "Internal server error"
+			continue
+		}
+
+		users = append(users, user)
+		valid = append(valid, i)
+	}
+	return users, valid
+}
+
+// decodeBatchUpdates loads the users targeted by update items and applies
+// the changes, recording failures in results; it returns the updated users
+// and the indexes of the items they came from
+func (h *UserHandler) decodeBatchUpdates(r *http.Request, items []json.RawMessage, results []BatchItemResult) ([]*models.User, []int) {
+	users := make([]*models.User, 0, len(items))
+	valid := make([]int, 0, len(items))
+	for i, raw := range items {
+		var item BatchUpdateUserItem
+		if err := h.decodeBatchItem(raw, &item); err != nil {
+			results[i].Status, results[i].Error = http.StatusBadRequest, err.Error()
+			continue
+		}
+
+		user, err := h.userService.GetByPublicID(r.Context(), strings.ToLower(item.ID))
+		if err != nil {
+			results[i].Status, results[i].Error = batchItemError(err)
+			continue
+		}
+		if user.Version != item.Version {
+			results[i].Status, results[i].Error = http.StatusConflict, "User has been modified"
+			continue
+		}
+
+		if item.FirstName != nil {
+			user.FirstName = *item.FirstName
+		}
+		if item.LastName != nil {
+			user.LastName = *item.LastName
+		}
+		if item.Active != nil {
+			user.Active = *item.Active
+		}
+
+		users = append(users, &user)
+		valid = append(valid, i)
+	}
+	return users, valid
+}
+
+// decodeBatchDeletes resolves the public IDs of delete items, recording
+// failures in results; it returns the IDs and the indexes of the items they
+// came from
+func (h *UserHandler) decodeBatchDeletes(r *http.Request, items []json.RawMessage, results []BatchItemResult) ([]uint, []int) {
+	ids := make([]uint, 0, len(items))
+	valid := make([]int, 0, len(items))
+	for i, raw := range items {
+		var item BatchDeleteUserItem
+		if err := h.decodeBatchItem(raw, &item); err != nil {
+			results[i].Status, results[i].Error = http.StatusBadRequest, err.Error()
+			continue
+		}
+		user, err := h.userService.GetByPublicID(r.Context(), strings.ToLower(item.ID))
+		if err != nil {
+			results[i].Status, results[i].Error = batchItemError(err)
+			continue
+		}
+		ids = append(ids, user.ID)
+		valid = append(valid, i)
+	}
+	return ids, valid
+}
+
+// decodeBatchItem decodes and validates one batch item
+func (h *UserHandler) decodeBatchItem(raw json.RawMessage, item any) error {
+	if err := json.Unmarshal(raw, item); err != nil {
+		return errors.New("invalid JSON")
+	}
+	return h.validator.Validate(item)
+}
+
+// batchItemError maps the error of a batch item to its status and message
+func batchItemError(err error) (int, string) {
+	switch {
+	case errors.Is(err, apperrs.ErrNotFound):
+		return http.StatusNotFound, "User not found"
+	case errors.Is(err, apperrs.ErrConflict):
+		return http.StatusConflict, "User has been modified"
+	case errors.Is(err, apperrs.ErrAlreadyExists):
+		return http.StatusConflict, "User already exists"
+	case errors.Is(err, apperrs.ErrInvalidInput), errors.Is(err, apperrs.ErrBadRequest):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, apperrs.ErrServiceUnavailable):
+		return http.StatusServiceUnavailable, "Service temporarily unavailable"
+	default:
+		logger.Error("Failed to process batch item", zap.Error(err))
+		return http.StatusInternalServerError, "Internal server error"
+	}
+}
+
+// newUserResponse converts a user to its response representation
+func newUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:        user.PublicID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+		Active:    user.Active,
+		Version:   user.Version,
+	}
+}
@@ -1,30 +1,37 @@
 package api
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
-	"strconv"
 
-	"errors"
 	"go-server-boilerplate/internal/app/ports"
 	"go-server-boilerplate/internal/infrastructure/database/models"
-	apperrs "go-server-boilerplate/internal/pkg/errors"
-	"go-server-boilerplate/internal/pkg/logger"
 	"go-server-boilerplate/internal/pkg/middleware"
+	"go-server-boilerplate/internal/pkg/validator"
 
 	"github.com/gorilla/mux"
-	"go.uber.org/zap"
 )
 
-// UserHandler handles user-related HTTP requests
+// UserHandler handles user-related HTTP requests. The CRUD routes are
+// served by a generic CRUDHandler; CreateUser, GetUser, ListUsers,
+// UpdateUser, PatchUser and DeleteUser delegate to it and carry the API documentation.
 type UserHandler struct {
 	userService ports.Service[models.User]
+	validator   *validator.Validator
+	crud        *CRUDHandler[models.User, CreateUserRequest, UpdateUserRequest, UserResponse]
 }
 
 // NewUserHandler creates a new user handler
 func NewUserHandler(userService ports.Service[models.User]) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		validator:   validator.New(),
+		crud: NewCRUDHandler("user", "users", userService, CRUDMapper[models.User, CreateUserRequest, UpdateUserRequest, UserResponse]{
+			Create:    newUser,
+			Update:    applyUserUpdate,
+			Updatable: userUpdateRequest,
+			Response:  func(user models.User) UserResponse { return *newUserResponse(&user) },
+		}),
 	}
 }
 
@@ -36,21 +43,23 @@ type CreateUserRequest struct {
 	LastName  string `json:"last_name" validate:"required"`
 }
 
-// UpdateUserRequest represents the request to update a user
+// UpdateUserRequest holds the updatable fields of a user. A PUT body sets
+// all of them; PATCH requests are applied to the current values.
 type UpdateUserRequest struct {
-	FirstName *string `json:"first_name,omitempty"`
-	LastName  *string `json:"last_name,omitempty"`
-	Active    *bool   `json:"active,omitempty"`
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Active    bool   `json:"active"`
 }
 
 // UserResponse represents the user response
 type UserResponse struct {
-	ID        uint   `json:"id"`
+	ID        string `json:"id"`
 	Email     string `json:"email"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Role      string `json:"role"`
 	Active    bool   `json:"active"`
+	Version   uint   `json:"version"`
 }
 
 // ListUsersResponse represents the response for listing users
@@ -64,17 +73,27 @@ type ListUsersResponse struct {
 
 // RegisterUserRoutes registers user routes
 func (h *UserHandler) RegisterUserRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
-	api := router.PathPrefix("/api/v1/users").Subrouter()
+	admin := func(next http.HandlerFunc) http.Handler {
+		return authMiddleware.AuthRequiredMiddleware(authMiddleware.RoleRequired("admin")(next))
+	}
+
+	// Bulk operations are admin only; the route is registered ahead of the
+	// subrouter, whose routes cannot extend the prefix without a slash
+	router.Handle("/api/v1/users:batch", admin(h.BatchUsers)).Methods(http.MethodPost)
 
-	// Public routes
-	api.HandleFunc("", h.CreateUser).Methods(http.MethodPost)
+	api := router.PathPrefix("/api/v1/users").Subrouter()
 
-	// Protected routes
-	api.Use(authMiddleware.AuthRequiredMiddleware)
-	api.HandleFunc("", h.ListUsers).Methods(http.MethodGet)
-	api.HandleFunc("/{id:[0-9]+}", h.GetUser).Methods(http.MethodGet)
-	api.HandleFunc("/{id:[0-9]+}", h.UpdateUser).Methods(http.MethodPut, http.MethodPatch)
-	api.HandleFunc("/{id:[0-9]+}", h.DeleteUser).Methods(http.MethodDelete)
+	// Admin routes, registered ahead of /{id}
+	api.Handle("/search", admin(h.SearchUsers)).Methods(http.MethodGet)
+	api.Handle("/export", admin(h.ExportUsers)).Methods(http.MethodGet)
+	api.Handle("/import", admin(h.ImportUsers)).Methods(http.MethodPost)
+
+	// Creating a user is public, as it is how new users sign up
+	h.crud.RegisterRoutes(api,
+		WithAuth(authMiddleware, OpCreate),
+		WithFilter("role", StringFilter),
+		WithFilter("active", BoolFilter),
+	)
 }
 
 // CreateUser godoc
@@ -86,51 +105,11 @@ func (h *UserHandler) RegisterUserRoutes(router *mux.Router, authMiddleware *mid
 // @Param user body CreateUserRequest true "User information"
 // @Success 201 {object} UserResponse
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/v1/users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var req CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	// Create user model
-	user := models.User{
-		Email:     req.Email,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Role:      "user",
-		Active:    true,
-	}
-
-	// Hash password
-	if err := user.SetPassword(req.Password); err != nil {
-		logger.Error("Failed to hash password", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Create user
-	if err := h.userService.Create(r.Context(), user); err != nil {
-		logger.Error("Failed to create user", zap.Error(err))
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
-		return
-	}
-
-	// Return created user
-	response := UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
-		Active:    user.Active,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	h.crud.Create(w, r)
 }
 
 // GetUser godoc
@@ -139,44 +118,17 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "User public ID"
+// @Param If-None-Match header string false "ETag of a cached representation"
 // @Success 200 {object} UserResponse
+// @Success 304
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Entity version"
 // @Security BearerAuth
 // @Router /api/v1/users/{id} [get]
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
-	}
-
-	user, err := h.userService.GetByID(r.Context(), uint(id))
-	if err != nil {
-		if errors.Is(err, apperrs.ErrNotFound) {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		logger.Error("Failed to get user", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	response := UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
-		Active:    user.Active,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.crud.Get(w, r)
 }
 
 // ListUsers godoc
@@ -187,131 +139,61 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
+// @Param role query string false "Only users with this role"
+// @Param active query bool false "Only active or inactive users"
 // @Success 200 {object} ListUsersResponse
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /api/v1/users [get]
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	page := 1
-	pageSize := 10
-
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
-			pageSize = ps
-		}
-	}
-
-	users, total, err := h.userService.List(r.Context(), page, pageSize)
-	if err != nil {
-		logger.Error("Failed to list users", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Convert to response format
-	userResponses := make([]UserResponse, len(users))
-	for i, user := range users {
-		userResponses[i] = UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-			Role:      user.Role,
-			Active:    user.Active,
-		}
-	}
-
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
-
-	response := ListUsersResponse{
-		Users:      userResponses,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.crud.List(w, r)
 }
 
 // UpdateUser godoc
-// @Summary Update user
-// @Description Update user information
+// @Summary Replace user
+// @Description Replace the updatable fields of a user; the body must set every one of them
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "User public ID"
+// @Param If-Match header string false "ETag the update is conditional on"
 // @Param user body UpdateUserRequest true "User update information"
 // @Success 200 {object} UserResponse
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Entity version"
 // @Security BearerAuth
 // @Router /api/v1/users/{id} [put]
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
-	}
-
-	var req UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	// Get existing user
-	user, err := h.userService.GetByID(r.Context(), uint(id))
-	if err != nil {
-		if errors.Is(err, apperrs.ErrNotFound) {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		logger.Error("Failed to get user", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Update fields if provided
-	if req.FirstName != nil {
-		user.FirstName = *req.FirstName
-	}
-	if req.LastName != nil {
-		user.LastName = *req.LastName
-	}
-	if req.Active != nil {
-		user.Active = *req.Active
-	}
-
-	// Update user
-	if err := h.userService.Update(r.Context(), user); err != nil {
-		logger.Error("Failed to update user", zap.Error(err))
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
-		return
-	}
-
-	response := UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Role:      user.Role,
-		Active:    user.Active,
-	}
+	h.crud.Update(w, r)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// PatchUser godoc
+// @Summary Patch user
+// @Description Change some fields of a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) applied to its UpdateUserRequest; plain JSON is taken as a merge patch
+// @Tags users
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Param id path string true "User public ID"
+// @Param If-Match header string false "ETag the update is conditional on"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 415 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Header 200 {string} ETag "Entity version"
+// @Security BearerAuth
+// @Router /api/v1/users/{id} [patch]
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	h.crud.Patch(w, r)
 }
 
 // DeleteUser godoc
@@ -320,31 +202,47 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "User public ID"
+// @Param If-Match header string false "ETag the deletion is conditional on"
 // @Success 204
 // @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Security BearerAuth
 // @Router /api/v1/users/{id} [delete]
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr := vars["id"]
+	h.crud.Delete(w, r)
+}
 
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return
+// newUser builds a user from a create request
+func newUser(req CreateUserRequest) (*models.User, error) {
+	user := &models.User{
+		Email:     req.Email,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Role:      "user",
+		Active:    true,
 	}
-
-	if err := h.userService.Delete(r.Context(), uint(id)); err != nil {
-		if errors.Is(err, apperrs.ErrNotFound) {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		logger.Error("Failed to delete user", zap.Error(err))
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	if err := user.SetPassword(req.Password); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
+	return user, nil
+}
 
-	w.WriteHeader(http.StatusNoContent)
+// applyUserUpdate replaces the updatable fields of user with an update
+// request
+func applyUserUpdate(user *models.User, req UpdateUserRequest) error {
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Active = req.Active
+	return nil
+}
+
+// userUpdateRequest renders the updatable fields of user
+func userUpdateRequest(user models.User) UpdateUserRequest {
+	return UpdateUserRequest{
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Active:    user.Active,
+	}
 }
@@ -0,0 +1,31 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"go-server-boilerplate/internal/app/services"
+	"go-server-boilerplate/internal/infrastructure/auth"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	"go-server-boilerplate/internal/infrastructure/memory"
+	"go-server-boilerplate/internal/interfaces/api"
+	"go-server-boilerplate/internal/pkg/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUserRoutes(t *testing.T) {
+	tm := memory.NewTransactionManager()
+	service := services.NewBaseService[models.User](memory.NewRepository[models.User](tm), tm)
+	router := mux.NewRouter()
+	api.NewUserHandler(service).RegisterUserRoutes(router, middleware.NewAuthMiddleware(auth.NewJWTManager("test-secret", 1)))
+
+	// Signing up needs no token
+	body := `{"email":"ada@example.com","password":"password123","first_name":"Ada","last_name":"Lovelace"}`
+	if rec := serve(router, http.MethodPost, "/api/v1/users", body); rec.Code != http.StatusCreated {
+		t.Fatalf("expected a user to be created without a token, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodGet, "/api/v1/users", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected listing users to require a token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-server-boilerplate/internal/app/ports"
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// UserSearchResult represents a user found by a search. Highlights maps the
+// matching fields to their HTML-escaped value with matches in <mark> tags.
+type UserSearchResult struct {
+	User       UserResponse      `json:"user"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchUsersResponse represents the response for searching users
+type SearchUsersResponse struct {
+	Query      string             `json:"query"`
+	Results    []UserSearchResult `json:"results"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int                `json:"total_pages"`
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Find users by email, first or last name. Every word of the query, of at least 2 characters, must match a word of the user, its start or a misspelling of it; a whole email address also matches. Results are ranked by relevance.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} SearchUsersResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Failure 501 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/search [get]
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	searcher, ok := h.userService.(ports.SearchService[models.User])
+	if !ok {
+		http.Error(w, "Search is not available", http.StatusNotImplemented)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	page := 1
+	pageSize := 10
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	results, total, err := searcher.Search(r.Context(), query, page, pageSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperrs.ErrInvalidInput):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, apperrs.ErrBadRequest):
+			http.Error(w, "Search is not available", http.StatusNotImplemented)
+		default:
+			logger.Error("Failed to search users", zap.Error(err))
+			serverError(w, err, "Internal server error")
+		}
+		return
+	}
+
+	response := SearchUsersResponse{
+		Query:      query,
+		Results:    make([]UserSearchResult, len(results)),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+	for i, result := range results {
+		response.Results[i] = UserSearchResult{
+			User:       *newUserResponse(&result.Entity),
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
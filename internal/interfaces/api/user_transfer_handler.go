@@ -0,0 +1,523 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-server-boilerplate/internal/infrastructure/database/models"
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// exportBatchSize is the number of users read from the database at a time
+	exportBatchSize = 500
+
+	// maxImportBytes bounds the size of an import body
+	maxImportBytes = 50 << 20
+
+	// maxImportLineBytes bounds the length of an NDJSON import line
+	maxImportLineBytes = 1 << 20
+
+	// maxImportErrors bounds the number of line errors reported by an import
+	maxImportErrors = 1000
+)
+
+// Transfer formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// exportColumns are the CSV columns of an export, in order
+var exportColumns = []string{"id", "email", "first_name", "last_name", "role", "active", "last_login", "created_at", "updated_at"}
+
+// ExportedUser represents one user of an export; passwords are never exported
+type ExportedUser struct {
+	ID        string     `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"first_name"`
+	LastName  string     `json:"last_name"`
+	Role      string     `json:"role"`
+	Active    bool       `json:"active"`
+	LastLogin *time.Time `json:"last_login"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ImportUserRow represents one user of an import. Users are matched by
+// email; fields that are absent keep their stored value. A password is
+// required for users that do not exist yet.
+type ImportUserRow struct {
+	Email     string  `json:"email" validate:"required,email"`
+	FirstName *string `json:"first_name" validate:"omitempty,max=255"`
+	LastName  *string `json:"last_name" validate:"omitempty,max=255"`
+	Role      *string `json:"role" validate:"omitempty,oneof=user admin"`
+	Active    *bool   `json:"active"`
+	Password  string  `json:"password" validate:"omitempty,min=8"`
+}
+
+// ImportLineError reports a line of an import that was not applied
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Email string `json:"email,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportUsersResponse reports the outcome of an import. With dry_run set
+// the counts describe what the import would have done.
+type ImportUsersResponse struct {
+	DryRun          bool              `json:"dry_run"`
+	Created         int               `json:"created"`
+	Updated         int               `json:"updated"`
+	Unchanged       int               `json:"unchanged"`
+	Failed          int               `json:"failed"`
+	Errors          []ImportLineError `json:"errors"`
+	ErrorsTruncated bool              `json:"errors_truncated,omitempty"`
+	// Aborted is set when the body could not be read to the end; lines
+	// before the failure have been processed
+	Aborted string `json:"aborted,omitempty"`
+}
+
+// ExportUsers godoc
+// @Summary Export users
+// @Description Stream all users as CSV or newline-delimited JSON. Rows are read from the database in batches, so exports of any size use constant memory.
+// @Tags users
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Export format" Enums(csv, ndjson) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/export [get]
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = FormatCSV
+	}
+	if format != FormatCSV && format != FormatNDJSON {
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	// Large exports outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	var (
+		started bool
+		write   func(users []models.User) error
+	)
+	start := func() {
+		started = true
+		filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		if format == FormatCSV {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if format == FormatCSV {
+		cw := csv.NewWriter(w)
+		write = func(users []models.User) error {
+			if !started {
+				start()
+				if err := cw.Write(exportColumns); err != nil {
+					return err
+				}
+			}
+			for _, user := range users {
+				if err := cw.Write(exportCSVRecord(user)); err != nil {
+					return err
+				}
+			}
+			cw.Flush()
+			return cw.Error()
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		write = func(users []models.User) error {
+			if !started {
+				start()
+			}
+			for _, user := range users {
+				if err := encoder.Encode(newExportedUser(user)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	err := h.userService.ForEachBatch(r.Context(), exportBatchSize, func(users []models.User) error {
+		if err := write(users); err != nil {
+			return err
+		}
+		return http.NewResponseController(w).Flush()
+	})
+	if err == nil && !started {
+		// No users: still send the header line, or an empty body
+		err = write(nil)
+	}
+	if err != nil {
+		logger.Error("Failed to export users", zap.String("format", format), zap.Error(err))
+		if !started {
+			serverError(w, err, "Internal server error")
+			return
+		}
+		// The status is already sent; abort the connection so the client
+		// cannot mistake the truncated body for a complete export
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// ImportUsers godoc
+// @Summary Import users
+// @Description Create or update users from CSV (with a header line) or newline-delimited JSON, matching existing users by email. Each line is validated and applied on its own; failed lines are reported with their line number. With dry_run, nothing is written.
+// @Tags users
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Import format; defaults from the Content-Type" Enums(csv, ndjson)
+// @Param dry_run query bool false "Validate and report without writing"
+// @Success 200 {object} ImportUsersResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/users/import [post]
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatFromContentType(r.Header.Get("Content-Type"))
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	// Large imports outlast the server's read and write timeouts
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	var next func() (int, ImportUserRow, error)
+	switch format {
+	case FormatCSV:
+		var err error
+		if next, err = csvRows(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case FormatNDJSON:
+		next = ndjsonRows(body)
+	default:
+		http.Error(w, "format must be csv or ndjson", http.StatusBadRequest)
+		return
+	}
+
+	response := ImportUsersResponse{DryRun: dryRun, Errors: []ImportLineError{}}
+	fail := func(line int, email, message string) {
+		response.Failed++
+		if len(response.Errors) < maxImportErrors {
+			response.Errors = append(response.Errors, ImportLineError{Line: line, Email: email, Error: message})
+		} else {
+			response.ErrorsTruncated = true
+		}
+	}
+
+	seen := make(map[string]int)
+	for {
+		line, row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr *importLineError
+		if errors.As(err, &lineErr) {
+			fail(line, "", lineErr.Error())
+			continue
+		}
+		if err != nil {
+			logger.Warn("User import aborted", zap.Int("line", line), zap.Error(err))
+			response.Aborted = fmt.Sprintf("line %d: %v", line, err)
+			break
+		}
+
+		email := strings.ToLower(strings.TrimSpace(row.Email))
+		if first, ok := seen[email]; ok {
+			fail(line, row.Email, fmt.Sprintf("duplicate of line %d", first))
+			continue
+		}
+		seen[email] = line
+
+		action, err := h.importUser(r, row, dryRun)
+		if err != nil {
+			_, message := batchItemError(err)
+			fail(line, row.Email, message)
+			continue
+		}
+		switch action {
+		case importCreated:
+			response.Created++
+		case importUpdated:
+			response.Updated++
+		default:
+			response.Unchanged++
+		}
+	}
+
+	logger.Info("Imported users",
+		zap.Bool("dry_run", dryRun),
+		zap.Int("created", response.Created),
+		zap.Int("updated", response.Updated),
+		zap.Int("failed", response.Failed),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Import actions
+const (
+	importCreated   = "created"
+	importUpdated   = "updated"
+	importUnchanged = "unchanged"
+)
+
+// importUser validates a row and creates or updates the user with its
+// email, unless dryRun is set; it returns what was (or would be) done
+func (h *UserHandler) importUser(r *http.Request, row ImportUserRow, dryRun bool) (string, error) {
+	row.Email = strings.TrimSpace(row.Email)
+	if err := h.validator.Validate(row); err != nil {
+		return "", err
+	}
+
+	existing, err := h.userService.FindOneBy(r.Context(), "email", row.Email)
+	if errors.Is(err, apperrs.ErrNotFound) {
+		if row.Password == "" {
+			return "", apperrs.BadRequest("password is required for new users")
+		}
+		user := models.User{Email: row.Email, Role: "user", Active: true}
+		applyImportRow(row, &user)
+		if err := user.SetPassword(row.Password); err != nil {
+			return "", err
+		}
+		if !dryRun {
+			if err := h.userService.Create(r.Context(), &user); err != nil {
+				return "", err
+			}
+		}
+		return importCreated, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	user := existing
+	applyImportRow(row, &user)
+	if row.Password != "" && !existing.CheckPassword(row.Password) {
+		if err := user.SetPassword(row.Password); err != nil {
+			return "", err
+		}
+	}
+	if user == existing {
+		return importUnchanged, nil
+	}
+	if !dryRun {
+		if err := h.userService.Update(r.Context(), &user); err != nil {
+			return "", err
+		}
+	}
+	return importUpdated, nil
+}
+
+// applyImportRow copies the fields present in row to user
+func applyImportRow(row ImportUserRow, user *models.User) {
+	if row.FirstName != nil {
+		user.FirstName = *row.FirstName
+	}
+	if row.LastName != nil {
+		user.LastName = *row.LastName
+	}
+	if row.Role != nil {
+		user.Role = *row.Role
+	}
+	if row.Active != nil {
+		user.Active = *row.Active
+	}
+}
+
+// importLineError is a malformed line; the import goes on with the next one
+type importLineError struct {
+	message string
+}
+
+func (e *importLineError) Error() string {
+	return e.message
+}
+
+// csvRows reads the header line of a CSV import and returns a function
+// yielding the following rows with their line number, io.EOF at the end
+func csvRows(body io.Reader) (func() (int, ImportUserRow, error), error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New("CSV header must contain an email column")
+	}
+
+	return func() (int, ImportUserRow, error) {
+		record, err := reader.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return parseErr.StartLine, ImportUserRow{}, &importLineError{message: parseErr.Err.Error()}
+			}
+			return 0, ImportUserRow{}, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			return line, ImportUserRow{}, &importLineError{message: fmt.Sprintf("expected %d fields, got %d", len(header), len(record))}
+		}
+
+		field := func(name string) (string, bool) {
+			i, ok := columns[name]
+			if !ok {
+				return "", false
+			}
+			return csvUnescape(record[i]), true
+		}
+
+		var row ImportUserRow
+		row.Email, _ = field("email")
+		row.Password, _ = field("password")
+		if v, ok := field("first_name"); ok {
+			row.FirstName = &v
+		}
+		if v, ok := field("last_name"); ok {
+			row.LastName = &v
+		}
+		if v, ok := field("role"); ok && v != "" {
+			row.Role = &v
+		}
+		if v, ok := field("active"); ok && v != "" {
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				return line, row, &importLineError{message: fmt.Sprintf("invalid active value %q", v)}
+			}
+			row.Active = &active
+		}
+		return line, row, nil
+	}, nil
+}
+
+// ndjsonRows returns a function yielding the rows of an NDJSON import with
+// their line number, io.EOF at the end; blank lines are skipped
+func ndjsonRows(body io.Reader) func() (int, ImportUserRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineBytes)
+	line := 0
+
+	return func() (int, ImportUserRow, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var row ImportUserRow
+			if err := json.Unmarshal([]byte(text), &row); err != nil {
+				return line, row, &importLineError{message: "Invalid JSON"}
+			}
+			return line, row, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return line + 1, ImportUserRow{}, err
+		}
+		return line, ImportUserRow{}, io.EOF
+	}
+}
+
+// formatFromContentType maps an import Content-Type to its format
+func formatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/json":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// newExportedUser converts a user to its export representation
+func newExportedUser(user models.User) ExportedUser {
+	return ExportedUser{
+		ID:        user.PublicID,
+		Email:     user.Email,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Role:      user.Role,
+		Active:    user.Active,
+		LastLogin: user.LastLogin,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+// exportCSVRecord converts a user to a CSV record in exportColumns order
+func exportCSVRecord(user models.User) []string {
+	lastLogin := ""
+	if user.LastLogin != nil {
+		lastLogin = user.LastLogin.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		user.PublicID,
+		csvEscape(user.Email),
+		csvEscape(user.FirstName),
+		csvEscape(user.LastName),
+		csvEscape(user.Role),
+		strconv.FormatBool(user.Active),
+		lastLogin,
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		user.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// csvEscape prefixes values that spreadsheets would evaluate as formulas
+// with a quote
+func csvEscape(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvUnescape reverses csvEscape so that exports can be imported again
+func csvUnescape(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
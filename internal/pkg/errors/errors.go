@@ -125,6 +125,14 @@ func Conflict(message string) *AppError {
 	return New(fmt.Errorf("%s: %w", message, ErrConflict), http.StatusConflict)
 }
 
+// ServiceUnavailable creates a service unavailable error
+func ServiceUnavailable(message string) *AppError {
+	if message == "" {
+		return New(ErrServiceUnavailable, http.StatusServiceUnavailable)
+	}
+	return New(fmt.Errorf("%s: %w", message, ErrServiceUnavailable), http.StatusServiceUnavailable)
+}
+
 // FromError converts a standard error to an AppError
 func FromError(err error) *AppError {
 	if err == nil {
@@ -149,6 +157,8 @@ func FromError(err error) *AppError {
 		return Forbidden("")
 	case errors.Is(err, ErrConflict):
 		return Conflict("")
+	case errors.Is(err, ErrServiceUnavailable):
+		return ServiceUnavailable("")
 	default:
 		return Internal(err)
 	}
@@ -0,0 +1,123 @@
+package fieldcrypt_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-server-boilerplate/internal/pkg/fieldcrypt"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, fieldcrypt.KeySize)
+}
+
+func TestEncryptDecryptAcrossRotation(t *testing.T) {
+	old, err := fieldcrypt.NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	value, err := old.Encrypt([]byte("ada@example.com"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(value, "enc:k1:") || strings.Contains(value, "ada") {
+		t.Fatalf("encrypted value = %q", value)
+	}
+	again, _ := old.Encrypt([]byte("ada@example.com"))
+	if again == value {
+		t.Fatal("encrypting twice gave the same value")
+	}
+
+	rotated, err := fieldcrypt.NewKeyring("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	plaintext, err := rotated.Decrypt(value)
+	if err != nil || string(plaintext) != "ada@example.com" {
+		t.Fatalf("Decrypt after rotation = %q, %v", plaintext, err)
+	}
+	if strings.HasPrefix(value, rotated.PrimaryPrefix()) {
+		t.Fatal("value wrapped by k1 has the prefix of k2")
+	}
+	if old.BlindIndex("Ada@Example.com ") != rotated.BlindIndex("ada@example.com") {
+		t.Fatal("blind index changed with the encryption key or the case")
+	}
+
+	if _, err := old.Decrypt(strings.Replace(value, "enc:k1:", "enc:k2:", 1)); !errors.Is(err, fieldcrypt.ErrUnknownKey) {
+		t.Fatalf("Decrypt with unknown key: %v", err)
+	}
+	retagged, _ := rotated.Encrypt([]byte("x"))
+	if _, err := rotated.Decrypt(strings.Replace(retagged, "enc:k2:", "enc:k1:", 1)); !errors.Is(err, fieldcrypt.ErrMalformed) {
+		t.Fatalf("Decrypt with swapped key ID: %v", err)
+	}
+	if _, err := rotated.Decrypt(value[:len(value)-4]); !errors.Is(err, fieldcrypt.ErrMalformed) {
+		t.Fatalf("Decrypt truncated value: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	encode := func(key []byte) string { return base64.StdEncoding.EncodeToString(key) }
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "k2.key"), []byte(encode(testKey(2))+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := fieldcrypt.Load([]string{"k1:" + encode(testKey(1))}, dir, "k2", encode(testKey(9)))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if keyring.Primary() != "k2" {
+		t.Fatalf("primary = %q", keyring.Primary())
+	}
+
+	if _, err := fieldcrypt.Load([]string{"k1:" + encode(testKey(1))}, dir, "", encode(testKey(9))); err == nil {
+		t.Fatal("Load accepted several keys without a primary")
+	}
+	if _, err := fieldcrypt.Load([]string{"k1:" + encode(testKey(1)[:16])}, "", "", encode(testKey(9))); err == nil {
+		t.Fatal("Load accepted a short key")
+	}
+	if _, err := fieldcrypt.Load([]string{"k1:" + encode(testKey(1))}, "", "", ""); err == nil {
+		t.Fatal("Load accepted a missing blind index key")
+	}
+}
+
+func TestSearchTokens(t *testing.T) {
+	k, err := fieldcrypt.NewKeyring("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	tokens := k.SearchTokens("Ada.Lovelace@example.com", "Ada")
+	has := func(token string) bool { return strings.Contains(tokens, " "+token+" ") }
+
+	for _, word := range []string{"ada", "lovelace", "example", "com"} {
+		for _, token := range k.WordTokens(word) {
+			if !has(token) {
+				t.Errorf("missing token %s of word %q", token, word)
+			}
+		}
+	}
+	for _, token := range strings.Fields(tokens) {
+		if len(token) != 3 {
+			t.Fatalf("token %q is not truncated to %d bits", token, fieldcrypt.TokenBits)
+		}
+	}
+	if tokens != k.SearchTokens("ada", "ADA.lovelace@EXAMPLE.com") {
+		t.Errorf("tokens depend on case and order: %q", tokens)
+	}
+	if k.SearchTokens("", "--") != "" {
+		t.Error("values without words have tokens")
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	got := fieldcrypt.Trigrams("Abab")
+	want := []string{"  a", " ab", "aba", "bab", "ab "}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("Trigrams(Abab) = %q, want %q", got, want)
+	}
+}
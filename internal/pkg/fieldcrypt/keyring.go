@@ -0,0 +1,198 @@
+// Package fieldcrypt encrypts individual field values with AES-GCM envelope
+// encryption and computes blind indexes that let encrypted values be matched
+// for equality.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prefix starts every encrypted value; it is followed by the ID of the key
+// that wrapped the value's data key, a colon and the base64 payload
+const Prefix = "enc:"
+
+// KeySize is the size of keys in bytes (AES-256)
+const KeySize = 32
+
+// keyIDPattern restricts key IDs to characters that need no escaping in
+// stored values or in LIKE patterns
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,32}$`)
+
+var (
+	// ErrUnknownKey is returned when decrypting a value wrapped by a key the
+	// keyring does not hold
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrMalformed is returned when decrypting a value that is not in the
+	// expected format or fails authentication
+	ErrMalformed = errors.New("malformed encrypted value")
+)
+
+// Keyring holds the key-encryption keys, identified by key ID, and the key
+// of blind indexes. Values are encrypted with a fresh data key, which is
+// itself encrypted ("wrapped") with the primary key; older keys are kept to
+// decrypt values written before a rotation.
+type Keyring struct {
+	primary  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewKeyring creates a keyring encrypting with the key named primary. Every
+// key must be KeySize bytes; the index key must be at least KeySize bytes
+// and, unlike encryption keys, cannot be rotated without recomputing every
+// blind index.
+func NewKeyring(primary string, keys map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not in the keyring", primary)
+	}
+	if len(indexKey) < KeySize {
+		return nil, fmt.Errorf("blind index key must be at least %d bytes", KeySize)
+	}
+
+	keyring := &Keyring{
+		primary:  primary,
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+		indexKey: indexKey,
+	}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("key ID %q must be 1 to 32 letters, digits or dashes", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[id] = aead
+	}
+	return keyring, nil
+}
+
+// Primary returns the ID of the key new values are encrypted with
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// PrimaryPrefix returns the prefix of values encrypted with the primary
+// key; stored values without it need to be re-encrypted
+func (k *Keyring) PrimaryPrefix() string {
+	return Prefix + k.primary + ":"
+}
+
+// Encrypt encrypts plaintext with a fresh data key wrapped by the primary key
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	// The key ID is authenticated with the wrapped data key, so a value
+	// cannot be passed off as wrapped by another key
+	wrapper := k.keys[k.primary]
+	payload, err := seal(wrapper, nil, dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+	if payload, err = seal(data, payload, plaintext, nil); err != nil {
+		return "", err
+	}
+	return k.PrimaryPrefix() + base64.RawStdEncoding.EncodeToString(payload), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt with any key of the keyring
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, Prefix), ":")
+	if !ok || !IsEncrypted(value) {
+		return nil, ErrMalformed
+	}
+	wrapper, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+	payload, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	dataKey, rest, err := open(wrapper, payload, KeySize, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, rest, err := open(data, rest, len(rest)-data.NonceSize()-data.Overhead(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
+
+// BlindIndex returns a keyed hash of value, hex encoded, that can be stored
+// next to its encrypted form and matched for equality without decrypting.
+// Values are trimmed and lowercased first, so matches are case-insensitive.
+func (k *Keyring) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted reports whether value looks like a value returned by Encrypt;
+// anything else is plaintext written before encryption was enabled
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// newAEAD returns AES-GCM with key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal appends a random nonce and the sealed plaintext to dst
+func seal(aead cipher.AEAD, dst, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// open opens the nonce and sealed message of size plaintext bytes at the
+// start of payload, returning the plaintext and the rest of payload
+func open(aead cipher.AEAD, payload []byte, size int, additionalData []byte) ([]byte, []byte, error) {
+	end := aead.NonceSize() + size + aead.Overhead()
+	if size < 0 || len(payload) < end {
+		return nil, nil, ErrMalformed
+	}
+	nonce, sealed := payload[:aead.NonceSize()], payload[aead.NonceSize():end]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, nil, ErrMalformed
+	}
+	return plaintext, payload[end:], nil
+}
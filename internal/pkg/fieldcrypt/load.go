@@ -0,0 +1,105 @@
+package fieldcrypt
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// keyFileSuffix is the extension of key files read from a directory
+const keyFileSuffix = ".key"
+
+// Load builds a keyring from keys given as "id:base64" specs and from the
+// "<id>.key" files of dir, each holding a base64 key; dir may be empty. The
+// primary key defaults to the only key when there is one. The blind index
+// key is base64 encoded.
+func Load(specs []string, dir, primary, indexKey string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	for _, spec := range specs {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(spec), ":")
+		if !ok {
+			return nil, fmt.Errorf("encryption key must be given as id:base64key")
+		}
+		if err := addKey(keys, id, encoded); err != nil {
+			return nil, err
+		}
+	}
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+keyFileSuffix))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read encryption key: %w", err)
+			}
+			if err := addKey(keys, strings.TrimSuffix(filepath.Base(file), keyFileSuffix), string(content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+	if primary == "" {
+		if len(keys) > 1 {
+			ids := make([]string, 0, len(keys))
+			for id := range keys {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return nil, fmt.Errorf("primary encryption key must be chosen among %s", strings.Join(ids, ", "))
+		}
+		for id := range keys {
+			primary = id
+		}
+	}
+
+	index, err := decodeKey(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("blind index key: %w", err)
+	}
+	return NewKeyring(primary, keys, index)
+}
+
+// addKey decodes a base64 key and adds it to keys under id
+func addKey(keys map[string][]byte, id, encoded string) error {
+	if _, ok := keys[id]; ok {
+		return fmt.Errorf("encryption key %q is configured twice", id)
+	}
+	key, err := decodeKey(encoded)
+	if err != nil {
+		return fmt.Errorf("encryption key %q: %w", id, err)
+	}
+	keys[id] = key
+	return nil
+}
+
+// decodeKey decodes a standard base64 key, with or without padding
+func decodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimRight(strings.TrimSpace(encoded), "=")
+	key, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	return key, nil
+}
+
+// DevelopmentKeyring returns a keyring with fixed, publicly known keys, so
+// that development databases stay readable across restarts. It must never
+// protect real data.
+func DevelopmentKeyring() *Keyring {
+	key := sha256.Sum256([]byte("go-server-boilerplate development encryption key"))
+	index := sha256.Sum256([]byte("go-server-boilerplate development blind index key"))
+	keyring, err := NewKeyring("dev", map[string][]byte{"dev": key[:]}, index[:])
+	if err != nil {
+		panic(err)
+	}
+	return keyring
+}
@@ -0,0 +1,99 @@
+package fieldcrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TokenBits is the number of bits of the keyed hash kept in a search token.
+// Tokens are deliberately this short: there are far more trigrams than the
+// 4096 tokens they map to, so every token stands for several unrelated
+// trigrams and matches have to be confirmed on the decrypted values.
+const TokenBits = 12
+
+// Words splits value into its lowercased runs of letters and digits
+func Words(value string) []string {
+	return strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Trigrams returns the distinct trigrams of a lowercased word, padded like
+// pg_trgm does with two spaces in front and one behind, so that short words
+// and word starts have trigrams of their own
+func Trigrams(word string) []string {
+	runes := []rune("  " + strings.ToLower(word) + " ")
+	seen := make(map[string]bool, len(runes))
+	trigrams := make([]string, 0, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+// SearchTokens returns the search tokens of values, a blind index of their
+// trigrams that lets encrypted values be searched, misspellings included,
+// without decrypting them: the truncated keyed hash of every trigram of
+// every word of the values. The tokens are sorted, so their order reveals
+// nothing, and space separated with a space at both ends, so that each one
+// can be matched with LIKE '% token %'.
+//
+// Tokens leak less than a blind index of whole words or prefixes would, but
+// they do leak. Anyone reading them learns which rows share most of their
+// trigrams, that is which hold the same or similar words, and how often each
+// token occurs. Truncation to TokenBits bits folds every token onto several
+// trigrams, which blurs their frequencies and keeps single tokens from
+// identifying words, but an attacker with many rows and a model of the
+// language may still make educated guesses about common names and domains.
+func (k *Keyring) SearchTokens(values ...string) string {
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, word := range Words(value) {
+			for _, token := range k.WordTokens(word) {
+				seen[token] = true
+			}
+		}
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+
+	tokens := make([]string, 0, len(seen))
+	for token := range seen {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return " " + strings.Join(tokens, " ") + " "
+}
+
+// WordTokens returns the distinct search tokens of the trigrams of word
+func (k *Keyring) WordTokens(word string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, trigram := range Trigrams(word) {
+		token := k.token(trigram)
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// token returns the keyed hash of a trigram truncated to TokenBits bits, in
+// hex; the "t:" prefix keeps tokens apart from blind indexes
+func (k *Keyring) token(trigram string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte("t:" + trigram))
+	sum := binary.BigEndian.Uint32(mac.Sum(nil))
+	return fmt.Sprintf("%0*x", (TokenBits+3)/4, sum>>(32-TokenBits))
+}
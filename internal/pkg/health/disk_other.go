@@ -0,0 +1,13 @@
+//go:build !unix
+
+package health
+
+import "context"
+
+// DiskSpace returns a check that always passes: free space is only measured
+// on Unix systems
+func DiskSpace(path string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
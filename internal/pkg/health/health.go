@@ -0,0 +1,283 @@
+// Package health provides a registry of dependency health checks backing
+// the liveness, readiness and startup probes.
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Probe selects the probes a check takes part in
+type Probe uint8
+
+// Probes
+const (
+	// Liveness fails when the process is wedged and must be restarted
+	Liveness Probe = 1 << iota
+	// Readiness fails when the instance must not receive traffic
+	Readiness
+	// Startup fails until the instance has finished starting; once it has
+	// passed it is never run again
+	Startup
+)
+
+// Statuses of checks and reports
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFailing  = "failing"
+)
+
+const (
+	// DefaultTimeout bounds a check registered without WithTimeout
+	DefaultTimeout = 2 * time.Second
+
+	// DefaultCacheTTL is how long check results are reused
+	DefaultCacheTTL = time.Second
+)
+
+// errShuttingDown is reported by the readiness probe during shutdown
+var errShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc reports whether a dependency is healthy
+type CheckFunc func(ctx context.Context) error
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string        `json:"name"`
+	Status    string        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Report is the outcome of a probe: failing if a critical check fails,
+// degraded if only non-critical checks fail, ok otherwise
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// CheckOption configures a registered check
+type CheckOption func(*check)
+
+// WithTimeout bounds each run of the check
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *check) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// NonCritical makes failures of the check degrade the probe instead of
+// failing it
+func NonCritical() CheckOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// ForProbes sets the probes the check takes part in; checks take part in
+// the readiness probe only by default
+func ForProbes(probes Probe) CheckOption {
+	return func(c *check) {
+		c.probes = probes
+	}
+}
+
+// check is a registered check with its latest result
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	critical bool
+	probes   Probe
+
+	// mu serializes runs, so concurrent probes share one
+	mu   sync.Mutex
+	last Result
+}
+
+// Option configures a Registry
+type Option func(*Registry)
+
+// WithCacheTTL sets how long check results are reused, so that frequent
+// probes from several load balancers do not hammer dependencies
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.cacheTTL = ttl
+	}
+}
+
+// Registry holds the health checks of the application's components
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []*check
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		cacheTTL: DefaultCacheTTL,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register adds a check. Checks are critical, bounded by DefaultTimeout and
+// part of the readiness probe unless options say otherwise.
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	c := &check{
+		name:     name,
+		fn:       fn,
+		timeout:  DefaultTimeout,
+		critical: true,
+		probes:   Readiness,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+// Shutdown makes the readiness probe fail from now on, so that load
+// balancers stop routing traffic while in-flight requests complete
+func (r *Registry) Shutdown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether Shutdown has been called
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Run runs the checks of probe concurrently, reusing results younger than
+// the cache TTL
+func (r *Registry) Run(ctx context.Context, probe Probe) Report {
+	if probe == Startup && r.started.Load() {
+		return Report{Status: StatusOK, Checks: []Result{}}
+	}
+
+	r.mu.RLock()
+	var checks []*check
+	for _, c := range r.checks {
+		if c.probes&probe != 0 {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	if probe == Readiness && r.shuttingDown.Load() {
+		results = append(results, Result{
+			Name:      "shutdown",
+			Status:    StatusFailing,
+			Critical:  true,
+			Error:     errShuttingDown.Error(),
+			CheckedAt: r.now(),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusFailing
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	if probe == Startup && report.Status != StatusFailing {
+		r.started.Store(true)
+	}
+	return report
+}
+
+// result returns the cached result of c, running it if the result is stale
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.last.CheckedAt.IsZero() && r.now().Sub(c.last.CheckedAt) < r.cacheTTL {
+		return c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := r.now()
+	err := runCheck(ctx, c.fn)
+	result := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		Critical:  c.critical,
+		Duration:  r.now().Sub(start),
+		CheckedAt: r.now(),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		if !c.critical {
+			result.Status = StatusDegraded
+		}
+		result.Error = err.Error()
+	}
+
+	// A probe cancelled by its caller says nothing about the dependency
+	if ctx.Err() == nil || !errors.Is(context.Cause(ctx), context.Canceled) {
+		c.last = result
+	}
+	return result
+}
+
+// runCheck runs fn, giving up when ctx is done even if fn ignores it
+func runCheck(ctx context.Context, fn CheckFunc) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
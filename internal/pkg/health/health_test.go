@@ -0,0 +1,115 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-server-boilerplate/internal/pkg/health"
+)
+
+func TestReadinessStatus(t *testing.T) {
+	ctx := context.Background()
+	registry := health.NewRegistry(health.WithCacheTTL(0))
+
+	var dbErr, diskErr atomic.Value
+	dbErr.Store(errors.New(""))
+	diskErr.Store(errors.New(""))
+	check := func(v *atomic.Value) health.CheckFunc {
+		return func(ctx context.Context) error {
+			if err := v.Load().(error); err.Error() != "" {
+				return err
+			}
+			return nil
+		}
+	}
+	registry.Register("database", check(&dbErr))
+	registry.Register("disk", check(&diskErr), health.NonCritical())
+
+	if report := registry.Run(ctx, health.Readiness); report.Status != health.StatusOK || len(report.Checks) != 2 {
+		t.Fatalf("expected ok with two checks, got %+v", report)
+	}
+
+	diskErr.Store(errors.New("low on space"))
+	if report := registry.Run(ctx, health.Readiness); report.Status != health.StatusDegraded {
+		t.Fatalf("expected a non-critical failure to degrade, got %+v", report)
+	}
+
+	dbErr.Store(errors.New("connection refused"))
+	report := registry.Run(ctx, health.Readiness)
+	if report.Status != health.StatusFailing || report.Checks[0].Error != "connection refused" {
+		t.Fatalf("expected a critical failure to fail, got %+v", report)
+	}
+
+	if report := registry.Run(ctx, health.Liveness); report.Status != health.StatusOK || len(report.Checks) != 0 {
+		t.Fatalf("expected readiness checks to stay out of liveness, got %+v", report)
+	}
+}
+
+func TestResultsAreCachedAndTimedOut(t *testing.T) {
+	ctx := context.Background()
+	registry := health.NewRegistry(health.WithCacheTTL(time.Hour))
+
+	var runs atomic.Int32
+	registry.Register("slow", func(ctx context.Context) error {
+		runs.Add(1)
+		time.Sleep(time.Second)
+		return nil
+	}, health.WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	report := registry.Run(ctx, health.Readiness)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("expected the check to be cut short by its timeout")
+	}
+	if report.Status != health.StatusFailing || report.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("expected a timeout failure, got %+v", report)
+	}
+
+	registry.Run(ctx, health.Readiness)
+	if runs.Load() != 1 {
+		t.Fatalf("expected the cached result to be reused, got %d runs", runs.Load())
+	}
+}
+
+func TestShutdownFailsReadinessOnly(t *testing.T) {
+	ctx := context.Background()
+	registry := health.NewRegistry()
+	registry.Register("database", func(ctx context.Context) error { return nil })
+
+	registry.Shutdown()
+	if report := registry.Run(ctx, health.Readiness); report.Status != health.StatusFailing {
+		t.Fatalf("expected readiness to fail during shutdown, got %+v", report)
+	}
+	if report := registry.Run(ctx, health.Liveness); report.Status != health.StatusOK {
+		t.Fatalf("expected liveness to pass during shutdown, got %+v", report)
+	}
+}
+
+func TestStartupPassesOnce(t *testing.T) {
+	ctx := context.Background()
+	registry := health.NewRegistry(health.WithCacheTTL(0))
+
+	var pending atomic.Int32
+	pending.Store(1)
+	registry.Register("migrations", func(ctx context.Context) error {
+		if pending.Load() > 0 {
+			return errors.New("1 migrations pending")
+		}
+		return nil
+	}, health.ForProbes(health.Startup))
+
+	if report := registry.Run(ctx, health.Startup); report.Status != health.StatusFailing {
+		t.Fatalf("expected startup to fail while migrations are pending, got %+v", report)
+	}
+	pending.Store(0)
+	if report := registry.Run(ctx, health.Startup); report.Status != health.StatusOK {
+		t.Fatalf("expected startup to pass, got %+v", report)
+	}
+	pending.Store(1)
+	if report := registry.Run(ctx, health.Startup); report.Status != health.StatusOK || len(report.Checks) != 0 {
+		t.Fatalf("expected startup to stay passed, got %+v", report)
+	}
+}
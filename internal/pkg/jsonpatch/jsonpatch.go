@@ -0,0 +1,119 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Media types of the patch formats
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	PatchMediaType      = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned for patches that are not well formed
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrInvalidPath is returned when a patch operation refers to a location
+	// the document does not have
+	ErrInvalidPath = errors.New("invalid path")
+
+	// ErrTestFailed is returned when a test operation does not hold
+	ErrTestFailed = errors.New("test failed")
+)
+
+// decode parses a single JSON value, keeping numbers as json.Number so that
+// they are written back unchanged
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// clone returns a deep copy of a decoded JSON value
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, member := range v {
+			copied[key] = clone(member)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, element := range v {
+			copied[i] = clone(element)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// equal reports whether two decoded JSON values are equal as RFC 6902
+// defines it for test: numbers compare by value, objects regardless of
+// member order
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, member := range x {
+			other, ok := y[key]
+			if !ok || !equal(member, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errX := strconv.ParseFloat(string(x), 64)
+		fy, errY := strconv.ParseFloat(string(y), 64)
+		return errX == nil && errY == nil && fx == fy
+	default:
+		return a == b
+	}
+}
+
+// invalidPatch returns an ErrInvalidPatch error with a formatted reason
+func invalidPatch(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPatch, fmt.Sprintf(format, args...))
+}
+
+// invalidPath returns an ErrInvalidPath error with a formatted reason
+func invalidPath(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPath, fmt.Sprintf(format, args...))
+}
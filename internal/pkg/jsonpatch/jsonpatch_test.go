@@ -0,0 +1,115 @@
+package jsonpatch_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-server-boilerplate/internal/pkg/jsonpatch"
+)
+
+// sameJSON reports whether two JSON documents hold the same value
+func sameJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var a, b any
+	if err := json.Unmarshal(got, &a); err != nil {
+		t.Fatalf("result is not JSON: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &b); err != nil {
+		t.Fatalf("expected value is not JSON: %s", want)
+	}
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		got, err := jsonpatch.MergePatch([]byte(c.doc), []byte(c.patch))
+		if err != nil || !sameJSON(t, got, c.want) {
+			t.Errorf("MergePatch(%s, %s) = %s, %v; want %s", c.doc, c.patch, got, err, c.want)
+		}
+	}
+
+	if _, err := jsonpatch.MergePatch([]byte(`{}`), []byte(`{"a":`)); !errors.Is(err, jsonpatch.ErrInvalidPatch) {
+		t.Errorf("malformed merge patch: %v", err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	// Examples from RFC 6902, appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{`{"foo":null}`, `[{"op":"replace","path":"/foo","value":"x"}]`, `{"foo":"x"}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"copy","from":"/~1","path":"/a"}]`, `{"/":9,"~1":10,"a":9}`},
+		{`{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+	for _, c := range cases {
+		patch, err := jsonpatch.DecodePatch([]byte(c.patch))
+		if err != nil {
+			t.Errorf("DecodePatch(%s): %v", c.patch, err)
+			continue
+		}
+		got, err := patch.Apply([]byte(c.doc))
+		if err != nil || !sameJSON(t, got, c.want) {
+			t.Errorf("Apply(%s, %s) = %s, %v; want %s", c.doc, c.patch, got, err, c.want)
+		}
+	}
+
+	failures := []struct {
+		doc, patch string
+		want       error
+	}{
+		{`{}`, `{"op":"add"}`, jsonpatch.ErrInvalidPatch},
+		{`{}`, `[{"op":"frobnicate","path":"/a"}]`, jsonpatch.ErrInvalidPatch},
+		{`{}`, `[{"op":"add","path":"/a"}]`, jsonpatch.ErrInvalidPatch},
+		{`{}`, `[{"op":"remove","path":"a"}]`, jsonpatch.ErrInvalidPatch},
+		{`{}`, `[{"op":"remove","path":"/a~2"}]`, jsonpatch.ErrInvalidPatch},
+		{`{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, jsonpatch.ErrInvalidPatch},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, jsonpatch.ErrInvalidPath},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`, jsonpatch.ErrInvalidPath},
+		{`{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":1}]`, jsonpatch.ErrInvalidPath},
+		{`{"foo":[1]}`, `[{"op":"remove","path":"/foo/01"}]`, jsonpatch.ErrInvalidPath},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, jsonpatch.ErrTestFailed},
+		{`{"a":1}`, `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`, jsonpatch.ErrTestFailed},
+	}
+	for _, c := range failures {
+		patch, err := jsonpatch.DecodePatch([]byte(c.patch))
+		if err == nil {
+			_, err = patch.Apply([]byte(c.doc))
+		}
+		if !errors.Is(err, c.want) {
+			t.Errorf("patch %s on %s: got %v, want %v", c.patch, c.doc, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Patch is a decoded RFC 6902 JSON Patch: a sequence of operations applied
+// in order, all or nothing
+type Patch struct {
+	operations []operation
+}
+
+// operation is one decoded patch operation
+type operation struct {
+	op    string
+	path  string
+	from  string
+	value any
+}
+
+// rawOperation is an operation as found in a patch document. Pointers tell
+// missing members from empty ones; a missing value stays nil while a null
+// one holds "null".
+type rawOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// DecodePatch decodes and checks a JSON Patch document: an array of
+// operations, each with the members its op requires and well-formed paths
+func DecodePatch(data []byte) (Patch, error) {
+	var raw []rawOperation
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Patch{}, invalidPatch("a JSON Patch is an array of operations: %v", err)
+	}
+
+	operations := make([]operation, len(raw))
+	for i, r := range raw {
+		op, err := decodeOperation(r)
+		if err != nil {
+			return Patch{}, fmt.Errorf("operation %d: %w", i, err)
+		}
+		operations[i] = op
+	}
+	return Patch{operations: operations}, nil
+}
+
+// decodeOperation checks and decodes one raw operation
+func decodeOperation(r rawOperation) (operation, error) {
+	op := operation{op: r.Op}
+	switch r.Op {
+	case "add", "remove", "replace", "move", "copy", "test":
+	case "":
+		return op, invalidPatch("missing op")
+	default:
+		return op, invalidPatch("unknown op %q", r.Op)
+	}
+
+	if r.Path == nil {
+		return op, invalidPatch("missing path")
+	}
+	if _, err := parsePointer(*r.Path); err != nil {
+		return op, err
+	}
+	op.path = *r.Path
+
+	switch r.Op {
+	case "add", "replace", "test":
+		if r.Value == nil {
+			return op, invalidPatch("missing value")
+		}
+		value, err := decode(r.Value)
+		if err != nil {
+			return op, invalidPatch("value: %v", err)
+		}
+		op.value = value
+	case "move", "copy":
+		if r.From == nil {
+			return op, invalidPatch("missing from")
+		}
+		if _, err := parsePointer(*r.From); err != nil {
+			return op, err
+		}
+		if r.Op == "move" && (op.path == *r.From || strings.HasPrefix(op.path, *r.From+"/")) {
+			return op, invalidPatch("cannot move %s into itself", *r.From)
+		}
+		op.from = *r.From
+	}
+	return op, nil
+}
+
+// Apply applies the patch to doc. If an operation fails, with
+// ErrInvalidPath or ErrTestFailed, the error names it and doc is left as it
+// was.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	for i, op := range p.operations {
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.op, op.path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// apply applies the operation to doc and returns the result
+func (op operation) apply(doc any) (any, error) {
+	path, _ := parsePointer(op.path)
+	switch op.op {
+	case "add":
+		return add(doc, path, clone(op.value))
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		return update(doc, path, func(any) (any, error) {
+			return clone(op.value), nil
+		})
+	case "move":
+		from, _ := parsePointer(op.from)
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, _ := parsePointer(op.from)
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, clone(value))
+	default: // test
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, fmt.Errorf("%w: value at %s differs", ErrTestFailed, op.path)
+		}
+		return doc, nil
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference
+// tokens; the empty pointer refers to the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, invalidPatch("path %q does not start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, invalidPatch("path %q has an invalid escape", pointer)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses token as an index into an array of length n. Adding
+// may also refer to the end of the array, as n or "-".
+func arrayIndex(token string, n int, adding bool) (int, error) {
+	if token == "-" && adding {
+		return n, nil
+	}
+	if token == "" || strings.Trim(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, invalidPath("%q is not an array index", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, invalidPath("%q is not an array index", token)
+	}
+	if index > n || (index == n && !adding) {
+		return 0, invalidPath("index %d is out of bounds", index)
+	}
+	return index, nil
+}
+
+// get returns the value at path in doc
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]any:
+			member, ok := container[token]
+			if !ok {
+				return nil, invalidPath("member %q does not exist", token)
+			}
+			doc = member
+		case []any:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, invalidPath("%q is not inside an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// update replaces the existing value at path in doc with the result of fn
+// and returns the resulting document
+func update(doc any, path []string, fn func(value any) (any, error)) (any, error) {
+	if len(path) == 0 {
+		return fn(doc)
+	}
+	token, rest := path[0], path[1:]
+	switch container := doc.(type) {
+	case map[string]any:
+		member, ok := container[token]
+		if !ok {
+			return nil, invalidPath("member %q does not exist", token)
+		}
+		value, err := update(member, rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = value
+		return container, nil
+	case []any:
+		index, err := arrayIndex(token, len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := update(container[index], rest, fn)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+		return container, nil
+	default:
+		return nil, invalidPath("%q is not inside an object or array", token)
+	}
+}
+
+// add adds value at path in doc: it sets an object member, inserts into an
+// array or replaces the whole document
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	last := path[len(path)-1]
+	return update(doc, path[:len(path)-1], func(parent any) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[last] = value
+			return container, nil
+		case []any:
+			index, err := arrayIndex(last, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		default:
+			return nil, invalidPath("%q is not inside an object or array", last)
+		}
+	})
+}
+
+// remove removes the value at path from doc, returning the resulting
+// document and the removed value
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, invalidPath("the whole document cannot be removed")
+	}
+	last := path[len(path)-1]
+	var removed any
+	doc, err := update(doc, path[:len(path)-1], func(parent any) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			member, ok := container[last]
+			if !ok {
+				return nil, invalidPath("member %q does not exist", last)
+			}
+			removed = member
+			delete(container, last)
+			return container, nil
+		case []any:
+			index, err := arrayIndex(last, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = container[index]
+			return append(container[:index], container[index+1:]...), nil
+		default:
+			return nil, invalidPath("%q is not inside an object or array", last)
+		}
+	})
+	return doc, removed, err
+}
@@ -7,7 +7,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-var log *zap.Logger
+// log discards everything until Init is called, so packages can log safely
+// from tests and tools that never initialize logging
+var log = zap.NewNop()
 
 // Init initializes the logger
 func Init(level string, json bool) {
@@ -20,8 +20,9 @@ func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
 type contextKey string
 
 const (
-	contextKeyUserID contextKey = "userID"
-	contextKeyRole   contextKey = "role"
+	contextKeyUserID       contextKey = "userID"
+	contextKeyUserPublicID contextKey = "userPublicID"
+	contextKeyRole         contextKey = "role"
 )
 
 // AuthRequiredMiddleware validates JWT and injects claims into context
@@ -42,9 +43,7 @@ func (m *AuthMiddleware) AuthRequiredMiddleware(next http.Handler) http.Handler
 			http.Error(w, "invalid token", http.StatusUnauthorized)
 			return
 		}
-		ctx := context.WithValue(r.Context(), contextKeyUserID, claims.UserID)
-		ctx = context.WithValue(ctx, contextKeyRole, claims.Role)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), claims.UserID, claims.Subject, claims.Role)))
 	})
 }
 
@@ -68,6 +67,24 @@ func (m *AuthMiddleware) RoleRequiredMiddleware(next http.Handler, roles ...stri
 	})
 }
 
+// RoleRequired returns a middleware function enforcing one of the given roles,
+// suitable for use with mux.Router.Use
+func (m *AuthMiddleware) RoleRequired(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return m.RoleRequiredMiddleware(next, roles...)
+	}
+}
+
+// WithUser returns a copy of ctx carrying the authenticated user, as
+// AuthRequiredMiddleware stores it
+func WithUser(ctx context.Context, userID uint, publicID, role string) context.Context {
+	ctx = context.WithValue(ctx, contextKeyUserID, userID)
+	if publicID != "" {
+		ctx = context.WithValue(ctx, contextKeyUserPublicID, publicID)
+	}
+	return context.WithValue(ctx, contextKeyRole, role)
+}
+
 // ExtractUserIDFromContext returns user id from context
 func ExtractUserIDFromContext(ctx context.Context) (uint, bool) {
 	v := ctx.Value(contextKeyUserID)
@@ -78,6 +95,13 @@ func ExtractUserIDFromContext(ctx context.Context) (uint, bool) {
 	return id, ok
 }
 
+// ExtractUserPublicIDFromContext returns the public ID of the user from
+// context; tokens issued before it was added to them have none
+func ExtractUserPublicIDFromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(contextKeyUserPublicID).(string)
+	return s, ok
+}
+
 // ExtractRoleFromContext returns role from context
 func ExtractRoleFromContext(ctx context.Context) (string, bool) {
 	v := ctx.Value(contextKeyRole)
@@ -11,8 +11,8 @@ func Cors(next http.Handler, allowedOrigins []string) http.Handler {
 	c := corspkg.New(corspkg.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Content-Length", "Accept", "Accept-Encoding", "Authorization", "X-Request-ID"},
-		ExposedHeaders:   []string{"Content-Length", "Content-Type"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Content-Length", "Accept", "Accept-Encoding", "Authorization", "X-Request-ID", "If-Match", "If-None-Match"},
+		ExposedHeaders:   []string{"Content-Length", "Content-Type", "ETag", "Accept-Patch"},
 		AllowCredentials: true,
 	})
 	return c.Handler(next)
@@ -14,6 +14,10 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					// Deliberate abort of a response already under way
+					panic(err)
+				}
 				stack := strings.Split(string(debug.Stack()), "\n")
 				cleanStack := []string{}
 				for i := 3; i < len(stack); i++ {
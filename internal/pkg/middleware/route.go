@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type routeContextKeyType string
+
+const routeContextKey routeContextKeyType = "route"
+
+// RouteMiddleware records the method and path template of the matched route,
+// such as "GET /api/v1/users/{id}", in the request context. It must be
+// installed with mux's Router.Use so that the route is known.
+func RouteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				ctx := context.WithValue(r.Context(), routeContextKey, r.Method+" "+template)
+				r = r.WithContext(ctx)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetRouteFromContext returns the route recorded by RouteMiddleware
+func GetRouteFromContext(ctx context.Context) string {
+	if v := ctx.Value(routeContextKey); v != nil {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
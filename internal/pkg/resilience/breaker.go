@@ -0,0 +1,227 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+// Circuit breaker states
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateOpen rejects every call until the open timeout has passed
+	StateOpen
+	// StateHalfOpen lets a limited number of trial calls through; a success
+	// closes the circuit and a failure opens it again
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerOption configures a CircuitBreaker
+type BreakerOption func(*CircuitBreaker)
+
+// WithFailureThreshold sets the number of consecutive failures that open
+// the circuit
+func WithFailureThreshold(n int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		if n > 0 {
+			b.threshold = n
+		}
+	}
+}
+
+// WithOpenTimeout sets how long the circuit stays open before trial calls
+// are let through
+func WithOpenTimeout(timeout time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) {
+		if timeout > 0 {
+			b.openTimeout = timeout
+		}
+	}
+}
+
+// WithHalfOpenCalls sets the number of concurrent trial calls let through
+// while half-open
+func WithHalfOpenCalls(n int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		if n > 0 {
+			b.halfOpenCalls = n
+		}
+	}
+}
+
+// WithFailurePredicate sets which errors count as failures of the
+// dependency; by default every error does
+func WithFailurePredicate(isFailure func(err error) bool) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.isFailure = isFailure
+	}
+}
+
+// WithStateChange sets a function called, under the breaker's lock, whenever
+// the state changes
+func WithStateChange(fn func(from, to State)) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.onStateChange = fn
+	}
+}
+
+// CircuitBreaker stops calling a dependency after consecutive failures, so
+// that callers fail fast instead of piling up on timeouts, and probes it
+// again after a cooldown
+type CircuitBreaker struct {
+	name          string
+	threshold     int
+	openTimeout   time.Duration
+	halfOpenCalls int
+	isFailure     func(err error) bool
+	onStateChange func(from, to State)
+	now           func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	inFlight int
+}
+
+// NewCircuitBreaker creates a closed circuit breaker; by default it opens
+// after 5 consecutive failures and tries again after 30 seconds
+func NewCircuitBreaker(name string, opts ...BreakerOption) *CircuitBreaker {
+	b := &CircuitBreaker{
+		name:          name,
+		threshold:     5,
+		openTimeout:   30 * time.Second,
+		halfOpenCalls: 1,
+		isFailure:     func(err error) bool { return err != nil },
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Allow reports whether a call may proceed. When it returns nil the caller
+// must report the outcome of the call with Done. When the circuit is open it
+// returns an error wrapping apperrs.ErrServiceUnavailable.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return b.openError()
+		}
+		b.transition(StateHalfOpen)
+	}
+	if b.state == StateHalfOpen {
+		if b.inFlight >= b.halfOpenCalls {
+			return b.openError()
+		}
+	}
+	b.inFlight++
+	return nil
+}
+
+// Done records the outcome of a call let through by Allow
+func (b *CircuitBreaker) Done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight = max(b.inFlight-1, 0)
+	failed := err != nil && b.isFailure(err)
+
+	switch b.state {
+	case StateHalfOpen:
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.transition(StateClosed)
+		}
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+// Execute runs fn if the circuit allows it and records its outcome
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Done(err)
+	return err
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// HealthCheck fails while the circuit is open
+func (b *CircuitBreaker) HealthCheck(ctx context.Context) error {
+	if b.State() == StateOpen {
+		return fmt.Errorf("circuit breaker %q is open", b.name)
+	}
+	return nil
+}
+
+// open opens the circuit; the caller holds the lock
+func (b *CircuitBreaker) open() {
+	b.openedAt = b.now()
+	b.transition(StateOpen)
+}
+
+// transition changes the state; the caller holds the lock
+func (b *CircuitBreaker) transition(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if to != StateClosed {
+		b.failures = 0
+	}
+	if b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
+
+// openError is returned for calls rejected by the circuit
+func (b *CircuitBreaker) openError() error {
+	return fmt.Errorf("circuit breaker %q is open: %w", b.name, apperrs.ErrServiceUnavailable)
+}
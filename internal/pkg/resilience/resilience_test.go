@@ -0,0 +1,113 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apperrs "go-server-boilerplate/internal/pkg/errors"
+	"go-server-boilerplate/internal/pkg/resilience"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := resilience.RetryPolicy{
+		Attempts:  3,
+		Backoff:   resilience.Backoff{Initial: time.Millisecond},
+		Retryable: func(err error) bool { return errors.Is(err, errTransient) },
+	}
+
+	calls := 0
+	err := resilience.Retry(ctx, policy, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = resilience.Retry(ctx, policy, func(ctx context.Context) error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || calls != 3 {
+		t.Fatalf("expected the last error after 3 attempts, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	permanent := errors.New("permanent")
+	err = resilience.Retry(ctx, policy, func(ctx context.Context) error {
+		calls++
+		return permanent
+	})
+	if !errors.Is(err, permanent) || calls != 1 {
+		t.Fatalf("expected no retry of a permanent error, got %v after %d calls", err, calls)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := resilience.Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+	} {
+		if got := backoff.Delay(attempt); got != want {
+			t.Errorf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+
+	backoff.Jitter = true
+	if got := backoff.Delay(2); got < 100*time.Millisecond || got > 200*time.Millisecond {
+		t.Errorf("expected a jittered delay between 100ms and 200ms, got %s", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := resilience.NewCircuitBreaker("db",
+		resilience.WithFailureThreshold(2),
+		resilience.WithOpenTimeout(20*time.Millisecond),
+		resilience.WithFailurePredicate(func(err error) bool { return errors.Is(err, errTransient) }),
+	)
+	fail := func() error { return errTransient }
+
+	_ = breaker.Execute(fail)
+	_ = breaker.Execute(func() error { return errors.New("rejected statement") })
+	_ = breaker.Execute(fail)
+	if breaker.State() != resilience.StateClosed {
+		t.Fatalf("expected non-failures to reset the count, got %s", breaker.State())
+	}
+
+	_ = breaker.Execute(fail)
+	if breaker.State() != resilience.StateOpen {
+		t.Fatalf("expected the breaker to open, got %s", breaker.State())
+	}
+	called := false
+	err := breaker.Execute(func() error { called = true; return nil })
+	if called || !errors.Is(err, apperrs.ErrServiceUnavailable) {
+		t.Fatalf("expected an open breaker to fail fast, got %v", err)
+	}
+	if breaker.HealthCheck(context.Background()) == nil {
+		t.Fatal("expected the health check to fail while open")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected a trial call once the timeout passed, got %v", err)
+	}
+	if err := breaker.Allow(); err == nil {
+		t.Fatal("expected a single trial call while half-open")
+	}
+	breaker.Done(nil)
+	if breaker.State() != resilience.StateClosed {
+		t.Fatalf("expected a successful trial to close the breaker, got %s", breaker.State())
+	}
+}
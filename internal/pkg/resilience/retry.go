@@ -0,0 +1,81 @@
+// Package resilience provides retries with exponential backoff and a
+// circuit breaker for calls to unreliable dependencies.
+package resilience
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff computes exponentially growing delays between attempts
+type Backoff struct {
+	// Initial is the delay before the first retry
+	Initial time.Duration
+	// Max caps the delay
+	Max time.Duration
+	// Multiplier grows the delay after each attempt; values below 1 are
+	// treated as 2
+	Multiplier float64
+	// Jitter randomizes each delay between half and all of its value, so that
+	// clients failing together do not retry together
+	Jitter bool
+}
+
+// Delay returns the delay before retry number attempt, counting from 1
+func (b Backoff) Delay(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(max(attempt, 1)-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter && delay > 0 {
+		delay = delay/2 + rand.Float64()*delay/2
+	}
+	return time.Duration(delay)
+}
+
+// RetryPolicy describes how an operation is retried
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, including the first one;
+	// values below 1 are treated as 1
+	Attempts int
+	Backoff  Backoff
+	// Retryable reports whether a failure may succeed when retried; nil
+	// retries every error
+	Retryable func(err error) bool
+	// OnRetry is called before waiting for each retry
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not
+// retryable, the attempts are exhausted or ctx is done; it returns the last
+// error of fn
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	attempts := max(policy.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			return err
+		}
+
+		delay := policy.Backoff.Delay(attempt)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}